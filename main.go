@@ -1,7 +1,10 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path"
 	"strings"
@@ -12,11 +15,15 @@ import (
 	"github.com/72nd/acc/pkg/config"
 	"github.com/72nd/acc/pkg/distributed"
 	"github.com/72nd/acc/pkg/document/invoices"
+	"github.com/72nd/acc/pkg/document/payslips"
 	"github.com/72nd/acc/pkg/document/records"
 	"github.com/72nd/acc/pkg/iso20022"
 	"github.com/72nd/acc/pkg/ledger"
+	"github.com/72nd/acc/pkg/mail"
 	"github.com/72nd/acc/pkg/query"
+	"github.com/72nd/acc/pkg/report"
 	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
 	"github.com/logrusorgru/aurora"
 	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
@@ -70,6 +77,34 @@ func main() {
 			Usage: "try to retain focus when open attachment",
 		},
 	}
+	reportFlags := []cli.Flag{
+		&cli.StringFlag{
+			Name:    "format",
+			Aliases: []string{"f"},
+			Value:   "table",
+			Usage:   "output format of the report (table, csv or pdf)",
+		},
+		&cli.StringFlag{
+			Name:    "input",
+			Aliases: []string{"i"},
+			Usage:   "acc project file",
+		},
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "path for the report, prints to the terminal if not set (except for pdf)",
+		},
+	}
+	yearReportFlags := append(reportFlags, &cli.IntFlag{
+		Name:    "year",
+		Aliases: []string{"y"},
+		Usage:   "year of the report, defaults to the current year",
+	})
+	layoutFlag := &cli.StringFlag{
+		Name:    "layout",
+		Aliases: []string{"l"},
+		Usage:   "YAML file with the layout of the documents, create one with «acc invoices layout»",
+	}
 
 	app := &cli.App{
 		Name:                 "acc",
@@ -89,6 +124,24 @@ func main() {
 					return nil
 				},
 				Subcommands: []*cli.Command{
+					{
+						Name:    "credit-note",
+						Aliases: []string{"crn"},
+						Usage:   "add a credit note for an invoice",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							if c.Bool("default") {
+								s.CreditNotes = append(s.CreditNotes, schema.NewCreditNote(s.Currency))
+							} else {
+								fmt.Println(aurora.BrightMagenta("Use the --default flag to suppress interactive mode and use defaults."))
+								s.CreditNotes = append(s.CreditNotes, schema.InteractiveNewCreditNote(s, c.String("asset")))
+							}
+							s.Save()
+							return nil
+						},
+						Flags: addFlags,
+					},
 					{
 						Name:    "customer",
 						Aliases: []string{"cst"},
@@ -165,6 +218,24 @@ func main() {
 							Usage:   "add multiple expende categories in one go",
 						}),
 					},
+					{
+						Name:    "fixed-asset",
+						Aliases: []string{"fas"},
+						Usage:   "add a fixed asset to the depreciation register",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							if c.Bool("default") {
+								s.FixedAssets = append(s.FixedAssets, schema.NewFixedAsset(s.Currency))
+							} else {
+								fmt.Println(aurora.BrightMagenta("Use the --default flag to suppress interactive mode and use defaults."))
+								s.FixedAssets = append(s.FixedAssets, schema.InteractiveNewFixedAsset(s))
+							}
+							s.Save()
+							return nil
+						},
+						Flags: addFlags,
+					},
 					{
 						Name:    "invoice",
 						Aliases: []string{"inv"},
@@ -201,6 +272,24 @@ func main() {
 						},
 						Flags: addFlags,
 					},
+					{
+						Name:    "offer",
+						Aliases: []string{"off"},
+						Usage:   "add a offer for a customer",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							if c.Bool("default") {
+								s.Offers = append(s.Offers, schema.NewOffer())
+							} else {
+								fmt.Println(aurora.BrightMagenta("Use the --default flag to suppress interactive mode and use defaults."))
+								s.Offers = append(s.Offers, schema.InteractiveNewOffer(s))
+							}
+							s.Save()
+							return nil
+						},
+						Flags: addFlags,
+					},
 					{
 						Name:    "project",
 						Aliases: []string{"prj"},
@@ -219,6 +308,42 @@ func main() {
 						},
 						Flags: addFlags,
 					},
+					{
+						Name:    "recurring",
+						Aliases: []string{"rec"},
+						Usage:   "add a template for recurring expenses or invoices",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							if c.Bool("default") {
+								s.RecurringTemplates = append(s.RecurringTemplates, schema.NewRecurringTemplate(s.Currency))
+							} else {
+								fmt.Println(aurora.BrightMagenta("Use the --default flag to suppress interactive mode and use defaults."))
+								s.RecurringTemplates = append(s.RecurringTemplates, schema.InteractiveNewRecurringTemplate(s))
+							}
+							s.Save()
+							return nil
+						},
+						Flags: addFlags,
+					},
+					{
+						Name:    "salary",
+						Aliases: []string{"sal"},
+						Usage:   "add a monthly salary of an employee",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							if c.Bool("default") {
+								s.Salaries = append(s.Salaries, schema.NewSalary(s.PayrollConfig, util.NewMoney(0, s.Currency)))
+							} else {
+								fmt.Println(aurora.BrightMagenta("Use the --default flag to suppress interactive mode and use defaults."))
+								s.Salaries = append(s.Salaries, schema.InteractiveNewSalary(s))
+							}
+							s.Save()
+							return nil
+						},
+						Flags: addFlags,
+					},
 					{
 						Name:    "transaction",
 						Aliases: []string{"trn"},
@@ -517,6 +642,68 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:  "inbox",
+				Usage: "create draft expenses for new receipts in the inbox folder and complete them",
+				Action: func(c *cli.Context) error {
+					inputPath := getReadPathOrExit(c, "input", "acc project file")
+					s := config.OpenSchema(inputPath)
+					// Drafts of earlier runs are only offered once, later scans of the watch
+					// mode complete only the new drafts.
+					pending := s.Expenses.Drafts()
+					for {
+						drafts, err := s.ScanInbox(c.String("folder"))
+						if len(drafts) != 0 {
+							s.Save()
+						}
+						if err != nil {
+							logrus.Fatal(err)
+						}
+						if !c.Bool("no-complete") {
+							s.CompleteDrafts(append(pending, drafts...), c.Bool("open-attachment"), c.Bool("retain-focus"))
+						}
+						pending = nil
+						if !c.Bool("watch") {
+							return nil
+						}
+						time.Sleep(time.Duration(c.Int("interval")) * time.Second)
+					}
+				},
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "folder",
+						Usage: "inbox `FOLDER`, defaults to the folder of the inbox config",
+					},
+					&cli.StringFlag{
+						Name:    "input",
+						Aliases: []string{"i"},
+						Usage:   "acc project `FILE`",
+					},
+					&cli.IntFlag{
+						Name:  "interval",
+						Value: 10,
+						Usage: "seconds between two scans of the inbox in watch mode",
+					},
+					&cli.BoolFlag{
+						Name:  "no-complete",
+						Usage: "only create the drafts without completing them",
+					},
+					&cli.BoolFlag{
+						Name:    "open-attachment",
+						Aliases: []string{"o"},
+						Usage:   "open the receipt while completing a draft",
+					},
+					&cli.BoolFlag{
+						Name:  "retain-focus",
+						Usage: "try to retain focus when open attachment",
+					},
+					&cli.BoolFlag{
+						Name:    "watch",
+						Aliases: []string{"w"},
+						Usage:   "keep watching the inbox for new receipts until interrupted",
+					},
+				},
+			},
 			{
 				Name:  "invoices",
 				Usage: "generate simple invoices based on a project",
@@ -529,6 +716,7 @@ func main() {
 					if c.Bool("all") {
 						invoices.GenerateAllInvoices(
 							s,
+							invoices.OpenLayout(c.String("layout")),
 							c.String("output-folder"),
 							c.String("place"),
 							c.Bool("do-overwrite"),
@@ -564,152 +752,712 @@ func main() {
 						Value: "PLACE-UNSET",
 						Usage: "place where the invoice originates from",
 					},
+					layoutFlag,
 				},
-			},
-			{
-				Name:    "ledger",
-				Aliases: []string{"ldg"},
-				Usage:   "generate hledger journal",
-				Action: func(c *cli.Context) error {
-					inputPath := getReadPathOrExit(c, "input", "acc project file")
-					outputPath := getPathOrExit(c, c.Bool("force"), "transactions.journal", "output", "the journal file")
-					s := config.OpenSchema(inputPath)
-					journal := ledger.JournalFromAcc(s, c.Int("year"))
-					journal.SaveHLedgerFile(outputPath)
-					logrus.Info("journal saved as ", outputPath)
-					return nil
-				},
-				Flags: []cli.Flag{
-					&cli.BoolFlag{
-						Name:    "force",
-						Aliases: []string{"f"},
-						Usage:   "force overwrite of existing report",
-					},
-					&cli.StringFlag{
-						Name:    "input",
-						Aliases: []string{"i"},
-						Usage:   "acc project file",
-					},
-					&cli.StringFlag{
-						Name:    "output",
-						Aliases: []string{"o"},
-						Usage:   "path for the journal file",
-					},
-					&cli.BoolFlag{
-						Name:    "update",
-						Aliases: []string{"u"},
-						Usage:   "update transaction id's in expenses and invoices",
-					},
-					&cli.IntFlag{
-						Name:    "year",
-						Aliases: []string{"y"},
-						Usage:   "generate journal for specific year",
-					},
-				},
-			},
-			{
-				Name:  "new",
-				Usage: "generates a new acc project with all needed files, use sub-commands to create only a subset",
-				Action: func(c *cli.Context) error {
-					outputPath := getFolderPath(c, "output-folder", c.Bool("force"), true)
-					if !c.Bool("default") {
-						fmt.Println(aurora.BrightMagenta("assistant for new acc project, use --default for non interactive use"))
-					}
-					config.NewSchema(outputPath, c.String("logo"), true, !c.Bool("default"), c.Bool("distributed-mode"))
-					return nil
-				},
-				Flags: []cli.Flag{
-					&cli.BoolFlag{
-						Name:    "default",
-						Aliases: []string{"d", "default-values"},
-						Usage:   "use default values and do not use interactive input",
-					},
-					&cli.BoolFlag{
-						Name:    "distributed-mode",
-						Aliases: []string{"D"},
-						Usage:   "enable distributed mode",
-					},
-					&cli.BoolFlag{
-						Name:    "force",
-						Aliases: []string{"f"},
-						Usage:   "force overwrite of existing files",
-					},
-					&cli.StringFlag{
-						Name:  "logo",
-						Usage: "path to the logo file",
-					},
-					&cli.StringFlag{
-						Name:    "output-folder",
-						Aliases: []string{"output", "o"},
-						Usage:   "path to the folder where the Acc project files should be written",
-					},
-				},
-			},
-			{
-				Name:  "query",
-				Usage: "find and display elements",
 				Subcommands: []*cli.Command{
 					{
-						Name:  "types",
-						Usage: "list all available element types",
+						Name:  "layout",
+						Usage: "save the default layout of the documents as a starting point for an own letterhead",
 						Action: func(c *cli.Context) error {
-							query.AccQueryables.PPTypes()
+							outputPath := getPathOrExit(c, c.Bool("force"), "layout.yaml", "output", "the invoice layout")
+							invoices.NewLayout().Save(outputPath)
+							logrus.Info("layout saved as ", outputPath)
 							return nil
 						},
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:    "force",
+								Aliases: []string{"f"},
+								Usage:   "force overwrite of existing layout file",
+							},
+							&cli.StringFlag{
+								Name:    "output",
+								Aliases: []string{"o"},
+								Usage:   "path to the layout file",
+							},
+						},
 					},
 					{
-						Name:  "keys",
-						Usage: "list keys for given elemt type",
+						Name:  "credit",
+						Usage: "issue a credit note cancelling an invoice completely or partially",
 						Action: func(c *cli.Context) error {
-							qry, err := query.AccQueryables.QueryablesFromUserInput(c.String("types"))
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							if c.String("invoice") == "" {
+								logrus.Fatal("no invoice given, use --invoice")
+							}
+							if err := os.MkdirAll(c.String("output-folder"), os.ModePerm); err != nil {
+								logrus.Fatal("creation of document output folder failed: ", err)
+							}
+							s := config.OpenSchema(inputPath)
+							inv, err := s.Invoices.InvoiceByIdent(c.String("invoice"))
 							if err != nil {
 								logrus.Fatal(err)
 							}
-							mode := query.TableMode
-							if c.Bool("yaml") {
-								mode = query.YamlMode
+							var amount int64
+							if c.String("amount") != "" {
+								value, err := util.NewMonyFromDotNotation(c.String("amount"), inv.Amount.Currency().Code)
+								if err != nil {
+									logrus.Fatal(err)
+								}
+								amount = value.Amount()
 							}
-							qry.PPKeys(mode)
+							crn, err := s.CreditInvoice(*inv, amount, c.String("reason"), c.String("output-folder"))
+							if err != nil {
+								logrus.Fatal(err)
+							}
+							customer, err := s.Parties.CustomerByRef(inv.Customer)
+							if err != nil {
+								logrus.Fatalf("no customer found for %s: %s", inv.String(), err)
+							}
+							invoices.GenerateCreditNote(invoices.OpenLayout(c.String("layout")), s.Company, crn, *inv, *customer, c.String("place"), crn.Path)
+							s.Save()
 							return nil
 						},
 						Flags: []cli.Flag{
 							&cli.StringFlag{
-								Name:    "types",
-								Aliases: []string{"t"},
-								Usage:   "types to be filtered separated by comma, use 'acc query types' to get possibilities",
+								Name:  "amount",
+								Usage: "credited amount (ex: 100.00), defaults to the whole open amount of the invoice",
 							},
-							&cli.BoolFlag{
-								Name:  "yaml",
-								Usage: "output as YAML",
+							&cli.StringFlag{
+								Name:    "input",
+								Aliases: []string{"i"},
+								Usage:   "acc project file",
+							},
+							&cli.StringFlag{
+								Name:  "invoice",
+								Usage: "identifier of the credited invoice",
+							},
+							&cli.StringFlag{
+								Name:    "output-folder",
+								Aliases: []string{"output", "o"},
+								Value:   "invoices",
+								Usage:   "path to the folder where the credit note documents should be stored",
+							},
+							&cli.StringFlag{
+								Name:  "place",
+								Value: "PLACE-UNSET",
+								Usage: "place where the credit note originates from",
+							},
+							layoutFlag,
+							&cli.StringFlag{
+								Name:  "reason",
+								Value: "Cancellation",
+								Usage: "reason for the credit",
 							},
 						},
 					},
-				},
-				Action: func(c *cli.Context) error {
-					qry, err := query.AccQueryables.QueryablesFromUserInput(c.String("types"))
-					if err != nil {
-						logrus.Fatal(err)
-					}
-					inputPath := getReadPathOrExit(c, "input", "acc project file")
-					s := config.OpenSchema(inputPath)
-					mode := query.TableMode
-					if c.Bool("yaml") {
-						mode = query.YamlMode
-					}
-					qry.QueryAcc(s, c.String("match"), c.String("date"), c.String("select"), mode, !c.Bool("no-render"), c.Bool("strict"), c.Bool("open-attachment"))
-					return nil
-				},
-				Flags: []cli.Flag{
-					&cli.StringFlag{
-						Name:    "date",
-						Aliases: []string{"d"},
+					{
+						Name:  "reinvoice",
+						Usage: "forward billable expenses, which were not invoiced yet, to the customers",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							if err := os.MkdirAll(c.String("output-folder"), os.ModePerm); err != nil {
+								logrus.Fatal("creation of document output folder failed: ", err)
+							}
+							s := config.OpenSchema(inputPath)
+							exp := s.Expenses.ReInvoiceable()
+							if ident := c.String("customer"); ident != "" {
+								cst, err := s.Parties.CustomerByIdentifier(ident)
+								if err != nil {
+									logrus.Fatal(err)
+								}
+								exp = exp.ByCustomer(*cst)
+							}
+							if ident := c.String("project"); ident != "" {
+								prj, err := s.Projects.ProjectByIdent(ident)
+								if err != nil {
+									logrus.Fatal(err)
+								}
+								exp = exp.ByProject(*prj)
+							}
+							groups := exp.GroupByCustomerAndProject()
+							layout := invoices.OpenLayout(c.String("layout"))
+							if len(groups) == 0 {
+								logrus.Info("no billable expenses to re-invoice found")
+								return nil
+							}
+							for i := range groups {
+								customer, err := s.Parties.CustomerByRef(groups[i][0].ObligedCustomer)
+								if err != nil {
+									logrus.Errorf("no customer found for %s: %s", groups[i][0].String(), err)
+									continue
+								}
+								inv := s.ReInvoice(groups[i], c.String("output-folder"))
+								invoices.GenerateReInvoice(layout, s.Company, inv, *customer, groups[i], c.String("place"), inv.Path)
+							}
+							s.Save()
+							return nil
+						},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "customer",
+								Aliases: []string{"c"},
+								Usage:   "only re-invoice the expenses of the customer with the given identifier",
+							},
+							&cli.StringFlag{
+								Name:    "input",
+								Aliases: []string{"i"},
+								Usage:   "acc project file",
+							},
+							&cli.StringFlag{
+								Name:    "output-folder",
+								Aliases: []string{"output", "o"},
+								Value:   "invoices",
+								Usage:   "path to the folder where the invoice documents should be stored",
+							},
+							&cli.StringFlag{
+								Name:  "place",
+								Value: "PLACE-UNSET",
+								Usage: "place where the invoice originates from",
+							},
+							layoutFlag,
+							&cli.StringFlag{
+								Name:    "project",
+								Aliases: []string{"p"},
+								Usage:   "only re-invoice the expenses of the project with the given identifier",
+							},
+						},
+					},
+					{
+						Name:  "send",
+						Usage: "email the invoice documents, which weren't sent yet, to the customers",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							emlFolder := ""
+							if c.Bool("dry-run") {
+								emlFolder = c.String("eml-folder")
+							}
+							n := mail.SendInvoices(&s, c.String("invoice"), c.String("output-folder"), emlFolder, c.Bool("resend"))
+							if n == 0 {
+								logrus.Info("no invoices to send found")
+								return nil
+							}
+							if !c.Bool("dry-run") {
+								s.Save()
+							}
+							return nil
+						},
+						Flags: []cli.Flag{
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "don't send the mails but save them as .eml files for review",
+							},
+							&cli.StringFlag{
+								Name:  "eml-folder",
+								Value: "mails",
+								Usage: "path to the folder where the .eml files of a dry run should be stored",
+							},
+							&cli.StringFlag{
+								Name:    "input",
+								Aliases: []string{"i"},
+								Usage:   "acc project file",
+							},
+							&cli.StringFlag{
+								Name:  "invoice",
+								Usage: "only send the invoice with the given identifier",
+							},
+							&cli.StringFlag{
+								Name:    "output-folder",
+								Aliases: []string{"output", "o"},
+								Value:   "invoices",
+								Usage:   "path to the folder containing the invoice documents generated by acc invoices",
+							},
+							&cli.BoolFlag{
+								Name:  "resend",
+								Usage: "also send invoices which were already emailed",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:    "offers",
+				Aliases: []string{"off"},
+				Usage:   "generate offer documents and convert accepted offers into invoices",
+				Action: func(c *cli.Context) error {
+					inputPath := getReadPathOrExit(c, "input", "acc project file")
+					if err := os.MkdirAll(c.String("output-folder"), os.ModePerm); err != nil {
+						logrus.Fatal("creation of document output folder failed: ", err)
+					}
+					s := config.OpenSchema(inputPath)
+					if ident := c.String("offer"); ident != "" {
+						off, err := s.Offers.OfferByIdent(ident)
+						if err != nil {
+							logrus.Fatal(err)
+						}
+						s.Offers = schema.Offers{*off}
+					}
+					invoices.GenerateAllOffers(
+						s,
+						invoices.OpenLayout(c.String("layout")),
+						c.String("output-folder"),
+						c.String("place"),
+						c.Bool("do-overwrite"),
+					)
+					return nil
+				},
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "input",
+						Aliases: []string{"i"},
+						Usage:   "acc project file",
+					},
+					&cli.StringFlag{
+						Name:  "offer",
+						Usage: "only generate the document of the offer with the given identifier",
+					},
+					&cli.StringFlag{
+						Name:    "output-folder",
+						Aliases: []string{"output", "o"},
+						Value:   "offers",
+						Usage:   "path to the folder where the exported documents should be stored",
+					},
+					&cli.BoolFlag{
+						Name:    "do-overwrite",
+						Aliases: []string{"overwrite"},
+						Value:   false,
+						Usage:   "force overwrite existing documents",
+					},
+					&cli.StringFlag{
+						Name:  "place",
+						Value: "PLACE-UNSET",
+						Usage: "place where the offer originates from",
+					},
+					layoutFlag,
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:  "invoice",
+						Usage: "create a partial or the final invoice for an accepted offer",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							if c.String("offer") == "" {
+								logrus.Fatal("please specify the offer identifier with --offer")
+							}
+							off, err := s.Offers.OfferByIdent(c.String("offer"))
+							if err != nil {
+								logrus.Fatal(err)
+							}
+							if _, err := s.InvoiceOffer(*off, c.Float64("percentage")); err != nil {
+								logrus.Fatal(err)
+							}
+							s.Save()
+							return nil
+						},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "input",
+								Aliases: []string{"i"},
+								Usage:   "acc project file",
+							},
+							&cli.StringFlag{
+								Name:  "offer",
+								Usage: "identifier of the accepted offer",
+							},
+							&cli.Float64Flag{
+								Name:    "percentage",
+								Aliases: []string{"p"},
+								Usage:   "create a partial invoice over the given percentage of the offer, without the final invoice over the remaining amount is created",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:    "ledger",
+				Aliases: []string{"ldg"},
+				Usage:   "generate hledger, ledger-cli or beancount journal",
+				Action: func(c *cli.Context) error {
+					wrt, err := ledger.WriterFromUserInput(c.String("format"))
+					if err != nil {
+						logrus.Fatal(err)
+					}
+					inputPath := getReadPathOrExit(c, "input", "acc project file")
+					incremental := c.Bool("incremental")
+					if incremental && wrt.Extension() != (ledger.HLedgerWriter{}).Extension() {
+						logrus.Fatal("the incremental mode is only available for hledger journals")
+					}
+					outputPath := getPathOrExit(c, c.Bool("force") || incremental, fmt.Sprintf("transactions.%s", wrt.Extension()), "output", "the journal file")
+					s := config.OpenSchema(inputPath)
+					journal := ledger.JournalFromAcc(s, c.Int("year"))
+					if incremental {
+						var from, to *time.Time
+						if c.Int("year") > 0 {
+							start, end := util.DateRangeFromYear(c.Int("year"))
+							from, to = &start, &end
+						}
+						journal.UpdateHLedgerFile(outputPath, from, to)
+						logrus.Info("journal updated ", outputPath)
+						return nil
+					}
+					journal.SaveFile(outputPath, wrt)
+					logrus.Info("journal saved as ", outputPath)
+					return nil
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:      "import",
+						Aliases:   []string{"imp"},
+						Usage:     "store manual corrections of a edited hledger journal in the project",
+						ArgsUsage: "JOURNAL",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							journalPath := getReadPathOrExit(c, "", "edited journal")
+							s := config.OpenSchema(inputPath)
+							count := ledger.ImportCorrections(&s, ledger.OpenHLedgerFile(journalPath))
+							if count == 0 {
+								logrus.Info("no corrections found in ", journalPath)
+								return nil
+							}
+							s.Save()
+							logrus.Infof("stored %d corrections", count)
+							return nil
+						},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "input",
+								Aliases: []string{"i"},
+								Usage:   "acc project file",
+							},
+						},
+					},
+				},
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "force overwrite of existing report",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Value: "hledger",
+						Usage: "journal format (hledger, ledger or beancount)",
+					},
+					&cli.BoolFlag{
+						Name:  "incremental",
+						Usage: "only update the acc managed entries of an existing hledger journal",
+					},
+					&cli.StringFlag{
+						Name:    "input",
+						Aliases: []string{"i"},
+						Usage:   "acc project file",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "path for the journal file",
+					},
+					&cli.BoolFlag{
+						Name:    "update",
+						Aliases: []string{"u"},
+						Usage:   "update transaction id's in expenses and invoices",
+					},
+					&cli.IntFlag{
+						Name:    "year",
+						Aliases: []string{"y"},
+						Usage:   "generate journal for specific year",
+					},
+				},
+			},
+			{
+				Name:  "new",
+				Usage: "generates a new acc project with all needed files, use sub-commands to create only a subset",
+				Action: func(c *cli.Context) error {
+					outputPath := getFolderPath(c, "output-folder", c.Bool("force"), true)
+					if !c.Bool("default") {
+						fmt.Println(aurora.BrightMagenta("assistant for new acc project, use --default for non interactive use"))
+					}
+					config.NewSchema(outputPath, c.String("logo"), true, !c.Bool("default"), c.Bool("distributed-mode"))
+					return nil
+				},
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "default",
+						Aliases: []string{"d", "default-values"},
+						Usage:   "use default values and do not use interactive input",
+					},
+					&cli.BoolFlag{
+						Name:    "distributed-mode",
+						Aliases: []string{"D"},
+						Usage:   "enable distributed mode",
+					},
+					&cli.BoolFlag{
+						Name:    "force",
+						Aliases: []string{"f"},
+						Usage:   "force overwrite of existing files",
+					},
+					&cli.StringFlag{
+						Name:  "logo",
+						Usage: "path to the logo file",
+					},
+					&cli.StringFlag{
+						Name:    "output-folder",
+						Aliases: []string{"output", "o"},
+						Usage:   "path to the folder where the Acc project files should be written",
+					},
+				},
+			},
+			{
+				Name:  "payroll",
+				Usage: "payslips and salary certificates of the employees",
+				Action: func(c *cli.Context) error {
+					_ = cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:    "certificates",
+						Aliases: []string{"lohnausweis"},
+						Usage:   "yearly summary of the salaries per employee for the salary certificate",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							year := getYearOrCurrent(c, "year")
+							outputReport(c, s, report.SalaryCertificates(s, year), fmt.Sprintf("salary-certificates-%d.pdf", year))
+							return nil
+						},
+						Flags: yearReportFlags,
+					},
+					{
+						Name:  "payslips",
+						Usage: "generate the payslips as PDF",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							if err := os.MkdirAll(c.String("output-folder"), os.ModePerm); err != nil {
+								logrus.Fatal("creation of document output folder failed: ", err)
+							}
+							s := config.OpenSchema(inputPath)
+							salaries := s.FilterYear(c.Int("year")).Salaries
+							if c.String("month") != "" {
+								var rsl schema.Salaries
+								for i := range salaries {
+									if salaries[i].Month == c.String("month") {
+										rsl = append(rsl, salaries[i])
+									}
+								}
+								salaries = rsl
+							}
+							payslips.GenerateAllPayslips(
+								s,
+								salaries,
+								c.String("output-folder"),
+								c.String("place"),
+								c.Bool("do-overwrite"),
+							)
+							return nil
+						},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "input",
+								Aliases: []string{"i"},
+								Usage:   "acc project file",
+							},
+							&cli.StringFlag{
+								Name:    "month",
+								Aliases: []string{"m"},
+								Usage:   "only generate the payslips of the given month (YYYY-MM)",
+							},
+							&cli.StringFlag{
+								Name:    "output-folder",
+								Aliases: []string{"output", "o"},
+								Value:   "payslips",
+								Usage:   "path to the folder where the payslips should be stored",
+							},
+							&cli.BoolFlag{
+								Name:    "do-overwrite",
+								Aliases: []string{"overwrite"},
+								Value:   false,
+								Usage:   "force overwrite existing documents",
+							},
+							&cli.StringFlag{
+								Name:  "place",
+								Value: "PLACE-UNSET",
+								Usage: "place where the payslip originates from",
+							},
+							&cli.IntFlag{
+								Name:    "year",
+								Aliases: []string{"y"},
+								Usage:   "only generate the payslips of the given year",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:  "query",
+				Usage: "find and display elements",
+				Subcommands: []*cli.Command{
+					{
+						Name:      "run",
+						Usage:     "run a query saved in the project config, parameters like {{year}} are given as KEY=VALUE",
+						ArgsUsage: "NAME [KEY=VALUE...]",
+						Action: func(c *cli.Context) error {
+							if c.NArg() == 0 {
+								logrus.Fatal("no query name given, use 'acc query saved' to list the saved queries")
+							}
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							saved, err := config.OpenAcc(inputPath).Queries.QueryByName(c.Args().First())
+							if err != nil {
+								logrus.Fatal(err)
+							}
+							if rsl := util.Check(saved); !rsl.Valid() {
+								logrus.Fatal("invalid saved query: ", rsl)
+							}
+							params, err := query.ParametersFromUserInput(c.Args().Tail())
+							if err != nil {
+								logrus.Fatal(err)
+							}
+							qry, err := saved.Apply(params)
+							if err != nil {
+								logrus.Fatal(err)
+							}
+							s := config.OpenSchema(inputPath)
+							writeQueryOutput(c, func(w io.Writer) error {
+								return qry.Run(w, s, c.String("format"), !c.Bool("no-render"))
+							})
+							return nil
+						},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "format",
+								Aliases: []string{"f"},
+								Usage:   "override the output format of the query: table, yaml, csv, json or markdown",
+							},
+							&cli.StringFlag{
+								Name:    "input",
+								Aliases: []string{"i"},
+								Usage:   "acc project file",
+							},
+							&cli.BoolFlag{
+								Name:    "no-render",
+								Aliases: []string{"n"},
+								Usage:   "do not render the output values",
+							},
+							&cli.StringFlag{
+								Name:  "output",
+								Usage: "write the result to the `FILE` instead of the standard output",
+							},
+						},
+					},
+					{
+						Name:  "saved",
+						Usage: "list the queries saved in the project config",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							mode := query.TableMode
+							if c.Bool("yaml") {
+								mode = query.YamlMode
+							}
+							config.OpenAcc(inputPath).Queries.PPSaved(mode)
+							return nil
+						},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "input",
+								Aliases: []string{"i"},
+								Usage:   "acc project file",
+							},
+							&cli.BoolFlag{
+								Name:  "yaml",
+								Usage: "output as YAML",
+							},
+						},
+					},
+					{
+						Name:  "types",
+						Usage: "list all available element types",
+						Action: func(c *cli.Context) error {
+							query.AccQueryables.PPTypes()
+							return nil
+						},
+					},
+					{
+						Name:  "keys",
+						Usage: "list keys for given elemt type",
+						Action: func(c *cli.Context) error {
+							qry, err := query.AccQueryables.QueryablesFromUserInput(c.String("types"))
+							if err != nil {
+								logrus.Fatal(err)
+							}
+							mode := query.TableMode
+							if c.Bool("yaml") {
+								mode = query.YamlMode
+							}
+							qry.PPKeys(mode)
+							return nil
+						},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "types",
+								Aliases: []string{"t"},
+								Usage:   "types to be filtered separated by comma, use 'acc query types' to get possibilities",
+							},
+							&cli.BoolFlag{
+								Name:  "yaml",
+								Usage: "output as YAML",
+							},
+						},
+					},
+				},
+				Action: func(c *cli.Context) error {
+					qry, err := query.AccQueryables.QueryablesFromUserInput(c.String("types"))
+					if err != nil {
+						logrus.Fatal(err)
+					}
+					inputPath := getReadPathOrExit(c, "input", "acc project file")
+					s := config.OpenSchema(inputPath)
+					mode := query.TableMode
+					if c.Bool("yaml") {
+						mode = query.YamlMode
+					}
+					if c.String("format") != "" {
+						if mode, err = query.OutputModeFromUserInput(c.String("format")); err != nil {
+							logrus.Fatal(err)
+						}
+					}
+					agg := query.Aggregation{
+						GroupBy: c.String("group-by"),
+						Count:   c.Bool("count"),
+					}
+					if c.String("sum") != "" {
+						agg.Sum = util.EscapedSplit(c.String("sum"), ",")
+					}
+					writeQueryOutput(c, func(w io.Writer) error {
+						return qry.QueryAcc(w, s, c.String("where"), c.String("match"), c.String("date"), c.String("select"), c.String("sort"), c.Int("limit"), agg, mode, !c.Bool("no-render"), c.Bool("strict"), c.Bool("open-attachment"))
+					})
+					return nil
+				},
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "count",
+						Usage: "print the number of matching elements (per group)",
+					},
+					&cli.StringFlag{
+						Name:    "date",
+						Aliases: []string{"d"},
 						Usage:   "filter keys by date ranges key:from:to as `REGEX:YYYY-MM-DD:YYYY-MM-DD` multiple can be separated by comma",
 					},
+					&cli.StringFlag{
+						Name:    "format",
+						Aliases: []string{"f"},
+						Usage:   "output format: table (default), yaml, csv, json or markdown",
+					},
+					&cli.StringFlag{
+						Name:    "group-by",
+						Aliases: []string{"g"},
+						Usage:   "group the matching elements by the value of the `KEY` and print a summary per group",
+					},
 					&cli.StringFlag{
 						Name:    "input",
 						Aliases: []string{"i"},
 						Usage:   "acc project file",
 					},
+					&cli.IntFlag{
+						Name:  "limit",
+						Usage: "print at most `N` elements (or groups)",
+					},
 					&cli.StringFlag{
 						Name:    "match",
 						Aliases: []string{"r"},
@@ -725,20 +1473,37 @@ func main() {
 						Aliases: []string{"o"},
 						Usage:   "open attachment (experimental feature)",
 					},
+					&cli.StringFlag{
+						Name:  "output",
+						Usage: "write the result to the `FILE` instead of the standard output",
+					},
 					&cli.StringFlag{
 						Name:    "select",
 						Aliases: []string{"s"},
 						Usage:   "select displayed keys, multiple can be sperated by comma `KEY[,KEY]`",
 					},
+					&cli.StringFlag{
+						Name:  "sort",
+						Usage: "sort by `KEY[:asc|:desc]`, aggregations can be sorted by the group-by key, count or a summed key",
+					},
 					&cli.BoolFlag{
 						Name:  "strict",
 						Usage: "case sensitive matching",
 					},
+					&cli.StringFlag{
+						Name:  "sum",
+						Usage: "sum up the values (amounts per currency) of the keys `KEY[,KEY]` (per group)",
+					},
 					&cli.StringFlag{
 						Name:    "types",
 						Aliases: []string{"t"},
 						Usage:   "types to be filtered separated by comma, use 'acc query types' to get possibilities",
 					},
+					&cli.StringFlag{
+						Name:    "where",
+						Aliases: []string{"w"},
+						Usage:   "filter with a query expression (ex: 'type:expense AND amount>500 CHF AND NOT settlementTransaction:empty')",
+					},
 					&cli.BoolFlag{
 						Name:  "yaml",
 						Usage: "output as YAML",
@@ -790,6 +1555,224 @@ func main() {
 					},
 				},
 			},
+			{
+				Name:    "recurring",
+				Aliases: []string{"rcr"},
+				Usage:   "recurring expenses and invoices",
+				Action: func(c *cli.Context) error {
+					_ = cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:  "run",
+						Usage: "generate the due expenses and invoices of all recurring templates",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							until := time.Now()
+							if date := getDateOrExit(c, "until"); date != nil {
+								until = *date
+							}
+							count := s.RecurringTemplates.Run(&s, until)
+							logrus.Infof("%d records generated", count)
+							s.Save()
+							return nil
+						},
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:    "input",
+								Aliases: []string{"i"},
+								Usage:   "acc project file",
+							},
+							&cli.StringFlag{
+								Name:    "until",
+								Aliases: []string{"u"},
+								Usage:   "generate all occurrences up to this date (YYYY-MM-DD), defaults to today",
+							},
+						},
+					},
+				},
+			},
+			{
+				Name:  "report",
+				Usage: "generate financial reports without hledger",
+				Action: func(c *cli.Context) error {
+					_ = cli.ShowCommandHelp(c, c.Command.Name)
+					return nil
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:    "balance",
+						Aliases: []string{"bal"},
+						Usage:   "balance sheet at the end of a year compared with the previous year",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							year := getYearOrCurrent(c, "year")
+							tbl, err := report.BalanceSheet(s, year)
+							if err != nil {
+								logrus.Fatal(err)
+							}
+							outputReport(c, s, tbl, fmt.Sprintf("balance-sheet-%d.pdf", year))
+							return nil
+						},
+						Flags: yearReportFlags,
+					},
+					{
+						Name:    "income",
+						Aliases: []string{"inc"},
+						Usage:   "income statement of a year compared with the previous year",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							year := getYearOrCurrent(c, "year")
+							tbl, err := report.IncomeStatement(s, year)
+							if err != nil {
+								logrus.Fatal(err)
+							}
+							outputReport(c, s, tbl, fmt.Sprintf("income-statement-%d.pdf", year))
+							return nil
+						},
+						Flags: yearReportFlags,
+					},
+					{
+						Name:    "advances",
+						Aliases: []string{"adv"},
+						Usage:   "expenses advanced by employees and the open balances",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							outputReport(c, s, report.EmployeeAdvances(s), "employee-advances.pdf")
+							if folder := c.String("statements"); folder != "" {
+								if err := os.MkdirAll(folder, os.ModePerm); err != nil {
+									logrus.Fatal("creation of statement output folder failed: ", err)
+								}
+								for i := range s.Parties.Employees {
+									emp := s.Parties.Employees[i]
+									if len(s.Expenses.AdvancedBy(emp)) == 0 {
+										continue
+									}
+									pth := path.Join(folder, fmt.Sprintf("reimbursement-%s.pdf", emp.Identifier))
+									report.ReimbursementStatement(s, emp).SavePdf(s.Company, pth)
+								}
+							}
+							if pth := c.String("pain"); pth != "" {
+								date := time.Now()
+								if execution := getDateOrExit(c, "execution-date"); execution != nil {
+									date = *execution
+								}
+								pmt, err := iso20022.NewCreditTransfer(s.Company, date, iso20022.AdvancePayments(s))
+								if err != nil {
+									logrus.Fatal("payment order can't be created: ", err)
+								}
+								pmt.Save(pth)
+							}
+							return nil
+						},
+						Flags: append(reportFlags,
+							&cli.StringFlag{
+								Name:  "execution-date",
+								Usage: "requested execution date of the payment order (YYYY-MM-DD), defaults to today",
+							},
+							&cli.StringFlag{
+								Name:  "pain",
+								Usage: "save a ISO 20022 pain.001 payment order for all open balances to the given path",
+							},
+							&cli.StringFlag{
+								Name:  "statements",
+								Usage: "save a reimbursement statement PDF per employee into the given folder",
+							},
+						),
+					},
+					{
+						Name:    "assets",
+						Aliases: []string{"fas"},
+						Usage:   "depreciation schedule of the fixed assets for a year",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							year := getYearOrCurrent(c, "year")
+							outputReport(c, s, report.FixedAssetSchedule(s, year), fmt.Sprintf("fixed-assets-%d.pdf", year))
+							return nil
+						},
+						Flags: yearReportFlags,
+					},
+					{
+						Name:    "project",
+						Aliases: []string{"prj"},
+						Usage:   "spent and invoiced amounts of a project per expense category",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							if c.String("project") == "" {
+								logrus.Fatal("please specify the project identifier with --project")
+							}
+							prj, err := s.Projects.ProjectByIdent(c.String("project"))
+							if err != nil {
+								logrus.Fatal(err)
+							}
+							outputReport(c, s, report.ProjectBudget(s, *prj, c.Bool("budget")), fmt.Sprintf("project-%s.pdf", prj.Identifier))
+							return nil
+						},
+						Flags: append(yearReportFlags,
+							&cli.BoolFlag{
+								Name:    "budget",
+								Aliases: []string{"b"},
+								Usage:   "compare the expenses with the budget of the project",
+							},
+							&cli.StringFlag{
+								Name:    "project",
+								Aliases: []string{"p"},
+								Usage:   "identifier of the project",
+							},
+						),
+					},
+					{
+						Name:  "projects",
+						Usage: "revenue, expenses and margin per project",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							from := getDateOrExit(c, "from")
+							to := getDateOrExit(c, "to")
+							outputReport(c, s, report.ProjectProfitability(s, from, to), "project-profitability.pdf")
+							return nil
+						},
+						Flags: append(yearReportFlags,
+							&cli.StringFlag{
+								Name:  "from",
+								Usage: "older expenses and invoices are ignored, format YYYY-MM-DD",
+							},
+							&cli.StringFlag{
+								Name:  "to",
+								Usage: "newer expenses and invoices are ignored, format YYYY-MM-DD",
+							},
+						),
+					},
+					{
+						Name:    "receivables",
+						Aliases: []string{"aging"},
+						Usage:   "aging list of the unpaid invoices reduced by their credit notes",
+						Action: func(c *cli.Context) error {
+							inputPath := getReadPathOrExit(c, "input", "acc project file")
+							s := config.OpenSchema(inputPath)
+							date := time.Now()
+							if d := getDateOrExit(c, "date"); d != nil {
+								date = *d
+							}
+							outputReport(c, s, report.Receivables(s, date), "receivables.pdf")
+							return nil
+						},
+						Flags: append(yearReportFlags,
+							&cli.StringFlag{
+								Name:  "date",
+								Usage: "reference date of the aging list (default today), format YYYY-MM-DD",
+							},
+						),
+					},
+				},
+			},
 			{
 				Name:    "server",
 				Aliases: []string{"srv"},
@@ -820,11 +1803,27 @@ func main() {
 				Aliases: []string{"v"},
 				Usage:   "validates the current project",
 				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "apply",
+						Usage: "save the corrections of --fix instead of only showing them",
+					},
+					&cli.StringFlag{
+						Name:  "fail-on",
+						Usage: "exit with a non-zero code if there are flaws of the `LEVEL` or above (fundamental, before-import, before-merge, before-export or any)",
+					},
 					&cli.BoolFlag{
 						Name:    "force",
 						Aliases: []string{"f"},
 						Usage:   "force overwrite of existing report",
 					},
+					&cli.BoolFlag{
+						Name:  "fix",
+						Usage: "correct all flaws which don't need human judgement, shows the changes without saving them unless --apply is set",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "format of the report: text (default), json or junit",
+					},
 					&cli.StringFlag{
 						Name:    "input",
 						Aliases: []string{"i"},
@@ -833,15 +1832,57 @@ func main() {
 					&cli.StringFlag{
 						Name:    "report",
 						Aliases: []string{"r", "o"},
-						Usage:   "path for the report",
+						Usage:   "path for the report, - for the standard output",
 					},
 				},
 				Action: func(c *cli.Context) error {
 					inputPath := getReadPathOrExit(c, "input", "acc project file")
-					outputPath := getPathOrExit(c, c.Bool("force"), "acc-report.txt", "report", "the validation report")
+					format, err := util.ReportFormatFromString(c.String("format"))
+					if err != nil {
+						logrus.Fatal(err)
+					}
+					var threshold util.FlawLevel
+					if c.String("fail-on") != "" {
+						if threshold, err = util.FlawLevelFromString(c.String("fail-on")); err != nil {
+							logrus.Fatal(err)
+						}
+					}
+					outputPath := getPathOrExit(c, c.Bool("force"), fmt.Sprintf("acc-report.%s", format.Extension()), "report", "the validation report")
+					if c.Bool("apply") && !c.Bool("fix") {
+						logrus.Fatal("--apply can only be used together with --fix")
+					}
 					s := config.OpenSchema(inputPath)
-					s.ValidateAndReportProject(outputPath)
-					logrus.Info("report saved as ", outputPath)
+					if c.Bool("fix") {
+						// Fix alters the schema in place, the corrections are made on a second
+						// copy so a dry run reports the flaws of the schema as it's saved.
+						fixed := config.OpenSchema(inputPath)
+						fixes := fixed.Fix()
+						diff := os.Stdout
+						if outputPath == "-" {
+							diff = os.Stderr
+						}
+						fmt.Fprint(diff, fixes.Diff())
+						switch {
+						case len(fixes) == 0:
+							logrus.Info("nothing to fix automatically")
+						case c.Bool("apply"):
+							fixed.Save()
+							s = fixed
+							logrus.Infof("%d corrections saved", len(fixes))
+						default:
+							logrus.Infof("%d corrections found (dry run), use --fix --apply to save them", len(fixes))
+						}
+					}
+					rsl := s.ValidateAndReportProject(outputPath, format)
+					if outputPath != "-" {
+						logrus.Info("report saved as ", outputPath)
+					}
+					if c.String("fail-on") == "" {
+						return nil
+					}
+					if n := rsl.CountFlaws(threshold); n != 0 {
+						logrus.Fatalf("validation failed: %d flaw(s) with level %s or above", n, threshold)
+					}
 					return nil
 				},
 			},
@@ -969,6 +2010,49 @@ func getDateOrExit(c *cli.Context, flag string) *time.Time {
 	return &value
 }
 
+// getYearOrCurrent returns the year given by the int flag. If the flag is not set, the
+// current year is returned.
+func getYearOrCurrent(c *cli.Context, flag string) int {
+	if c.Int(flag) > 0 {
+		return c.Int(flag)
+	}
+	return time.Now().Year()
+}
+
+// outputReport writes the report in the format given by the --format flag to the path given
+// by the --output flag. The fallback path is used for PDF reports when no output is given.
+func outputReport(c *cli.Context, s schema.Schema, tbl report.Table, pdfFallback string) {
+	format, err := report.FormatFromUserInput(c.String("format"))
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	pth := c.String("output")
+	if format == report.PdfFormat && pth == "" {
+		logrus.Infof("as no path for the report is provided with -output the default value (%s) will be used", pdfFallback)
+		pth = pdfFallback
+	}
+	tbl.Output(format, s.Company, pth)
+}
+
+// writeQueryOutput runs the query and writes the result to the file given by the --output
+// flag (standard output if empty). The file is only written if the query succeeds.
+func writeQueryOutput(c *cli.Context, run func(w io.Writer) error) {
+	pth := c.String("output")
+	if pth == "" {
+		if err := run(os.Stdout); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+	var buf bytes.Buffer
+	if err := run(&buf); err != nil {
+		logrus.Fatal(err)
+	}
+	if err := ioutil.WriteFile(pth, buf.Bytes(), 0644); err != nil {
+		logrus.Fatal("error writing output file: ", err)
+	}
+}
+
 // projectFilesExist checks if there are no default project files existent.
 // If this is the case, the application will be terminated.
 func projectFilesExist(folderPath string) bool {
@@ -6,8 +6,10 @@ import (
 	"strings"
 
 	"github.com/72nd/acc/pkg/distributed"
+	"github.com/72nd/acc/pkg/query"
 	"github.com/72nd/acc/pkg/schema"
 	"github.com/72nd/acc/pkg/util"
+	"github.com/creasty/defaults"
 	"github.com/sirupsen/logrus"
 )
 
@@ -31,13 +33,22 @@ type Acc struct {
 	// Company contains the information about the organisation which uses acc.
 	Company             schema.Company       `yaml:"company" default:""`
 	JournalConfig       schema.JournalConfig `yaml:"journalConfig" default:""`
+	CreditNotesFilePath string               `yaml:"creditNotesFilePath" default:"credit-notes.yaml"`
 	Currency            string               `yaml:"currency" default:"CHF"`
 	DistributedMode     bool                 `yaml:"distributedMode" default:"false"`
 	ExpensesFilePath    string               `yaml:"expensesFilePath" default:"expenses.yaml"`
+	FixedAssetsFilePath string               `yaml:"fixedAssetsFilePath" default:"fixed-assets.yaml"`
+	InboxConfig         schema.InboxConfig   `yaml:"inboxConfig" default:""`
 	InvoicesFilePath    string               `yaml:"invoicesFilePath" default:"invoices.yaml"`
+	MailConfig          schema.MailConfig    `yaml:"mailConfig" default:""`
 	MiscRecordsFilePath string               `yaml:"miscRecordsFilePath" default:"misc.yaml"`
+	OffersFilePath      string               `yaml:"offersFilePath" default:"offers.yaml"`
 	PartiesFilePath     string               `yaml:"partiesFilePath" default:"parties.yaml"`
+	PayrollConfig       schema.PayrollConfig `yaml:"payrollConfig" default:""`
+	PayrollFilePath     string               `yaml:"payrollFilePath" default:"payroll.yaml"`
 	ProjectsFilePath    string               `yaml:"projectsFilePath" default:"projects.yaml"`
+	Queries             query.SavedQueries   `yaml:"queries,omitempty"`
+	RecurringFilePath   string               `yaml:"recurringFilePath" default:"recurring.yaml"`
 	StatementFilePath   string               `yaml:"statementFilePath" default:"bank.yaml"`
 	FileName            string               `yaml:"-"`
 }
@@ -48,6 +59,10 @@ func (a Acc) NewDistributedModeAcc(repoPath string) Acc {
 	return Acc{
 		Company:         a.Company,
 		JournalConfig:   a.JournalConfig,
+		InboxConfig:     a.InboxConfig,
+		MailConfig:      a.MailConfig,
+		PayrollConfig:   a.PayrollConfig,
+		Queries:         a.Queries,
 		Currency:        "CHF",
 		DistributedMode: true,
 		FileName:        filepath.Join(repoPath, DefaultConfigFile),
@@ -69,12 +84,20 @@ func NewSchema(folderPath, logo string, doSave, interactive, distMode bool) sche
 		Company:             cmp,
 		JournalConfig:       jrc,
 		DistributedMode:     distMode,
+		CreditNotesFilePath: schema.DefaultCreditNotesFile,
 		Currency:            "CHF",
 		ExpensesFilePath:    schema.DefaultExpensesFile,
+		FixedAssetsFilePath: schema.DefaultFixedAssetsFile,
+		InboxConfig:         schema.NewInboxConfig(),
 		InvoicesFilePath:    schema.DefaultInvoicesFile,
+		MailConfig:          schema.NewMailConfig(),
 		MiscRecordsFilePath: schema.DefaultMiscRecordsFile,
+		OffersFilePath:      schema.DefaultOffersFile,
 		PartiesFilePath:     schema.DefaultPartiesFile,
+		PayrollConfig:       schema.NewPayrollConfig(),
+		PayrollFilePath:     schema.DefaultPayrollFile,
 		ProjectsFilePath:    schema.DefaultProjectsFile,
+		RecurringFilePath:   schema.DefaultRecurringFile,
 		StatementFilePath:   schema.DefaultStatementFile,
 		FileName:            DefaultConfigFile,
 	}
@@ -126,11 +149,24 @@ func NewSchema(folderPath, logo string, doSave, interactive, distMode bool) sche
 	}
 }
 
-// OpenAcc opens a Acc saved in the json file given by the path.
+// OpenAcc opens a Acc saved in the json file given by the path. Journal options missing
+// in the file (as they were introduced in a later version of acc) get their default value.
 func OpenAcc(path string) Acc {
 	var acc Acc
 	path = util.AbsolutePathWithWD(path)
 	util.OpenYaml(&acc, path, "acc")
+	if err := defaults.Set(&acc.JournalConfig); err != nil {
+		logrus.Fatal("error setting defaults for journal config: ", err)
+	}
+	if err := defaults.Set(&acc.PayrollConfig); err != nil {
+		logrus.Fatal("error setting defaults for payroll config: ", err)
+	}
+	if err := defaults.Set(&acc.MailConfig); err != nil {
+		logrus.Fatal("error setting defaults for mail config: ", err)
+	}
+	if err := defaults.Set(&acc.InboxConfig); err != nil {
+		logrus.Fatal("error setting defaults for inbox config: ", err)
+	}
 	acc.FileName = path
 	return acc
 }
@@ -140,21 +176,35 @@ func OpenSchema(path string) schema.Schema {
 	baseFolder := filepath.Dir(util.AbsolutePathWithWD(path))
 	acc := OpenAcc(path)
 	if acc.DistributedMode {
-		return distributed.Open(baseFolder, acc.Company, acc.JournalConfig, acc.SaveSchema, acc.Currency)
+		s := distributed.Open(baseFolder, acc.Company, acc.JournalConfig, acc.SaveSchema, acc.Currency)
+		s.PayrollConfig = acc.PayrollConfig
+		s.MailConfig = acc.MailConfig
+		s.InboxConfig = acc.InboxConfig
+		s.ExpenseFolder = acc.ExpenseFolder
+		return s
 	}
 	return schema.Schema{
 		Company:             acc.Company,
+		CreditNotes:         schema.OpenCreditNotes(filepath.Join(baseFolder, acc.creditNotesFilePath())),
 		Expenses:            schema.OpenExpenses(filepath.Join(baseFolder, acc.ExpensesFilePath)),
+		FixedAssets:         schema.OpenFixedAssets(filepath.Join(baseFolder, acc.fixedAssetsFilePath())),
+		InboxConfig:         acc.InboxConfig,
 		Invoices:            schema.OpenInvoices(filepath.Join(baseFolder, acc.InvoicesFilePath)),
 		JournalConfig:       acc.JournalConfig,
 		Currency:            acc.Currency,
+		MailConfig:          acc.MailConfig,
 		MiscRecords:         schema.OpenMiscRecords(filepath.Join(baseFolder, acc.MiscRecordsFilePath)),
+		Offers:              schema.OpenOffers(filepath.Join(baseFolder, acc.offersFilePath())),
 		Parties:             schema.OpenPartiesCollection(filepath.Join(baseFolder, acc.PartiesFilePath)),
+		PayrollConfig:       acc.PayrollConfig,
 		Projects:            schema.OpenProjects(filepath.Join(baseFolder, acc.ProjectsFilePath)),
+		RecurringTemplates:  schema.OpenRecurringTemplates(filepath.Join(baseFolder, acc.recurringFilePath())),
+		Salaries:            schema.OpenSalaries(filepath.Join(baseFolder, acc.payrollFilePath())),
 		Statement:           schema.OpenBankStatement(filepath.Join(baseFolder, acc.StatementFilePath)),
 		AppendExpenseSuffix: acc.AppendExpensesSuffix,
 		AppendInvoiceSuffix: acc.AppendInvoiceSuffix,
 		BaseFolder:          baseFolder,
+		ExpenseFolder:       acc.ExpenseFolder,
 		SaveFunc:            acc.SaveSchema,
 	}
 }
@@ -178,16 +228,36 @@ func (a Acc) SaveSchema(s schema.Schema) {
 func (a Acc) SaveSchemaToFolder(s schema.Schema) {
 	a.Company = s.Company
 	a.JournalConfig = s.JournalConfig
+	a.PayrollConfig = s.PayrollConfig
+	a.MailConfig = s.MailConfig
+	a.InboxConfig = s.InboxConfig
 	a.Save(a.FileName)
 
+	s.CreditNotes.Save(filepath.Join(s.BaseFolder, a.creditNotesFilePath()))
 	s.Expenses.Save(&s, filepath.Join(s.BaseFolder, a.ExpensesFilePath))
+	s.FixedAssets.Save(filepath.Join(s.BaseFolder, a.fixedAssetsFilePath()))
 	s.Invoices.Save(filepath.Join(s.BaseFolder, a.InvoicesFilePath))
 	s.MiscRecords.Save(filepath.Join(s.BaseFolder, a.MiscRecordsFilePath))
+	s.Offers.Save(filepath.Join(s.BaseFolder, a.offersFilePath()))
 	s.Parties.Save(filepath.Join(s.BaseFolder, a.PartiesFilePath))
 	s.Projects.Save(filepath.Join(s.BaseFolder, a.ProjectsFilePath))
+	s.RecurringTemplates.Save(filepath.Join(s.BaseFolder, a.recurringFilePath()))
+	s.Salaries.Save(filepath.Join(s.BaseFolder, a.payrollFilePath()))
 	s.Statement.Save(filepath.Join(s.BaseFolder, a.StatementFilePath))
 }
 
+// ExpenseFolder returns the folder the receipt of the expense is stored in (see acc inbox).
+func (a Acc) ExpenseFolder(s schema.Schema, exp schema.Expense) string {
+	if a.DistributedMode {
+		return distributed.ExpenseFolder(s, s.BaseFolder, exp)
+	}
+	folder := a.InboxConfig.AssetFolder
+	if !filepath.IsAbs(folder) {
+		folder = filepath.Join(s.BaseFolder, folder)
+	}
+	return folder
+}
+
 // Type returns a string with the type name of the element.
 func (a Acc) Type() string {
 	return "Acc-Main"
@@ -221,9 +291,9 @@ func (a Acc) Conditions() util.Conditions {
 	}
 }
 
-// Validate the element and return the result.
+// Validate the element and its saved queries and return the result.
 func (a Acc) Validate() util.ValidateResults {
-	return []util.ValidateResult{util.Check(a)}
+	return append([]util.ValidateResult{util.Check(a)}, a.Queries.Validate()...)
 }
 
 func (a *Acc) AppendExpensesSuffix(suffix string, overwrite bool) {
@@ -244,7 +314,52 @@ func (a *Acc) AppendInvoiceSuffix(suffix string, overwrite bool) {
 	a.InvoicesFilePath = path
 }
 
+// creditNotesFilePath returns the path of the credit notes file. Projects created before
+// the credit notes were introduced have no path in their config.
+func (a Acc) creditNotesFilePath() string {
+	if a.CreditNotesFilePath == "" {
+		return schema.DefaultCreditNotesFile
+	}
+	return a.CreditNotesFilePath
+}
+
+// fixedAssetsFilePath returns the path of the fixed assets register. Projects created
+// before the register was introduced have no path in their config.
+func (a Acc) fixedAssetsFilePath() string {
+	if a.FixedAssetsFilePath == "" {
+		return schema.DefaultFixedAssetsFile
+	}
+	return a.FixedAssetsFilePath
+}
+
 func appendSuffix(file, suffix string) string {
 	ext := filepath.Ext(file)
 	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(file, ext), suffix, ext)
 }
+
+// offersFilePath returns the path of the offers file. Projects created before the offers
+// were introduced have no path in their config.
+func (a Acc) offersFilePath() string {
+	if a.OffersFilePath == "" {
+		return schema.DefaultOffersFile
+	}
+	return a.OffersFilePath
+}
+
+// payrollFilePath returns the path of the payroll file. Projects created before the
+// payroll was introduced have no path in their config.
+func (a Acc) payrollFilePath() string {
+	if a.PayrollFilePath == "" {
+		return schema.DefaultPayrollFile
+	}
+	return a.PayrollFilePath
+}
+
+// recurringFilePath returns the path of the recurring templates. Projects created before
+// the templates were introduced have no path in their config.
+func (a Acc) recurringFilePath() string {
+	if a.RecurringFilePath == "" {
+		return schema.DefaultRecurringFile
+	}
+	return a.RecurringFilePath
+}
@@ -75,10 +75,15 @@ type OpenContainer struct {
 	wg       sync.WaitGroup
 	cst      []schema.Party
 	cstMux   sync.Mutex
+	crn      schema.CreditNotes
 	emp      []schema.Party
 	empMux   sync.Mutex
 	exp      []schema.Expense
 	expMux   sync.Mutex
+	fas      schema.FixedAssets
+	off      schema.Offers
+	sal      schema.Salaries
+	rec      schema.RecurringTemplates
 	prj      ProjectFiles
 	prjMux   sync.Mutex
 	files    map[string]string
@@ -162,6 +167,16 @@ func Open(path string, cmp schema.Company, jfg schema.JournalConfig, saveFunc fu
 	wg.Add(1)
 	// wg.Add(1, "openEmployeeFile")
 	go openEmployeeFile(path, cnt, &wg)
+	wg.Add(1)
+	go openCreditNotesFile(path, cnt, &wg)
+	wg.Add(1)
+	go openFixedAssetsFile(path, cnt, &wg)
+	wg.Add(1)
+	go openOffersFile(path, cnt, &wg)
+	wg.Add(1)
+	go openPayrollFile(path, cnt, &wg)
+	wg.Add(1)
+	go openRecurringFile(path, cnt, &wg)
 	wg.Wait()
 	// wg.Wait("open")
 	cnt.Wait()
@@ -169,17 +184,22 @@ func Open(path string, cmp schema.Company, jfg schema.JournalConfig, saveFunc fu
 	return schema.Schema{
 		Currency:      currency,
 		Company:       cmp,
+		CreditNotes:   cnt.crn,
 		Expenses:      append(cnt.exp, cnt.prj.Expenses()...),
+		FixedAssets:   cnt.fas,
 		Invoices:      cnt.prj.Invoices(),
 		JournalConfig: jfg,
+		Offers:        cnt.off,
 		Parties: schema.PartiesCollection{
 			Customers: cnt.cst,
 			Employees: cnt.emp,
 		},
-		Projects:   cnt.prj.Projects(),
-		FileHashes: cnt.files,
-		SaveFunc:   saveFunc,
-		BaseFolder: path,
+		Projects:           cnt.prj.Projects(),
+		Salaries:           cnt.sal,
+		RecurringTemplates: cnt.rec,
+		FileHashes:         cnt.files,
+		SaveFunc:           saveFunc,
+		BaseFolder:         path,
 	}
 }
 
@@ -308,3 +328,78 @@ func openEmployeeFile(path string, cnt *OpenContainer, wg *sync.WaitGroup) {
 	wg.Done()
 	// wg.Done("openEmployeeFile")
 }
+
+// openFixedAssetsFile opens the optional fixed assets register in the given folder path.
+func openFixedAssetsFile(path string, cnt *OpenContainer, wg *sync.WaitGroup) {
+	fasPath := filepath.Join(path, schema.DefaultFixedAssetsFile)
+	if _, err := os.Stat(fasPath); os.IsNotExist(err) {
+		wg.Done()
+		return
+	}
+	var fas schema.FixedAssets
+	hash := schema.OpenYamlHashed(&fas, fasPath, "fixed assets file")
+	cnt.AddFile(StrTuple{fasPath, hash})
+	// Only this go-routine writes the fixed assets, no mutex needed.
+	cnt.fas = fas
+	wg.Done()
+}
+
+// openCreditNotesFile opens the optional credit notes in the given folder path.
+func openCreditNotesFile(path string, cnt *OpenContainer, wg *sync.WaitGroup) {
+	crnPath := filepath.Join(path, schema.DefaultCreditNotesFile)
+	if _, err := os.Stat(crnPath); os.IsNotExist(err) {
+		wg.Done()
+		return
+	}
+	var crn schema.CreditNotes
+	hash := schema.OpenYamlHashed(&crn, crnPath, "credit notes file")
+	cnt.AddFile(StrTuple{crnPath, hash})
+	// Only this go-routine writes the credit notes, no mutex needed.
+	cnt.crn = crn
+	wg.Done()
+}
+
+// openOffersFile opens the optional offers in the given folder path.
+func openOffersFile(path string, cnt *OpenContainer, wg *sync.WaitGroup) {
+	offPath := filepath.Join(path, schema.DefaultOffersFile)
+	if _, err := os.Stat(offPath); os.IsNotExist(err) {
+		wg.Done()
+		return
+	}
+	var off schema.Offers
+	hash := schema.OpenYamlHashed(&off, offPath, "offers file")
+	cnt.AddFile(StrTuple{offPath, hash})
+	// Only this go-routine writes the offers, no mutex needed.
+	cnt.off = off
+	wg.Done()
+}
+
+// openPayrollFile opens the optional payroll file in the given folder path.
+func openPayrollFile(path string, cnt *OpenContainer, wg *sync.WaitGroup) {
+	salPath := filepath.Join(path, schema.DefaultPayrollFile)
+	if _, err := os.Stat(salPath); os.IsNotExist(err) {
+		wg.Done()
+		return
+	}
+	var sal schema.Salaries
+	hash := schema.OpenYamlHashed(&sal, salPath, "payroll file")
+	cnt.AddFile(StrTuple{salPath, hash})
+	// Only this go-routine writes the salaries, no mutex needed.
+	cnt.sal = sal
+	wg.Done()
+}
+
+// openRecurringFile opens the optional recurring templates in the given folder path.
+func openRecurringFile(path string, cnt *OpenContainer, wg *sync.WaitGroup) {
+	recPath := filepath.Join(path, schema.DefaultRecurringFile)
+	if _, err := os.Stat(recPath); os.IsNotExist(err) {
+		wg.Done()
+		return
+	}
+	var rec schema.RecurringTemplates
+	hash := schema.OpenYamlHashed(&rec, recPath, "recurring templates file")
+	cnt.AddFile(StrTuple{recPath, hash})
+	// Only this go-routine writes the templates, no mutex needed.
+	cnt.rec = rec
+	wg.Done()
+}
@@ -3,6 +3,7 @@ package distributed
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"sync"
 
 	"github.com/72nd/acc/pkg/schema"
@@ -57,7 +58,17 @@ func Save(s schema.Schema, path string) {
 	wg.Add(1)
 	go saveEmployees(path, s.Parties.Employees, s.FileHashes, &wg)
 	wg.Add(1)
-	go saveInternalExpenses(path, s.Expenses, s.FileHashes, &wg)
+	go saveInternalExpenses(path, s, s.FileHashes, &wg)
+	wg.Add(1)
+	go saveCreditNotes(path, s.CreditNotes, s.FileHashes, &wg)
+	wg.Add(1)
+	go saveFixedAssets(path, s.FixedAssets, s.FileHashes, &wg)
+	wg.Add(1)
+	go saveOffers(path, s.Offers, s.FileHashes, &wg)
+	wg.Add(1)
+	go savePayroll(path, s.Salaries, s.FileHashes, &wg)
+	wg.Add(1)
+	go saveRecurring(path, s.RecurringTemplates, s.FileHashes, &wg)
 	wg.Wait()
 }
 
@@ -164,37 +175,95 @@ func saveProject(path string, prj ProjectFile, hashes map[string]string, wg *syn
 	wg.Done()
 }
 
-func saveInternalExpenses(path string, exp schema.Expenses, hashes map[string]string, wg *sync.WaitGroup) {
-	createNonExistingDir(filepath.Join(path, internalFolderName))
+// saveInternalExpenses saves the internal expenses by year. Expenses which don't belong to
+// an existing project are saved there as well, otherwise they would be lost.
+func saveInternalExpenses(path string, s schema.Schema, hashes map[string]string, wg *sync.WaitGroup) {
+	intFolder := filepath.Join(path, internalFolderName)
+	createNonExistingDir(intFolder)
 	var intExp schema.Expenses
-	for i := range exp {
-		if exp[i].Internal {
-			intExp = append(intExp, exp[i])
+	for i := range s.Expenses {
+		if _, _, ok := expenseProject(s, s.Expenses[i]); !ok {
+			intExp = append(intExp, s.Expenses[i])
 		}
 	}
 	sorted := intExp.SortByYear()
 
 	var expWg sync.WaitGroup
+	written := make(map[string]bool)
 	for k, v := range sorted {
 		expWg.Add(1)
-		go saveInternalYearExpenses(filepath.Join(path, internalFolderName), k, v, hashes, &expWg)
+		written[internalExpensesPath(intFolder, k)] = true
+		go saveInternalYearExpenses(intFolder, k, v, hashes, &expWg)
 	}
 	expWg.Wait()
+
+	// Files of years without any internal expense left (ex: an expense was moved to a
+	// project) are emptied, otherwise the expenses would be opened twice.
+	for _, expPath := range getMatchingFilesInPath(intFolder, regexp.MustCompile(`^expenses-(2\d\d\d|other)\.yaml$`)) {
+		if !written[expPath] {
+			schema.SaveYamlOnChange(schema.Expenses{}, expPath, "internal expenses", hashes[expPath])
+		}
+	}
 	wg.Done()
 }
 
 func saveInternalYearExpenses(path string, year int, exp schema.Expenses, hashes map[string]string, wg *sync.WaitGroup) {
-	filename := fmt.Sprintf("expenses-%d.yaml", year)
-	if year == 0 {
-		filename = "expenses-other.yaml"
-	}
-	expPath := filepath.Join(path, filename)
+	expPath := internalExpensesPath(path, year)
 	schema.SaveYamlOnChange(exp, expPath, "internal expenses", hashes[expPath])
 	wg.Done()
 }
 
+// internalExpensesPath returns the path of the file of the internal expenses of the year,
+// the year 0 is used for expenses without a valid date.
+func internalExpensesPath(path string, year int) string {
+	if year == 0 {
+		return filepath.Join(path, "expenses-other.yaml")
+	}
+	return filepath.Join(path, fmt.Sprintf("expenses-%d.yaml", year))
+}
+
 func saveEmployees(path string, emp []schema.Party, hashes map[string]string, wg *sync.WaitGroup) {
 	empPath := filepath.Join(path, employeesFileName)
 	schema.SaveYamlOnChange(emp, empPath, "employees", hashes[empPath])
 	wg.Done()
 }
+
+func saveCreditNotes(path string, crn schema.CreditNotes, hashes map[string]string, wg *sync.WaitGroup) {
+	crnPath := filepath.Join(path, schema.DefaultCreditNotesFile)
+	if _, ok := hashes[crnPath]; ok || len(crn) > 0 {
+		schema.SaveYamlOnChange(crn, crnPath, "credit notes", hashes[crnPath])
+	}
+	wg.Done()
+}
+
+func saveFixedAssets(path string, fas schema.FixedAssets, hashes map[string]string, wg *sync.WaitGroup) {
+	fasPath := filepath.Join(path, schema.DefaultFixedAssetsFile)
+	if _, ok := hashes[fasPath]; ok || len(fas) > 0 {
+		schema.SaveYamlOnChange(fas, fasPath, "fixed assets", hashes[fasPath])
+	}
+	wg.Done()
+}
+
+func saveOffers(path string, off schema.Offers, hashes map[string]string, wg *sync.WaitGroup) {
+	offPath := filepath.Join(path, schema.DefaultOffersFile)
+	if _, ok := hashes[offPath]; ok || len(off) > 0 {
+		schema.SaveYamlOnChange(off, offPath, "offers", hashes[offPath])
+	}
+	wg.Done()
+}
+
+func savePayroll(path string, sal schema.Salaries, hashes map[string]string, wg *sync.WaitGroup) {
+	salPath := filepath.Join(path, schema.DefaultPayrollFile)
+	if _, ok := hashes[salPath]; ok || len(sal) > 0 {
+		schema.SaveYamlOnChange(sal, salPath, "payroll", hashes[salPath])
+	}
+	wg.Done()
+}
+
+func saveRecurring(path string, rec schema.RecurringTemplates, hashes map[string]string, wg *sync.WaitGroup) {
+	recPath := filepath.Join(path, schema.DefaultRecurringFile)
+	if _, ok := hashes[recPath]; ok || len(rec) > 0 {
+		schema.SaveYamlOnChange(rec, recPath, "recurring templates", hashes[recPath])
+	}
+	wg.Done()
+}
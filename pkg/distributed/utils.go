@@ -7,6 +7,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/72nd/acc/pkg/schema"
 	"github.com/sirupsen/logrus"
 )
 
@@ -105,3 +106,32 @@ func createNonExistingDir(path string) {
 		logrus.Fatal("error while creating folder: ", err)
 	}
 }
+
+// ExpenseFolder returns the folder for the receipt of the expense within the base folder
+// of a distributed acc project. Receipts of expenses linked to a project are stored in the
+// project folder, all others in the internal folder.
+func ExpenseFolder(s schema.Schema, base string, exp schema.Expense) string {
+	prj, cst, ok := expenseProject(s, exp)
+	if !ok {
+		return filepath.Join(base, internalFolderName)
+	}
+	return filepath.Join(base, projectFolderName, folderName(cst.Name), folderName(prj.Name))
+}
+
+// expenseProject returns the project and customer in whose project file the expense is
+// saved. False is returned for internal expenses and if the project or its customer
+// doesn't exist, these expenses are saved in the internal folder.
+func expenseProject(s schema.Schema, exp schema.Expense) (*schema.Project, *schema.Party, bool) {
+	if exp.Internal || exp.Project.Empty() {
+		return nil, nil, false
+	}
+	prj, err := s.Projects.ProjectByRef(exp.Project)
+	if err != nil {
+		return nil, nil, false
+	}
+	cst, err := s.Parties.CustomerByRef(prj.Customer)
+	if err != nil {
+		return nil, nil, false
+	}
+	return prj, cst, true
+}
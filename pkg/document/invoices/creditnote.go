@@ -0,0 +1,63 @@
+package invoices
+
+import (
+	"fmt"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/signintech/gopdf"
+)
+
+// GenerateCreditNote generates the credit note letter for the given credit note of the
+// invoice. Place is the city where the credit note is generated.
+func GenerateCreditNote(layout Layout, company schema.Company, creditNote schema.CreditNote, invoice schema.Invoice, customer schema.Party, place, dstPath string) {
+	doc := NewInvoiceDocument(layout, place)
+	save(doc.GenerateCreditNote(company, creditNote, invoice, customer), dstPath)
+}
+
+// GenerateCreditNote generates a PDF for the given credit note and returns it as a
+// gopdf.GoPdf element.
+func (d *InvoiceDocument) GenerateCreditNote(company schema.Company, creditNote schema.CreditNote, invoice schema.Invoice, customer schema.Party) gopdf.GoPdf {
+	d.data = newLayoutData(company, customer, d.place, d.texts)
+	d.data.Document = DocumentData{
+		Identifier:    creditNote.Identifier,
+		Name:          creditNote.Name,
+		Date:          d.texts.displayDate(creditNote.Date),
+		Amount:        creditNote.Amount.Display(),
+		Reference:     invoice.Identifier,
+		ReferenceDate: d.texts.displayDate(invoice.SendDate),
+	}
+	d.data.Items = []ItemData{
+		{
+			Date:        d.texts.displayDate(invoice.SendDate),
+			Reference:   invoice.Identifier,
+			Description: invoice.Name,
+			Amount:      invoice.Amount.Display(),
+		},
+		{
+			Date:        d.texts.displayDate(creditNote.Date),
+			Reference:   creditNote.Identifier,
+			Description: creditNote.Name,
+			Amount:      fmt.Sprintf("-%s", creditNote.Amount.Display()),
+		},
+	}
+	d.letterhead()
+
+	y := d.title(fmt.Sprintf("%s %s", d.texts.CreditNote, creditNote.Identifier))
+	y = d.paragraph(y, d.data.apply("credit note intro", d.texts.CreditNoteIntro))
+	if creditNote.Reason != "" {
+		y = d.paragraph(y, fmt.Sprintf("%s: %s", d.texts.Reason, creditNote.Reason))
+	}
+
+	d.Doc.SetFontStyle("B")
+	y = d.expenseLine(y, d.texts.Date, d.texts.Reference, d.texts.Description, d.texts.Amount)
+	d.Doc.DefaultFontStyle()
+	for i := range d.data.Items {
+		item := d.data.Items[i]
+		y = d.expenseLine(y, item.Date, item.Reference, item.Description, item.Amount)
+	}
+	y = d.totalLine(y)
+	y = d.expenseLine(y, d.texts.CreditNote, "", "", creditNote.Amount.Display())
+	d.Doc.DefaultFontStyle()
+	d.paragraph(y+d.Doc.LineHeight(), d.data.apply("credit note closing", d.texts.CreditNoteClosing))
+	return d.Doc.Pdf
+}
@@ -11,7 +11,7 @@ import (
 // GenerateAllInvoices takes the schema and the place (the city where the invoice is generated) and
 // generates an invoice-letter for all invoices in the schema and save it to the given destination
 // folder.
-func GenerateAllInvoices(s schema.Schema, dstFolder, place string, doOverwrite bool) {
+func GenerateAllInvoices(s schema.Schema, layout Layout, dstFolder, place string, doOverwrite bool) {
 	nFiles := len(s.Invoices)
 	for i := range s.Invoices {
 		fileName := fmt.Sprintf("%s.pdf", s.Invoices[i].FileString())
@@ -26,13 +26,13 @@ func GenerateAllInvoices(s schema.Schema, dstFolder, place string, doOverwrite b
 			logrus.Errorf("found for invoice %s no customer (given: %s): %s", s.Invoices[i].Id, s.Invoices[i].Customer, err)
 			continue
 		}
-		GenerateInvoice(s.Company, s.Invoices[i], *customer, place, filePath)
+		GenerateInvoice(layout, s.Company, s.Invoices[i], *customer, place, filePath)
 	}
 }
 
-// GenerateInvoice generates an invoice for a given invoice record. Place is the city where the
-// invoice is generated.
-func GenerateInvoice(company schema.Company, invoice schema.Invoice, customer schema.Party, place, dstPath string) {
-	doc := NewInvoiceDocument(12, place)
+// GenerateInvoice generates an invoice for a given invoice record using the layout. Place is
+// the city where the invoice is generated.
+func GenerateInvoice(layout Layout, company schema.Company, invoice schema.Invoice, customer schema.Party, place, dstPath string) {
+	doc := NewInvoiceDocument(layout, place)
 	save(doc.Generate(company, invoice, customer), dstPath)
 }
@@ -0,0 +1,377 @@
+package invoices
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/72nd/acc/pkg/document"
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+// Layout describes the letterhead, the page setup and the fixed texts of the invoice
+// documents (invoices, offers and credit notes). Organisations can save their own layout
+// as YAML file and use it with the --layout flag. All positions are in millimetres,
+// measured from the top left corner of the A4 page.
+//
+// The content of the text blocks and some of the fixed texts are Go templates which are
+// applied to a LayoutData element. Example: `{{ .Company.Name }}, {{ .Customer.Name }}`.
+type Layout struct {
+	// FontSize is the default font size of the document.
+	FontSize int `yaml:"fontSize" default:"12"`
+	// RegularFont is the path to a TrueType font file. Uses the embedded Lato if empty.
+	RegularFont string `yaml:"regularFont" default:""`
+	// BoldFont is the path to a TrueType font file for bold text. Uses the embedded Lato if empty.
+	BoldFont string `yaml:"boldFont" default:""`
+	// Language chooses the fixed texts (ex: de, en or fr).
+	Language string `yaml:"language" default:"de"`
+	// MarginLeft is the left margin of the body.
+	MarginLeft float64 `yaml:"marginLeft" default:"20"`
+	// MarginRight is the right margin of the body.
+	MarginRight float64 `yaml:"marginRight" default:"20"`
+	// BodyTop is the vertical position where the title of the document starts.
+	BodyTop float64 `yaml:"bodyTop" default:"115"`
+	// PageBreak is the vertical position after which tables continue on a new page.
+	PageBreak float64 `yaml:"pageBreak" default:"260"`
+	// Logo places the logo of the company (see Company.Logo). Disabled if the width is zero.
+	Logo Box `yaml:"logo"`
+	// Header is the letterhead with the contact information of the company.
+	Header TextBlock `yaml:"header"`
+	// Sender is the return address above the address of the customer.
+	Sender TextBlock `yaml:"sender"`
+	// Address is the address of the customer.
+	Address TextBlock `yaml:"address"`
+	// PlaceDate states where and when the document was written.
+	PlaceDate TextBlock `yaml:"placeDate"`
+	// Footer is printed on every page, typically containing the bank details.
+	Footer TextBlock `yaml:"footer"`
+	// Texts contains the fixed texts for each language.
+	Texts map[string]Texts `yaml:"texts"`
+}
+
+// Box is a rectangle on the page.
+type Box struct {
+	X      float64 `yaml:"x"`
+	Y      float64 `yaml:"y"`
+	Width  float64 `yaml:"width"`
+	Height float64 `yaml:"height"`
+}
+
+// TextBlock is a (multiline) text at a certain position of the page.
+type TextBlock struct {
+	X float64 `yaml:"x"`
+	Y float64 `yaml:"y"`
+	// Width of the block, used for the alignment and the underline.
+	Width float64 `yaml:"width"`
+	// Size is the font size, zero uses the default font size of the layout.
+	Size int `yaml:"size"`
+	// Style of the font, empty for regular or B for bold.
+	Style string `yaml:"style"`
+	// Align is either left, center or right.
+	Align string `yaml:"align"`
+	// Underline draws a line below the block.
+	Underline bool `yaml:"underline"`
+	// Content is a template, an empty content disables the block.
+	Content string `yaml:"content"`
+}
+
+// Texts are the fixed texts of the documents in one language. The texts ending with
+// Intro, Validity and Closing are templates. DateFormat is the Go layout of the dates
+// (ex: 02.01.2006), month names are always English.
+type Texts struct {
+	DateFormat        string `yaml:"dateFormat"`
+	Invoice           string `yaml:"invoice"`
+	InvoiceIntro      string `yaml:"invoiceIntro"`
+	PaymentTerms      string `yaml:"paymentTerms"`
+	ReInvoiceIntro    string `yaml:"reInvoiceIntro"`
+	Receipt           string `yaml:"receipt"`
+	Offer             string `yaml:"offer"`
+	OfferValidity     string `yaml:"offerValidity"`
+	CreditNote        string `yaml:"creditNote"`
+	CreditNoteIntro   string `yaml:"creditNoteIntro"`
+	CreditNoteClosing string `yaml:"creditNoteClosing"`
+	Reason            string `yaml:"reason"`
+	Date              string `yaml:"date"`
+	Reference         string `yaml:"reference"`
+	Description       string `yaml:"description"`
+	Quantity          string `yaml:"quantity"`
+	Price             string `yaml:"price"`
+	Amount            string `yaml:"amount"`
+	Total             string `yaml:"total"`
+	Phone             string `yaml:"phone"`
+	Mail              string `yaml:"mail"`
+	BankDetails       string `yaml:"bankDetails"`
+}
+
+// NewLayout returns the default layout.
+func NewLayout() Layout {
+	lyt := Layout{}
+	if err := defaults.Set(&lyt); err != nil {
+		logrus.Fatal("error setting defaults for invoice layout: ", err)
+	}
+	lyt.Logo = Box{X: 150, Y: 15, Width: 40, Height: 20}
+	lyt.Header = TextBlock{
+		X:       20,
+		Y:       20,
+		Size:    10,
+		Content: "{{ .Company.Name }}\n{{ .Company.Street }} {{ .Company.StreetNr }}\n{{ .Company.PostalCode }} {{ .Company.Place }}\n{{ .Texts.Phone }}: {{ .Company.Phone }}\n{{ .Texts.Mail }}: {{ .Company.Mail }}\nURL: {{ .Company.Url }}",
+	}
+	lyt.Sender = TextBlock{
+		X:         115,
+		Y:         50,
+		Width:     75,
+		Size:      7,
+		Underline: true,
+		Content:   "{{ .Company.Name }}, {{ .Company.Street }} {{ .Company.StreetNr }}, {{ .Company.PostalCode }} {{ .Company.Place }}",
+	}
+	lyt.Address = TextBlock{
+		X:       115,
+		Y:       60,
+		Size:    10,
+		Content: "{{ .Customer.AddressLines }}",
+	}
+	lyt.PlaceDate = TextBlock{
+		X:       20,
+		Y:       100,
+		Width:   170,
+		Align:   "right",
+		Content: "{{ .Place }}, {{ .Date }}",
+	}
+	lyt.Footer = TextBlock{
+		X:       20,
+		Y:       282,
+		Width:   170,
+		Size:    8,
+		Align:   "center",
+		Content: "{{ .Company.Name }} · {{ .Company.Street }} {{ .Company.StreetNr }} · {{ .Company.PostalCode }} {{ .Company.Place }}{{ if .Company.Iban }}\n{{ .Texts.BankDetails }}: {{ if .Company.Bank }}{{ .Company.Bank }}, {{ end }}IBAN {{ .Company.Iban }}{{ end }}",
+	}
+	lyt.Texts = map[string]Texts{
+		"de": {
+			DateFormat:        "02.01.2006",
+			Invoice:           "Rechnung",
+			InvoiceIntro:      "Wir erlauben uns, Ihnen die folgenden Leistungen in Rechnung zu stellen.",
+			PaymentTerms:      "Zahlbar innert 30 Tagen.",
+			ReInvoiceIntro:    "Wir erlauben uns, Ihnen die folgenden Auslagen weiterzuverrechnen.\nDie Belege finden Sie im Anhang.",
+			Receipt:           "Beleg",
+			Offer:             "Offerte",
+			OfferValidity:     "Diese Offerte ist gültig bis am {{ .Document.DueDate }}.",
+			CreditNote:        "Gutschrift",
+			CreditNoteIntro:   "Wir schreiben Ihnen zur Rechnung {{ .Document.Reference }} vom {{ .Document.ReferenceDate }} den folgenden Betrag gut.",
+			CreditNoteClosing: "Bereits bezahlte Beträge erstatten wir Ihnen zurück.",
+			Reason:            "Grund",
+			Date:              "Datum",
+			Reference:         "Referenz",
+			Description:       "Bezeichnung",
+			Quantity:          "Menge",
+			Price:             "Preis",
+			Amount:            "Betrag",
+			Total:             "Total",
+			Phone:             "Telefon",
+			Mail:              "E-Mail",
+			BankDetails:       "Bankverbindung",
+		},
+		"en": {
+			DateFormat:        "2 January 2006",
+			Invoice:           "Invoice",
+			InvoiceIntro:      "We kindly ask you to pay the following services.",
+			PaymentTerms:      "Payable within 30 days.",
+			ReInvoiceIntro:    "We kindly ask you to refund the following expenses.\nThe receipts are attached.",
+			Receipt:           "Receipt",
+			Offer:             "Offer",
+			OfferValidity:     "This offer is valid until {{ .Document.DueDate }}.",
+			CreditNote:        "Credit note",
+			CreditNoteIntro:   "We credit you the following amount of the invoice {{ .Document.Reference }} from {{ .Document.ReferenceDate }}.",
+			CreditNoteClosing: "Amounts already paid will be refunded.",
+			Reason:            "Reason",
+			Date:              "Date",
+			Reference:         "Reference",
+			Description:       "Description",
+			Quantity:          "Quantity",
+			Price:             "Price",
+			Amount:            "Amount",
+			Total:             "Total",
+			Phone:             "Phone",
+			Mail:              "Mail",
+			BankDetails:       "Bank details",
+		},
+		"fr": {
+			DateFormat:        "02/01/2006",
+			Invoice:           "Facture",
+			InvoiceIntro:      "Nous nous permettons de vous facturer les prestations suivantes.",
+			PaymentTerms:      "Payable dans les 30 jours.",
+			ReInvoiceIntro:    "Nous nous permettons de vous refacturer les frais suivants.\nVous trouverez les justificatifs en annexe.",
+			Receipt:           "Justificatif",
+			Offer:             "Offre",
+			OfferValidity:     "Cette offre est valable jusqu'au {{ .Document.DueDate }}.",
+			CreditNote:        "Note de crédit",
+			CreditNoteIntro:   "Nous vous créditons le montant suivant de la facture {{ .Document.Reference }} du {{ .Document.ReferenceDate }}.",
+			CreditNoteClosing: "Les montants déjà payés vous seront remboursés.",
+			Reason:            "Motif",
+			Date:              "Date",
+			Reference:         "Référence",
+			Description:       "Désignation",
+			Quantity:          "Quantité",
+			Price:             "Prix",
+			Amount:            "Montant",
+			Total:             "Total",
+			Phone:             "Téléphone",
+			Mail:              "E-mail",
+			BankDetails:       "Coordonnées bancaires",
+		},
+	}
+	return lyt
+}
+
+// OpenLayout opens the layout saved in the YAML file given by the path. Values missing
+// in the file are taken from the default layout. An empty path returns the default layout.
+func OpenLayout(path string) Layout {
+	lyt := NewLayout()
+	if path == "" {
+		return lyt
+	}
+	util.OpenYaml(&lyt, path, "invoice layout")
+	return lyt
+}
+
+// Save writes the layout as YAML file to the given path.
+func (l Layout) Save(path string) {
+	util.SaveToYaml(l, path, "invoice layout")
+}
+
+// texts returns the fixed texts in the language of the layout. Texts missing in this
+// language are taken from the default texts (German if the language is unknown).
+func (l Layout) texts() Texts {
+	fallback, ok := NewLayout().Texts[l.Language]
+	if !ok {
+		fallback = NewLayout().Texts["de"]
+	}
+	rsl, ok := l.Texts[l.Language]
+	if !ok {
+		logrus.Warnf("no texts for language «%s» in invoice layout, using default texts", l.Language)
+		return fallback
+	}
+	rsl.fill(fallback)
+	return rsl
+}
+
+// fill sets all empty texts to the value of the fallback.
+func (t *Texts) fill(fallback Texts) {
+	fields := []struct {
+		value    *string
+		fallback string
+	}{
+		{&t.DateFormat, fallback.DateFormat},
+		{&t.Invoice, fallback.Invoice},
+		{&t.InvoiceIntro, fallback.InvoiceIntro},
+		{&t.PaymentTerms, fallback.PaymentTerms},
+		{&t.ReInvoiceIntro, fallback.ReInvoiceIntro},
+		{&t.Receipt, fallback.Receipt},
+		{&t.Offer, fallback.Offer},
+		{&t.OfferValidity, fallback.OfferValidity},
+		{&t.CreditNote, fallback.CreditNote},
+		{&t.CreditNoteIntro, fallback.CreditNoteIntro},
+		{&t.CreditNoteClosing, fallback.CreditNoteClosing},
+		{&t.Reason, fallback.Reason},
+		{&t.Date, fallback.Date},
+		{&t.Reference, fallback.Reference},
+		{&t.Description, fallback.Description},
+		{&t.Quantity, fallback.Quantity},
+		{&t.Price, fallback.Price},
+		{&t.Amount, fallback.Amount},
+		{&t.Total, fallback.Total},
+		{&t.Phone, fallback.Phone},
+		{&t.Mail, fallback.Mail},
+		{&t.BankDetails, fallback.BankDetails},
+	}
+	for i := range fields {
+		if *fields[i].value == "" {
+			*fields[i].value = fields[i].fallback
+		}
+	}
+}
+
+// newDoc returns a new document.Doc with the fonts of the layout.
+func (l Layout) newDoc() document.Doc {
+	if l.RegularFont == "" && l.BoldFont == "" {
+		return document.NewDoc(l.FontSize, 1.2)
+	}
+	regular := readFont(l.RegularFont)
+	bold := regular
+	if l.BoldFont != "" {
+		bold = readFont(l.BoldFont)
+	}
+	return document.NewDocWithFonts(l.FontSize, 1.2, regular, bold)
+}
+
+func readFont(path string) []byte {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		logrus.Fatalf("error reading font file \"%s\": %s", path, err)
+	}
+	return raw
+}
+
+// LayoutData is the input for the templates of the Layout.
+type LayoutData struct {
+	Company  schema.Company
+	Customer schema.Party
+	Place    string
+	// Date is the date the document was generated.
+	Date     string
+	Texts    Texts
+	Document DocumentData
+	Items    []ItemData
+}
+
+// DocumentData contains the information about the document rendered with the layout.
+type DocumentData struct {
+	Identifier string
+	Name       string
+	Date       string
+	Amount     string
+	// DueDate is the validity of an offer.
+	DueDate string
+	// Reference and ReferenceDate point to the credited invoice of a credit note.
+	Reference     string
+	ReferenceDate string
+}
+
+// ItemData is a line in the item table of the document.
+type ItemData struct {
+	Date        string
+	Reference   string
+	Description string
+	Quantity    string
+	Price       string
+	Amount      string
+}
+
+// newLayoutData returns the template data for a document addressed to the customer.
+func newLayoutData(company schema.Company, customer schema.Party, place string, texts Texts) LayoutData {
+	return LayoutData{
+		Company:  company,
+		Customer: customer,
+		Place:    place,
+		Date:     time.Now().Format(texts.DateFormat),
+		Texts:    texts,
+	}
+}
+
+// displayDate returns the date (YYYY-MM-DD) in the format of the language. Dates which
+// can't be parsed are returned unaltered.
+func (t Texts) displayDate(date string) string {
+	value, err := time.Parse(util.DateFormat, date)
+	if err != nil {
+		return date
+	}
+	return value.Format(t.DateFormat)
+}
+
+// apply renders the given template with the data.
+func (d LayoutData) apply(name, tpl string) string {
+	return strings.TrimRight(util.ApplyTemplate(fmt.Sprintf("invoice layout %s", name), tpl, d), "\n")
+}
@@ -0,0 +1,47 @@
+package invoices
+
+import "testing"
+
+func TestLayoutDefaults(t *testing.T) {
+	lyt := NewLayout()
+	if lyt.FontSize != 12 || lyt.Language != "de" || lyt.MarginLeft != 20 || lyt.MarginRight != 20 {
+		t.Errorf("unexpected defaults: font size %d, language %s, margins %.0f/%.0f", lyt.FontSize, lyt.Language, lyt.MarginLeft, lyt.MarginRight)
+	}
+	for _, lang := range []string{"de", "en", "fr"} {
+		if lyt.Texts[lang].DateFormat == "" || lyt.Texts[lang].Invoice == "" {
+			t.Errorf("missing default texts for language %s", lang)
+		}
+	}
+
+	lyt.Language = "en"
+	lyt.Texts["en"] = Texts{Invoice: "Bill"}
+	texts := lyt.texts()
+	if texts.Invoice != "Bill" || texts.Total != "Total" || texts.DateFormat != "2 January 2006" {
+		t.Errorf("expected own invoice text and English defaults, got %+v", texts)
+	}
+	lyt.Language = "rm"
+	if texts := lyt.texts(); texts.Invoice != "Rechnung" {
+		t.Errorf("expected German texts for unknown language, got %s", texts.Invoice)
+	}
+}
+
+func TestDisplayDate(t *testing.T) {
+	tests := []struct {
+		language string
+		date     string
+		expected string
+	}{
+		{"de", "2020-03-04", "04.03.2020"},
+		{"en", "2020-03-04", "4 March 2020"},
+		{"fr", "2020-03-04", "04/03/2020"},
+		{"de", "", ""},
+		{"en", "04.03.2020", "04.03.2020"},
+	}
+	for _, test := range tests {
+		lyt := NewLayout()
+		lyt.Language = test.language
+		if date := lyt.texts().displayDate(test.date); date != test.expected {
+			t.Errorf("%s: expected «%s» for %s, got «%s»", test.language, test.expected, test.date, date)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package invoices
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+	"github.com/signintech/gopdf"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// quantityColumn is the right edge of the quantity column.
+	quantityColumn = 125.0
+	// unitPriceColumn is the right edge of the unit price column.
+	unitPriceColumn = 155.0
+)
+
+// GenerateAllOffers generates a document for all offers in the schema and saves it to the
+// given destination folder.
+func GenerateAllOffers(s schema.Schema, layout Layout, dstFolder, place string, doOverwrite bool) {
+	nFiles := len(s.Offers)
+	for i := range s.Offers {
+		fileName := fmt.Sprintf("%s.pdf", s.Offers[i].FileString())
+		filePath := path.Join(dstFolder, fileName)
+		if _, err := os.Stat(filePath); !os.IsNotExist(err) && !doOverwrite {
+			logrus.Infof("(%d/%d) File %s exists, skipping", i+1, nFiles, fileName)
+			continue
+		}
+		logrus.Infof("(%d/%d) Generate %s...", i+1, nFiles, fileName)
+		customer, err := s.Parties.CustomerByRef(s.Offers[i].Customer)
+		if err != nil {
+			logrus.Errorf("found for offer %s no customer (given: %s): %s", s.Offers[i].Id, s.Offers[i].Customer, err)
+			continue
+		}
+		GenerateOffer(layout, s.Company, s.Offers[i], *customer, s.Currency, place, filePath)
+	}
+}
+
+// GenerateOffer generates the offer letter for the given offer. Place is the city where
+// the offer is generated.
+func GenerateOffer(layout Layout, company schema.Company, offer schema.Offer, customer schema.Party, currency, place, dstPath string) {
+	doc := NewInvoiceDocument(layout, place)
+	save(doc.GenerateOffer(company, offer, customer, currency), dstPath)
+}
+
+// GenerateOffer generates a PDF for the given offer and returns it as a gopdf.GoPdf element.
+func (d *InvoiceDocument) GenerateOffer(company schema.Company, offer schema.Offer, customer schema.Party, currency string) gopdf.GoPdf {
+	total := offer.Amount(currency)
+	d.data = newLayoutData(company, customer, d.place, d.texts)
+	d.data.Document = DocumentData{
+		Identifier: offer.Identifier,
+		Name:       offer.Name,
+		Date:       d.texts.displayDate(offer.Date),
+		Amount:     total.Display(),
+		DueDate:    d.texts.displayDate(offer.ValidUntil),
+	}
+	for i := range offer.Items {
+		item := offer.Items[i]
+		price := ""
+		if item.UnitPrice.Money != nil {
+			price = item.UnitPrice.Display()
+		}
+		d.data.Items = append(d.data.Items, ItemData{
+			Description: item.Description,
+			Quantity:    strconv.FormatFloat(item.Quantity, 'f', -1, 64),
+			Price:       price,
+			Amount:      util.NewMoney(item.Amount(), total.Currency().Code).Display(),
+		})
+	}
+	d.letterhead()
+
+	y := d.title(fmt.Sprintf("%s %s: %s", d.texts.Offer, offer.Identifier, offer.Name))
+	y = d.paragraph(y, d.data.apply("offer validity", d.texts.OfferValidity))
+
+	d.Doc.SetFontStyle("B")
+	y = d.itemLine(y, d.texts.Description, d.texts.Quantity, d.texts.Price, d.texts.Amount)
+	d.Doc.DefaultFontStyle()
+	for i := range d.data.Items {
+		item := d.data.Items[i]
+		y = d.breakPage(y)
+		y = d.itemLine(y, item.Description, item.Quantity, item.Price, item.Amount)
+	}
+	y = d.totalLine(y)
+	d.itemLine(y, d.texts.Total, "", "", total.Display())
+	d.Doc.DefaultFontStyle()
+	return d.Doc.Pdf
+}
+
+// itemLine adds a line of the offer items and returns the position of the next line.
+func (d *InvoiceDocument) itemLine(y float64, description, quantity, price, amount string) float64 {
+	d.Doc.AddText(d.layout.MarginLeft, y, description)
+	if quantity != "" {
+		d.addRightAligned(quantityColumn, y, quantity)
+	}
+	if price != "" {
+		d.addRightAligned(unitPriceColumn, y, price)
+	}
+	d.addRightAligned(pageWidth-d.layout.MarginRight, y, amount)
+	return y + d.Doc.LineHeight()
+}
@@ -2,73 +2,185 @@ package invoices
 
 import (
 	"fmt"
+	"os"
+	"strings"
+
 	"github.com/72nd/acc/pkg/document"
 	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
 	"github.com/signintech/gopdf"
 	"github.com/sirupsen/logrus"
-	"math"
-	"os"
-	"time"
 )
 
+// pageWidth is the width of a A4 page in millimetres.
+const pageWidth = 210.0
+
 // InvoiceDocument is a Doc report which generates a invoice-letter.
 type InvoiceDocument struct {
 	document.Doc
-	place string
+	layout Layout
+	texts  Texts
+	place  string
+	data   LayoutData
 }
 
-// NewInvoiceDocument returns a new InvoiceDocument.
-func NewInvoiceDocument(fontSize int, place string) InvoiceDocument {
+// NewInvoiceDocument returns a new InvoiceDocument using the given layout.
+func NewInvoiceDocument(layout Layout, place string) InvoiceDocument {
 	return InvoiceDocument{
-		Doc:   document.NewDoc(fontSize, 1.2),
-		place: place,
+		Doc:    layout.newDoc(),
+		layout: layout,
+		texts:  layout.texts(),
+		place:  place,
 	}
 }
 
 // Generate generates a PDF for a given InvoiceDocument and returns it as a gopdf.GoPdf element.
 func (d *InvoiceDocument) Generate(company schema.Company, invoice schema.Invoice, customer schema.Party) gopdf.GoPdf {
+	items := []ItemData{{
+		Date:        d.texts.displayDate(invoice.SendDate),
+		Reference:   invoice.Identifier,
+		Description: invoice.Name,
+		Amount:      invoice.Amount.Display(),
+	}}
+	return d.generateInvoice(company, invoice, customer, items, d.texts.InvoiceIntro, d.texts.Reference)
+}
+
+// generateInvoice renders the letter of a invoice with the given items. The intro is
+// a template, refHeader is the title of the reference column.
+func (d *InvoiceDocument) generateInvoice(company schema.Company, invoice schema.Invoice, customer schema.Party, items []ItemData, intro, refHeader string) gopdf.GoPdf {
+	d.data = newLayoutData(company, customer, d.place, d.texts)
+	d.data.Document = DocumentData{
+		Identifier: invoice.Identifier,
+		Name:       invoice.Name,
+		Date:       d.texts.displayDate(invoice.SendDate),
+		Amount:     invoice.Amount.Display(),
+	}
+	d.data.Items = items
+	d.letterhead()
+
+	y := d.title(fmt.Sprintf("%s %s", d.texts.Invoice, invoice.Identifier))
+	y = d.paragraph(y, d.data.apply("invoice intro", intro))
+	d.Doc.SetFontStyle("B")
+	y = d.expenseLine(y, d.texts.Date, refHeader, d.texts.Description, d.texts.Amount)
+	d.Doc.DefaultFontStyle()
+	for i := range items {
+		y = d.breakPage(y)
+		y = d.expenseLine(y, items[i].Date, items[i].Reference, items[i].Description, items[i].Amount)
+	}
+	y = d.totalLine(y)
+	d.expenseLine(y, d.texts.Total, "", "", invoice.Amount.Display())
+	d.Doc.DefaultFontStyle()
+	d.paragraph(y+2*d.Doc.LineHeight(), d.texts.PaymentTerms)
+	return d.Doc.Pdf
+}
+
+// newPage adds a new page with the footer of the layout.
+func (d *InvoiceDocument) newPage() {
 	d.Doc.Pdf.AddPage()
 	d.Doc.Pdf.SetLineWidth(0.1)
-	d.Doc.Pdf.SetMargins(20, 10, 20, 10)
+	d.Doc.Pdf.SetMargins(d.layout.MarginLeft, 10, d.layout.MarginRight, 10)
 	d.Doc.Pdf.SetFillColor(0, 0, 0)
-	d.header(company)
-	d.address(company, customer)
+	d.textBlock("footer", d.layout.Footer)
+}
 
-	return d.Doc.Pdf
+// letterhead adds the first page with the logo, the header, the address of the customer
+// and the place and date.
+func (d *InvoiceDocument) letterhead() {
+	d.newPage()
+	d.logo()
+	d.textBlock("header", d.layout.Header)
+	d.textBlock("sender", d.layout.Sender)
+	d.textBlock("address", d.layout.Address)
+	d.textBlock("place and date", d.layout.PlaceDate)
 }
 
-func (d *InvoiceDocument) header(company schema.Company) {
-	d.Doc.AddFormattedMultilineText(20, 20, fmt.Sprintf(
-		"%s\n%s %d\n%d %s\nTelefon: %s\nE-Mail: %s\nURL: %s",
-		company.Name,
-		company.Street,
-		company.StreetNr,
-		company.PostalCode,
-		company.Place,
-		company.Phone,
-		company.Mail,
-		company.Url,
-	), 10, "")
+func (d *InvoiceDocument) logo() {
+	box := d.layout.Logo
+	pth := d.data.Company.Logo
+	if box.Width <= 0 || pth == "" {
+		return
+	}
+	if !util.FileExist(pth) {
+		logrus.Warnf("logo «%s» not found, document is generated without logo", pth)
+		return
+	}
+	height := box.Height
+	if height <= 0 {
+		height = box.Width
+	}
+	rect := fitImage(pth, box.Width, height)
+	if err := d.Doc.Pdf.Image(pth, box.X+box.Width-rect.W, box.Y, &rect); err != nil {
+		logrus.Errorf("error while including logo %s: %s", pth, err)
+	}
 }
 
-func (d *InvoiceDocument) address(company schema.Company, customer schema.Party) {
-	sender := fmt.Sprintf(
-		"%s, %s %d, %d %s",
-		company.Name,
-		company.Street,
-		company.StreetNr,
-		company.PostalCode,
-		company.Place,
-	)
-	d.Doc.AddFormattedText(115, 50, sender, 7, "")
-	y := 50 + math.Round(d.Doc.LineHeight()/1.3)
-	d.Pdf.Line(115, y, 190, y)
-	d.Doc.AddFormattedMultilineText(115, y+2*d.Doc.LineHeight(), customer.AddressLines(), 10, "")
-
-	placeDate := fmt.Sprintf("%s, %s", d.place, time.Now().Format("02.01.2006"))
-	placeDateWidth, _ := d.Pdf.MeasureTextWidth(placeDate)
-	placeDateX := gopdf.PointsToUnits(gopdf.Unit_MM, gopdf.PageSizeA4.W) - d.Pdf.MarginRight() - placeDateWidth
-	d.Doc.AddText(placeDateX, 100, placeDate)
+// textBlock renders the template of the block and adds it to the current page.
+func (d *InvoiceDocument) textBlock(name string, block TextBlock) {
+	if block.Content == "" {
+		return
+	}
+	size := block.Size
+	if size == 0 {
+		size = d.layout.FontSize
+	}
+	d.Doc.SetFontSize(size)
+	d.Doc.SetFontStyle(block.Style)
+	y := block.Y
+	for _, line := range strings.Split(d.data.apply(name, block.Content), "\n") {
+		width, err := d.Doc.Pdf.MeasureTextWidth(line)
+		if err != nil {
+			logrus.Fatal("error while measuring text width: ", err)
+		}
+		x := block.X
+		switch block.Align {
+		case "right":
+			x = block.X + block.Width - width
+		case "center":
+			x = block.X + (block.Width-width)/2
+		}
+		d.Doc.AddText(x, y, line)
+		y += d.Doc.LineHeight()
+	}
+	if block.Underline {
+		y -= d.Doc.LineHeight() * 0.2
+		d.Doc.Pdf.Line(block.X, y, block.X+block.Width, y)
+	}
+	d.Doc.DefaultFontSize()
+	d.Doc.DefaultFontStyle()
+}
+
+// title adds the title of the document and returns the position of the next element.
+func (d *InvoiceDocument) title(content string) float64 {
+	y := d.layout.BodyTop
+	d.Doc.AddFormattedText(d.layout.MarginLeft, y, content, d.layout.FontSize+6, "B")
+	return y + 2*d.Doc.LineHeight()
+}
+
+// paragraph adds a (multiline) text and returns the position of the next element.
+func (d *InvoiceDocument) paragraph(y float64, content string) float64 {
+	if content == "" {
+		return y
+	}
+	d.Doc.AddMultilineText(d.layout.MarginLeft, y, content)
+	return y + float64(strings.Count(content, "\n")+2)*d.Doc.LineHeight()
+}
+
+// totalLine draws the line above the total and switches to bold text. Returns the
+// position of the total.
+func (d *InvoiceDocument) totalLine(y float64) float64 {
+	d.Doc.Pdf.Line(d.layout.MarginLeft, y, pageWidth-d.layout.MarginRight, y)
+	d.Doc.SetFontStyle("B")
+	return y + 0.5*d.Doc.LineHeight()
+}
+
+// breakPage continues the table on a new page if the given position is beyond the page
+// break of the layout. Returns the position of the next line.
+func (d *InvoiceDocument) breakPage(y float64) float64 {
+	if y <= d.layout.PageBreak {
+		return y
+	}
+	d.newPage()
+	return 20
 }
 
 func save(pdf gopdf.GoPdf, dstPath string) {
@@ -0,0 +1,120 @@
+package invoices
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+	"github.com/phpdave11/gofpdi"
+	"github.com/signintech/gopdf"
+	"github.com/sirupsen/logrus"
+)
+
+// GenerateReInvoice generates the invoice letter forwarding the given expenses to the
+// customer. The receipts of the expenses are attached as appendix.
+func GenerateReInvoice(layout Layout, company schema.Company, invoice schema.Invoice, customer schema.Party, expenses schema.Expenses, place, dstPath string) {
+	doc := NewInvoiceDocument(layout, place)
+	save(doc.GenerateReInvoice(company, invoice, customer, expenses), dstPath)
+}
+
+// GenerateReInvoice generates a PDF for an invoice re-invoicing the given expenses and
+// returns it as a gopdf.GoPdf element.
+func (d *InvoiceDocument) GenerateReInvoice(company schema.Company, invoice schema.Invoice, customer schema.Party, expenses schema.Expenses) gopdf.GoPdf {
+	d.generateInvoice(company, invoice, customer, d.reInvoiceItems(expenses), d.texts.ReInvoiceIntro, d.texts.Receipt)
+	for i := range expenses {
+		d.appendReceipt(expenses[i])
+	}
+	return d.Doc.Pdf
+}
+
+// reInvoiceItems returns a line of the invoice for each expense.
+func (d *InvoiceDocument) reInvoiceItems(expenses schema.Expenses) []ItemData {
+	items := make([]ItemData, len(expenses))
+	for i := range expenses {
+		items[i] = ItemData{
+			Date:        d.texts.displayDate(expenses[i].DateOfAccrual),
+			Reference:   expenses[i].Identifier,
+			Description: expenses[i].Name,
+			Amount:      expenses[i].Amount.Display(),
+		}
+	}
+	return items
+}
+
+// expenseLine adds a line of the expense table and returns the position of the next line.
+func (d *InvoiceDocument) expenseLine(y float64, date, ident, name, amount string) float64 {
+	left := d.layout.MarginLeft
+	d.Doc.AddText(left, y, date)
+	d.Doc.AddText(left+25, y, ident)
+	d.Doc.AddText(left+50, y, name)
+	d.addRightAligned(pageWidth-d.layout.MarginRight, y, amount)
+	return y + d.Doc.LineHeight()
+}
+
+// appendReceipt adds all pages of the receipt of the expense (PDF or PNG) at the end
+// of the document.
+func (d *InvoiceDocument) appendReceipt(exp schema.Expense) {
+	if exp.Path == "" || !util.FileExist(exp.Path) {
+		logrus.Warnf("receipt of %s not found, not attached to invoice", exp.String())
+		return
+	}
+	caption := fmt.Sprintf("%s %s: %s", d.texts.Receipt, exp.Identifier, exp.Name)
+	switch strings.ToLower(path.Ext(exp.Path)) {
+	case ".pdf":
+		imp := gofpdi.NewImporter()
+		imp.SetSourceFile(exp.Path)
+		pages := len(imp.GetPageSizes())
+		for i := 1; i <= pages; i++ {
+			d.receiptPage(fmt.Sprintf("%s (%d/%d)", caption, i, pages))
+			tpl := d.Doc.Pdf.ImportPage(exp.Path, i, "/MediaBox")
+			d.Doc.Pdf.UseImportedTemplate(tpl, d.layout.MarginLeft, 25, pageWidth-d.layout.MarginLeft-d.layout.MarginRight, 0)
+		}
+	case ".png":
+		d.receiptPage(caption)
+		rect := fitImage(exp.Path, pageWidth-d.layout.MarginLeft-d.layout.MarginRight, 250)
+		if err := d.Doc.Pdf.Image(exp.Path, d.layout.MarginLeft, 25, &rect); err != nil {
+			logrus.Errorf("error while including image %s into invoice: %s", exp.Path, err)
+		}
+	default:
+		logrus.Warnf("receipt %s of %s is neither a PDF nor a PNG, not attached to invoice", exp.Path, exp.String())
+	}
+}
+
+func (d *InvoiceDocument) receiptPage(caption string) {
+	d.newPage()
+	d.Doc.AddFormattedText(d.layout.MarginLeft, 15, caption, 10, "B")
+}
+
+// addRightAligned adds the text with its right edge at the given x position.
+func (d *InvoiceDocument) addRightAligned(x, y float64, content string) {
+	width, err := d.Doc.Pdf.MeasureTextWidth(content)
+	if err != nil {
+		logrus.Fatal("error while measuring text width: ", err)
+	}
+	d.Doc.AddText(x-width, y, content)
+}
+
+// fitImage returns the size of the image scaled to fit into the given container.
+func fitImage(pth string, containerWidth, containerHeight float64) gopdf.Rect {
+	reader, err := os.Open(pth)
+	if err != nil {
+		logrus.Fatalf("couldn't open image \"%s\": %s", pth, err)
+	}
+	defer reader.Close()
+	img, _, err := image.DecodeConfig(reader)
+	if err != nil {
+		logrus.Fatalf("error while reading image \"%s\": %s", pth, err)
+	}
+	width := float64(img.Width)
+	height := float64(img.Height)
+	if containerWidth/containerHeight > width/height {
+		return gopdf.Rect{W: width * containerHeight / height, H: containerHeight}
+	}
+	return gopdf.Rect{W: containerWidth, H: height * containerWidth / width}
+}
@@ -0,0 +1,28 @@
+package invoices
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestReInvoiceItems(t *testing.T) {
+	d := InvoiceDocument{texts: NewLayout().texts()}
+	items := d.reInvoiceItems(schema.Expenses{
+		{Identifier: "e-1", Name: "Stage wood", DateOfAccrual: "2020-03-04", Amount: util.NewMoney(120050, "CHF")},
+		{Identifier: "e-2", Name: "Train ticket", DateOfAccrual: "2020-03-05", Amount: util.NewMoney(4200, "CHF")},
+	})
+	expected := []ItemData{
+		{Date: "04.03.2020", Reference: "e-1", Description: "Stage wood", Amount: "1,200.50 CHF"},
+		{Date: "05.03.2020", Reference: "e-2", Description: "Train ticket", Amount: "42.00 CHF"},
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("expected %d lines, got %+v", len(expected), items)
+	}
+	for i := range expected {
+		if items[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], items[i])
+		}
+	}
+}
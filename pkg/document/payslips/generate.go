@@ -0,0 +1,39 @@
+// Package payslips renders the monthly payslips of the employees as PDF files.
+package payslips
+
+import (
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/sirupsen/logrus"
+)
+
+// GenerateAllPayslips generates a payslip for all given salaries and saves them to the
+// given destination folder. Existing files are only replaced if doOverwrite is true.
+func GenerateAllPayslips(s schema.Schema, salaries schema.Salaries, dstFolder, place string, doOverwrite bool) {
+	nFiles := len(salaries)
+	for i := range salaries {
+		fileName := fmt.Sprintf("%s_%s.pdf", salaries[i].Identifier, salaries[i].Month)
+		filePath := path.Join(dstFolder, fileName)
+		if _, err := os.Stat(filePath); !os.IsNotExist(err) && !doOverwrite {
+			logrus.Infof("(%d/%d) File %s exists, skipping", i+1, nFiles, fileName)
+			continue
+		}
+		logrus.Infof("(%d/%d) Generate %s...", i+1, nFiles, fileName)
+		employee, err := s.Parties.EmployeeByRef(salaries[i].Employee)
+		if err != nil {
+			logrus.Errorf("found for salary %s no employee (given: %s): %s", salaries[i].Identifier, salaries[i].Employee.Id, err)
+			continue
+		}
+		GeneratePayslip(s.Company, salaries[i], *employee, place, filePath)
+	}
+}
+
+// GeneratePayslip generates the payslip for a given salary. Place is the city where the
+// payslip is generated.
+func GeneratePayslip(company schema.Company, salary schema.Salary, employee schema.Party, place, dstPath string) {
+	doc := NewPayslipDocument(11, place)
+	save(doc.Generate(company, salary, employee), dstPath)
+}
@@ -0,0 +1,137 @@
+package payslips
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/72nd/acc/pkg/document"
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+	"github.com/signintech/gopdf"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	marginLeft  = 20.0
+	marginRight = 20.0
+	pageWidth   = 210.0
+	// rateColumn is the right edge of the rate column.
+	rateColumn = 140.0
+)
+
+// PayslipDocument is a Doc which generates the payslip of a monthly salary.
+type PayslipDocument struct {
+	document.Doc
+	place string
+	y     float64
+}
+
+// NewPayslipDocument returns a new PayslipDocument.
+func NewPayslipDocument(fontSize int, place string) PayslipDocument {
+	return PayslipDocument{
+		Doc:   document.NewDoc(fontSize, 1.4),
+		place: place,
+	}
+}
+
+// Generate generates a PDF for a given PayslipDocument and returns it as a gopdf.GoPdf element.
+func (d *PayslipDocument) Generate(company schema.Company, salary schema.Salary, employee schema.Party) gopdf.GoPdf {
+	d.Doc.Pdf.AddPage()
+	d.Doc.Pdf.SetLineWidth(0.1)
+	d.Doc.Pdf.SetMargins(marginLeft, 10, marginRight, 10)
+	d.Doc.Pdf.SetFillColor(0, 0, 0)
+	d.header(company)
+	d.address(company, employee)
+	d.body(salary)
+	return d.Doc.Pdf
+}
+
+func (d *PayslipDocument) header(company schema.Company) {
+	d.Doc.AddFormattedMultilineText(marginLeft, 20, fmt.Sprintf(
+		"%s\n%s %d\n%d %s",
+		company.Name,
+		company.Street,
+		company.StreetNr,
+		company.PostalCode,
+		company.Place,
+	), 10, "")
+}
+
+func (d *PayslipDocument) address(company schema.Company, employee schema.Party) {
+	sender := fmt.Sprintf(
+		"%s, %s %d, %d %s",
+		company.Name,
+		company.Street,
+		company.StreetNr,
+		company.PostalCode,
+		company.Place,
+	)
+	d.Doc.AddFormattedText(115, 50, sender, 7, "")
+	y := 50 + math.Round(d.Doc.LineHeight()/1.3)
+	d.Pdf.Line(115, y, 190, y)
+	d.Doc.AddFormattedMultilineText(115, y+2*d.Doc.LineHeight(), employee.AddressLines(), 10, "")
+
+	placeDate := fmt.Sprintf("%s, %s", d.place, time.Now().Format("02.01.2006"))
+	d.addRightAligned(pageWidth-marginRight, 100, placeDate)
+}
+
+func (d *PayslipDocument) body(salary schema.Salary) {
+	currency := salary.Gross.Currency().Code
+	d.y = 115
+	d.Doc.AddFormattedText(marginLeft, d.y, fmt.Sprintf("Lohnabrechnung %s", salary.Month), 16, "B")
+	d.y += 3 * d.Doc.LineHeight()
+
+	d.line("Bruttolohn", "", salary.Gross.Display(), true)
+	d.y += 0.5 * d.Doc.LineHeight()
+	for i := range salary.Deductions {
+		d.line(
+			salary.Deductions[i].Name,
+			fmt.Sprintf("%.2f %%", salary.Deductions[i].Rate),
+			util.NewMoney(-salary.Deductions[i].Amount.Amount(), currency).Display(),
+			false)
+	}
+	d.line("Total Abzüge", "", util.NewMoney(-salary.TotalDeductions(), currency).Display(), false)
+	d.Doc.Pdf.Line(marginLeft, d.y, pageWidth-marginRight, d.y)
+	d.y += 0.5 * d.Doc.LineHeight()
+	d.line("Nettolohn", "", salary.Net().Display(), true)
+}
+
+// line adds a line with a label, an optional rate and the amount.
+func (d *PayslipDocument) line(label, rate, amount string, emphasis bool) {
+	if emphasis {
+		d.Doc.SetFontStyle("B")
+	}
+	d.Doc.AddText(marginLeft, d.y, label)
+	if rate != "" {
+		d.addRightAligned(rateColumn, d.y, rate)
+	}
+	d.addRightAligned(pageWidth-marginRight, d.y, amount)
+	if emphasis {
+		d.Doc.DefaultFontStyle()
+	}
+	d.y += d.Doc.LineHeight()
+}
+
+// addRightAligned adds the text with its right edge at the given x position.
+func (d *PayslipDocument) addRightAligned(x, y float64, content string) {
+	width, err := d.Doc.Pdf.MeasureTextWidth(content)
+	if err != nil {
+		logrus.Fatal("error while measuring text width: ", err)
+	}
+	d.Doc.AddText(x-width, y, content)
+}
+
+func save(pdf gopdf.GoPdf, dstPath string) {
+	if dstPath == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		dstPath = wd
+	}
+	if err := pdf.WritePdf(dstPath); err != nil {
+		logrus.Fatal("error while writing pdf: ", err)
+	}
+}
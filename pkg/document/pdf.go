@@ -10,6 +10,9 @@ import (
 	"strings"
 )
 
+// fontFamily is the name under which the text font is registered in the PDF.
+const fontFamily = "text"
+
 // Doc is the basic structure for a PDF file.
 type Doc struct {
 	fontSize        int
@@ -22,19 +25,24 @@ type Doc struct {
 	Pdf             gopdf.GoPdf
 }
 
-// NewDoc returns a new Doc.
+// NewDoc returns a new Doc using the embedded Lato font.
 func NewDoc(fontSize int, lineSpread float64) Doc {
+	return NewDocWithFonts(fontSize, lineSpread, utils.LatoRegular(), utils.LatoHeavy())
+}
+
+// NewDocWithFonts returns a new Doc using the given TrueType fonts for the regular and
+// the bold text.
+func NewDocWithFonts(fontSize int, lineSpread float64, regular, bold []byte) Doc {
 	pdf := gopdf.GoPdf{}
 	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4, Unit: gopdf.Unit_MM})
-	if err := pdf.AddTTFFontByReaderWithOption("lato", bytes.NewBuffer(utils.LatoHeavy()), gopdf.TtfOption{Style: gopdf.Bold}); err != nil {
-		logrus.Fatal("error adding lato heavy to utils: ", err)
+	if err := pdf.AddTTFFontByReaderWithOption(fontFamily, bytes.NewBuffer(bold), gopdf.TtfOption{Style: gopdf.Bold}); err != nil {
+		logrus.Fatal("error adding bold font to document: ", err)
 	}
-	latoRegular := utils.LatoRegular()
-	if err := pdf.AddTTFFontByReaderWithOption("lato", bytes.NewBuffer(latoRegular), gopdf.TtfOption{Style: gopdf.Regular, UseKerning: true}); err != nil {
-		logrus.Fatal("error adding lato regular to utils: ", err)
+	if err := pdf.AddTTFFontByReaderWithOption(fontFamily, bytes.NewBuffer(regular), gopdf.TtfOption{Style: gopdf.Regular, UseKerning: true}); err != nil {
+		logrus.Fatal("error adding regular font to document: ", err)
 	}
 	var parser core.TTFParser
-	if err := parser.ParseByReader(bytes.NewBuffer(latoRegular)); err != nil {
+	if err := parser.ParseByReader(bytes.NewBuffer(regular)); err != nil {
 		logrus.Fatal("error while parsing font for height calculation: ", err)
 	}
 	doc := Doc{
@@ -63,7 +71,7 @@ func (d *Doc) SetPosition(x, y float64) {
 // SetFontSize sets the font size for all elements added after.
 func (d *Doc) SetFontSize(size int) {
 	d.fontSize = size
-	if err := d.Pdf.SetFont("lato", "", size); err != nil {
+	if err := d.Pdf.SetFont(fontFamily, "", size); err != nil {
 		logrus.Fatal("error while changing Pdf font size: ", err)
 	}
 }
@@ -75,7 +83,7 @@ func (d *Doc) DefaultFontSize() {
 
 // SetFontStyle changes the font style (italic, bold...) for elements added afterwards.
 func (d *Doc) SetFontStyle(style string) {
-	if err := d.Pdf.SetFont("lato", style, d.fontSize); err != nil {
+	if err := d.Pdf.SetFont(fontFamily, style, d.fontSize); err != nil {
 		logrus.Fatal("error while changing Pdf font style: ", err)
 	}
 	d.fontStyle = style
@@ -0,0 +1,12 @@
+// Package reports renders tabular reports (financial statements etc.) as PDF files.
+package reports
+
+import (
+	"github.com/72nd/acc/pkg/schema"
+)
+
+// GenerateTable renders the given table data as a PDF and saves it to the given path.
+func GenerateTable(company schema.Company, title, subtitle string, header []string, rows []Row, dstPath string) {
+	doc := NewTableDocument(10)
+	save(doc.Generate(company, title, subtitle, header, rows), dstPath)
+}
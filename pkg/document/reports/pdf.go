@@ -0,0 +1,126 @@
+package reports
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/72nd/acc/pkg/document"
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/signintech/gopdf"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	marginLeft   = 20.0
+	marginRight  = 20.0
+	marginTop    = 20.0
+	marginBottom = 20.0
+	pageWidth    = 210.0
+	pageHeight   = 297.0
+	// firstColumnShare is the fraction of the text width used by the first column.
+	firstColumnShare = 0.4
+)
+
+// Row is a single row of a table report. Emphasized rows are printed bold and are
+// used for section titles and totals.
+type Row struct {
+	Cells    []string
+	Emphasis bool
+}
+
+// TableDocument is a Doc which renders a tabular report (like a balance sheet) on one or
+// multiple A4 pages.
+type TableDocument struct {
+	document.Doc
+	y float64
+}
+
+// NewTableDocument returns a new TableDocument.
+func NewTableDocument(fontSize int) TableDocument {
+	return TableDocument{
+		Doc: document.NewDoc(fontSize, 1.4),
+	}
+}
+
+// Generate generates the PDF for the given report data and returns it as a gopdf.GoPdf element.
+func (d *TableDocument) Generate(company schema.Company, title, subtitle string, header []string, rows []Row) gopdf.GoPdf {
+	d.Doc.Pdf.SetLineWidth(0.1)
+	d.Doc.Pdf.SetMargins(marginLeft, marginTop, marginRight, marginBottom)
+	d.newPage()
+
+	d.Doc.AddFormattedText(marginLeft, d.y, company.Name, 9, "")
+	created := fmt.Sprintf("created %s", time.Now().Format("02.01.2006"))
+	d.addRightAligned(pageWidth-marginRight, d.y, created)
+	d.y += 2 * d.Doc.LineHeight()
+
+	d.Doc.AddFormattedText(marginLeft, d.y, title, 16, "B")
+	d.y += 2 * d.Doc.LineHeight()
+	if subtitle != "" {
+		d.Doc.AddText(marginLeft, d.y, subtitle)
+		d.y += 2 * d.Doc.LineHeight()
+	}
+
+	d.row(Row{Cells: header, Emphasis: true})
+	d.Doc.Pdf.Line(marginLeft, d.y, pageWidth-marginRight, d.y)
+	d.y += 0.5 * d.Doc.LineHeight()
+	for i := range rows {
+		if d.y > pageHeight-marginBottom-d.Doc.LineHeight() {
+			d.newPage()
+		}
+		d.row(rows[i])
+	}
+	return d.Doc.Pdf
+}
+
+func (d *TableDocument) newPage() {
+	d.Doc.Pdf.AddPage()
+	d.y = marginTop
+}
+
+// row adds a row at the current position. The first cell is aligned left, all other
+// cells are aligned right.
+func (d *TableDocument) row(row Row) {
+	if row.Emphasis {
+		d.Doc.SetFontStyle("B")
+	}
+	textWidth := pageWidth - marginLeft - marginRight
+	first := textWidth * firstColumnShare
+	var colWidth float64
+	if len(row.Cells) > 1 {
+		colWidth = (textWidth - first) / float64(len(row.Cells)-1)
+	}
+	for i := range row.Cells {
+		if i == 0 {
+			d.Doc.AddText(marginLeft, d.y, row.Cells[i])
+			continue
+		}
+		d.addRightAligned(marginLeft+first+float64(i)*colWidth, d.y, row.Cells[i])
+	}
+	if row.Emphasis {
+		d.Doc.DefaultFontStyle()
+	}
+	d.y += d.Doc.LineHeight()
+}
+
+// addRightAligned adds the text with its right edge at the given x position.
+func (d *TableDocument) addRightAligned(x, y float64, content string) {
+	width, err := d.Doc.Pdf.MeasureTextWidth(content)
+	if err != nil {
+		logrus.Fatal("error while measuring text width: ", err)
+	}
+	d.Doc.AddText(x-width, y, content)
+}
+
+func save(pdf gopdf.GoPdf, dstPath string) {
+	if dstPath == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		dstPath = wd
+	}
+	if err := pdf.WritePdf(dstPath); err != nil {
+		logrus.Fatal("error while writing pdf: ", err)
+	}
+}
@@ -17,7 +17,7 @@ func LatoHeavy() []byte {
 	box := riceBox()
 	data, err := box.Bytes("Lato-Heavy.ttf")
 	if err != nil {
-		logrus.Errorf("could not load lato heavy: ", err)
+		logrus.Error("could not load lato heavy: ", err)
 	}
 	return data
 }
@@ -26,7 +26,7 @@ func LatoRegular() []byte {
 	box := riceBox()
 	data, err := box.Bytes("Lato-Regular.ttf")
 	if err != nil {
-		logrus.Errorf("could not load lato regular: ", err)
+		logrus.Error("could not load lato regular: ", err)
 	}
 	return data
 }
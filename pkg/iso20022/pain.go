@@ -1 +1,226 @@
 package iso20022
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// PainNamespace is the namespace of the ISO 20022 customer credit transfer initiation.
+const PainNamespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+
+// Payment is a single payment order of a payment run.
+type Payment struct {
+	// Creditor receives the payment, the Iban of the party is mandatory.
+	Creditor schema.Party
+	Amount   util.Money
+	// Reference is the unique identifier of the payment (instruction and end-to-end id).
+	Reference string
+	// Remittance is the unstructured information for the creditor.
+	Remittance string
+}
+
+// CreditTransfer is the root node of a ISO 20022 pain.001 payment order file.
+type CreditTransfer struct {
+	XMLName     xml.Name        `xml:"Document"`
+	Xmlns       string          `xml:"xmlns,attr"`
+	GroupHeader painGroupHeader `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	PaymentInfo painPaymentInfo `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type painGroupHeader struct {
+	MessageId        string `xml:"MsgId"`
+	CreationDateTime string `xml:"CreDtTm"`
+	NumberOfTxs      int    `xml:"NbOfTxs"`
+	ControlSum       string `xml:"CtrlSum"`
+	InitiatingParty  string `xml:"InitgPty>Nm"`
+}
+
+type painPaymentInfo struct {
+	PaymentInfoId     string            `xml:"PmtInfId"`
+	PaymentMethod     string            `xml:"PmtMtd"`
+	BatchBooking      bool              `xml:"BtchBookg"`
+	NumberOfTxs       int               `xml:"NbOfTxs"`
+	ControlSum        string            `xml:"CtrlSum"`
+	ExecutionDate     string            `xml:"ReqdExctnDt"`
+	DebtorName        string            `xml:"Dbtr>Nm"`
+	DebtorIban        string            `xml:"DbtrAcct>Id>IBAN"`
+	DebtorAgent       string            `xml:"DbtrAgt>FinInstnId>Othr>Id"`
+	CreditTransaction []painTransaction `xml:"CdtTrfTxInf"`
+}
+
+type painTransaction struct {
+	InstructionId string     `xml:"PmtId>InstrId"`
+	EndToEndId    string     `xml:"PmtId>EndToEndId"`
+	Amount        painAmount `xml:"Amt>InstdAmt"`
+	CreditorName  string     `xml:"Cdtr>Nm"`
+	Street        string     `xml:"Cdtr>PstlAdr>StrtNm,omitempty"`
+	BuildingNr    string     `xml:"Cdtr>PstlAdr>BldgNb,omitempty"`
+	PostalCode    string     `xml:"Cdtr>PstlAdr>PstCd,omitempty"`
+	Town          string     `xml:"Cdtr>PstlAdr>TwnNm,omitempty"`
+	Country       string     `xml:"Cdtr>PstlAdr>Ctry"`
+	CreditorIban  string     `xml:"CdtrAcct>Id>IBAN"`
+	Remittance    string     `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+type painAmount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// NewCreditTransfer returns the payment order file for the given payments which will be
+// debited from the bank account of the company at the given execution date. An error is
+// returned if the company or a creditor has no IBAN.
+func NewCreditTransfer(company schema.Company, execution time.Time, payments []Payment) (CreditTransfer, error) {
+	if company.Iban == "" {
+		return CreditTransfer{}, fmt.Errorf("no IBAN for the company set, needed as debtor account")
+	}
+	now := time.Now()
+	msgId := fmt.Sprintf("ACC-%s", now.Format("20060102150405"))
+	var sum int64
+	txs := make([]painTransaction, len(payments))
+	for i := range payments {
+		pmt := payments[i]
+		if pmt.Creditor.Iban == "" {
+			return CreditTransfer{}, fmt.Errorf("no IBAN for %s set", pmt.Creditor.Name)
+		}
+		sum += pmt.Amount.Amount()
+		txs[i] = painTransaction{
+			InstructionId: pmt.Reference,
+			EndToEndId:    pmt.Reference,
+			Amount: painAmount{
+				Currency: pmt.Amount.Currency().Code,
+				Value:    painDecimal(pmt.Amount.Amount()),
+			},
+			CreditorName: pmt.Creditor.Name,
+			Street:       pmt.Creditor.Street,
+			BuildingNr:   painNumber(pmt.Creditor.StreetNr),
+			PostalCode:   painNumber(pmt.Creditor.PostalCode),
+			Town:         pmt.Creditor.Place,
+			Country:      painCountry(company, pmt.Creditor),
+			CreditorIban: compactIban(pmt.Creditor.Iban),
+			Remittance:   truncate(pmt.Remittance, 140),
+		}
+	}
+	return CreditTransfer{
+		Xmlns: PainNamespace,
+		GroupHeader: painGroupHeader{
+			MessageId:        msgId,
+			CreationDateTime: now.Format("2006-01-02T15:04:05"),
+			NumberOfTxs:      len(txs),
+			ControlSum:       painDecimal(sum),
+			InitiatingParty:  company.Name,
+		},
+		PaymentInfo: painPaymentInfo{
+			PaymentInfoId:     fmt.Sprintf("%s-1", msgId),
+			PaymentMethod:     "TRF",
+			BatchBooking:      true,
+			NumberOfTxs:       len(txs),
+			ControlSum:        painDecimal(sum),
+			ExecutionDate:     execution.Format(DateLayout),
+			DebtorName:        company.Name,
+			DebtorIban:        compactIban(company.Iban),
+			DebtorAgent:       "NOTPROVIDED",
+			CreditTransaction: txs,
+		},
+	}, nil
+}
+
+// Save writes the payment order as XML to the given path.
+func (c CreditTransfer) Save(path string) {
+	raw, err := xml.MarshalIndent(c, "", "  ")
+	if err != nil {
+		logrus.Fatal("error while marshalling payment order: ", err)
+	}
+	content := append([]byte(xml.Header), raw...)
+	if err := ioutil.WriteFile(path, content, 0644); err != nil {
+		logrus.Fatalf("error writing file %s: %s", path, err)
+	}
+	logrus.Infof("payment order with %d payments saved as %s", c.GroupHeader.NumberOfTxs, path)
+}
+
+// painCountry returns the country of the creditor's address. If the creditor has no
+// country set, the country of the company and then the country of the creditor's IBAN
+// is used.
+func painCountry(company schema.Company, creditor schema.Party) string {
+	if creditor.Country != "" {
+		return strings.ToUpper(creditor.Country)
+	}
+	if company.Country != "" {
+		return strings.ToUpper(company.Country)
+	}
+	if iban := compactIban(creditor.Iban); len(iban) >= 2 {
+		return iban[:2]
+	}
+	return ""
+}
+
+func painDecimal(cents int64) string {
+	return fmt.Sprintf("%d.%02d", cents/100, cents%100)
+}
+
+func painNumber(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
+
+func compactIban(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length])
+}
+
+// AdvancePayments returns a payment for each employee with open (not yet reimbursed)
+// advanced expenses and currency. The remittance information lists the identifiers of
+// the expenses. Expenses without an amount are skipped.
+func AdvancePayments(s schema.Schema) []Payment {
+	var rsl []Payment
+	for i := range s.Parties.Employees {
+		emp := s.Parties.Employees[i]
+		exp := s.Expenses.AdvancedBy(emp)
+		var currencies []string
+		sums := make(map[string]int64)
+		idents := make(map[string][]string)
+		for k := range exp {
+			if exp[k].IsSettled() || exp[k].Amount.Money == nil {
+				continue
+			}
+			currency := exp[k].Amount.Currency().Code
+			if _, ok := sums[currency]; !ok {
+				currencies = append(currencies, currency)
+			}
+			sums[currency] += exp[k].Amount.Amount()
+			idents[currency] = append(idents[currency], exp[k].Identifier)
+		}
+		for _, currency := range currencies {
+			if sums[currency] == 0 {
+				continue
+			}
+			ref := fmt.Sprintf("%s-%s", emp.Identifier, time.Now().Format("20060102"))
+			if len(currencies) > 1 {
+				ref = fmt.Sprintf("%s-%s", ref, currency)
+			}
+			rsl = append(rsl, Payment{
+				Creditor:   emp,
+				Amount:     util.NewMoney(sums[currency], currency),
+				Reference:  ref,
+				Remittance: fmt.Sprintf("Rückerstattung %s", strings.Join(idents[currency], ", ")),
+			})
+		}
+	}
+	return rsl
+}
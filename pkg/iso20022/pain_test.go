@@ -0,0 +1,83 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestNewCreditTransfer(t *testing.T) {
+	company := schema.Company{Name: "Theater AG", Iban: "CH93 0076 2011 6238 5295 7"}
+	pmts := []Payment{
+		{
+			Creditor:   schema.Party{Name: "Max Muster", Street: "Hauptgasse", StreetNr: 4, PostalCode: 3011, Place: "Bern", Iban: "ch44 3199 9123 0008 8901 2"},
+			Amount:     util.NewMoney(12050, "CHF"),
+			Reference:  "e-1-20200301",
+			Remittance: "Rückerstattung e-1",
+		},
+		{
+			Creditor:  schema.Party{Name: "Erika Muster", Place: "Wien", Country: "at", Iban: "AT61 1904 3002 3457 3201"},
+			Amount:    util.NewMoney(5, "EUR"),
+			Reference: "e-2-20200301",
+		},
+	}
+	trf, err := NewCreditTransfer(company, time.Date(2020, 3, 2, 0, 0, 0, 0, time.UTC), pmts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := xml.Marshal(trf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := string(raw)
+	for _, element := range []string{
+		`<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"><CstmrCdtTrfInitn><GrpHdr>`,
+		"<NbOfTxs>2</NbOfTxs><CtrlSum>120.55</CtrlSum><InitgPty><Nm>Theater AG</Nm></InitgPty></GrpHdr>",
+		"<PmtMtd>TRF</PmtMtd><BtchBookg>true</BtchBookg>",
+		"<ReqdExctnDt>2020-03-02</ReqdExctnDt><Dbtr><Nm>Theater AG</Nm></Dbtr><DbtrAcct><Id><IBAN>CH9300762011623852957</IBAN></Id></DbtrAcct>",
+		"<PmtId><InstrId>e-1-20200301</InstrId><EndToEndId>e-1-20200301</EndToEndId></PmtId><Amt><InstdAmt Ccy=\"CHF\">120.50</InstdAmt></Amt>",
+		"<Cdtr><Nm>Max Muster</Nm><PstlAdr><StrtNm>Hauptgasse</StrtNm><BldgNb>4</BldgNb><PstCd>3011</PstCd><TwnNm>Bern</TwnNm><Ctry>CH</Ctry></PstlAdr></Cdtr>",
+		"<CdtrAcct><Id><IBAN>CH4431999123000889012</IBAN></Id></CdtrAcct><RmtInf><Ustrd>Rückerstattung e-1</Ustrd></RmtInf>",
+		"<Amt><InstdAmt Ccy=\"EUR\">0.05</InstdAmt></Amt><Cdtr><Nm>Erika Muster</Nm><PstlAdr><TwnNm>Wien</TwnNm><Ctry>AT</Ctry></PstlAdr></Cdtr>",
+	} {
+		if !strings.Contains(doc, element) {
+			t.Errorf("expected %s in payment order %s", element, doc)
+		}
+	}
+
+	pmts[0].Creditor.Iban = ""
+	if _, err := NewCreditTransfer(company, time.Now(), pmts); err == nil {
+		t.Error("expected error for creditor without IBAN")
+	}
+}
+
+func TestAdvancePayments(t *testing.T) {
+	emp := schema.Party{Id: "emp-1", Identifier: "e-1", Name: "Max Muster"}
+	s := schema.Schema{
+		Parties: schema.PartiesCollection{Employees: []schema.Party{emp}},
+		Expenses: schema.Expenses{
+			{Identifier: "ex-1", Amount: util.NewMoney(1000, "CHF"), AdvancedByThirdParty: true, AdvancedThirdParty: schema.NewRef("emp-1")},
+			{Identifier: "ex-2", Amount: util.NewMoney(500, "EUR"), AdvancedByThirdParty: true, AdvancedThirdParty: schema.NewRef("emp-1")},
+			{Identifier: "ex-3", Amount: util.NewMoney(250, "CHF"), AdvancedByThirdParty: true, AdvancedThirdParty: schema.NewRef("emp-1")},
+			{Identifier: "ex-4", AdvancedByThirdParty: true, AdvancedThirdParty: schema.NewRef("emp-1")},
+			{Identifier: "ex-5", Amount: util.NewMoney(300, "CHF"), AdvancedByThirdParty: true, AdvancedThirdParty: schema.NewRef("emp-1"), DateOfSettlement: "2020-03-01"},
+		},
+	}
+	pmts := AdvancePayments(s)
+	if len(pmts) != 2 {
+		t.Fatalf("expected 2 payments, got %d", len(pmts))
+	}
+	if pmts[0].Amount.Value() != "12.50 CHF" || pmts[0].Remittance != "Rückerstattung ex-1, ex-3" {
+		t.Errorf("unexpected CHF payment %s, %s", pmts[0].Amount.Value(), pmts[0].Remittance)
+	}
+	if pmts[1].Amount.Value() != "5.00 EUR" || pmts[1].Remittance != "Rückerstattung ex-2" {
+		t.Errorf("unexpected EUR payment %s, %s", pmts[1].Amount.Value(), pmts[1].Remittance)
+	}
+	if pmts[0].Reference == pmts[1].Reference {
+		t.Errorf("expected distinct references, got %s twice", pmts[0].Reference)
+	}
+}
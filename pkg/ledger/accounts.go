@@ -0,0 +1,224 @@
+package ledger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AccountSeparator separates the components of a hledger account name.
+const AccountSeparator = ":"
+
+// AccountType states the type of an account and is derived from the root component
+// of the account name (after the aliases got applied).
+type AccountType int
+
+const (
+	UnknownAccount AccountType = iota
+	AssetAccount
+	LiabilityAccount
+	EquityAccount
+	RevenueAccount
+	ExpenseAccount
+)
+
+// AccountTypeOf returns the type of the given account. As in hledger the type is
+// determined by the name of the top level account.
+func AccountTypeOf(account string) AccountType {
+	root := strings.ToLower(strings.Split(account, AccountSeparator)[0])
+	switch root {
+	case "asset", "assets":
+		return AssetAccount
+	case "liability", "liabilities":
+		return LiabilityAccount
+	case "equity":
+		return EquityAccount
+	case "revenue", "revenues", "income":
+		return RevenueAccount
+	case "expense", "expenses":
+		return ExpenseAccount
+	}
+	return UnknownAccount
+}
+
+// Posting is a single movement on one account. Every Entry results in two postings,
+// a positive (debit) one on Account1 and a negative (credit) one on Account2.
+type Posting struct {
+	Date     time.Time
+	Account  string
+	Amount   int64
+	Currency string
+}
+
+// Postings returns all postings of the journal with the aliases already resolved.
+func (j Journal) Postings() []Posting {
+	rsl := make([]Posting, 0, 2*len(j.Entries))
+	for i := range j.Entries {
+		e := j.Entries[i]
+		currency := ""
+		var amount int64
+		if e.Amount.Money != nil {
+			currency = e.Amount.Currency().Code
+			amount = e.Amount.Amount()
+		}
+		rsl = append(rsl,
+			Posting{
+				Date:     e.Date,
+				Account:  j.ResolveAlias(e.Account1),
+				Amount:   amount,
+				Currency: currency,
+			},
+			Posting{
+				Date:     e.Date,
+				Account:  j.ResolveAlias(e.Account2),
+				Amount:   -amount,
+				Currency: currency,
+			})
+	}
+	return rsl
+}
+
+// ResolveAlias applies the aliases of the journal to the given account name the same
+// way hledger does. An alias matches the whole account name or the leading components
+// of it.
+func (j Journal) ResolveAlias(account string) string {
+	for i := range j.Aliases {
+		from, to := j.Aliases[i][0], j.Aliases[i][1]
+		if account == from {
+			return to
+		}
+		if strings.HasPrefix(account, from+AccountSeparator) {
+			return to + strings.TrimPrefix(account, from)
+		}
+	}
+	return account
+}
+
+// Balances returns the balance of each account considering all postings between from
+// and to (both inclusive). If from or to is nil the range is open on this side. Amounts
+// in different currencies can't be added, an error is returned if a posting isn't in
+// the given currency.
+func (j Journal) Balances(currency string, from, to *time.Time) (Balances, error) {
+	rsl := make(Balances)
+	pst := j.Postings()
+	for i := range pst {
+		if from != nil && pst[i].Date.Before(*from) {
+			continue
+		}
+		if to != nil && pst[i].Date.After(*to) {
+			continue
+		}
+		if pst[i].Currency != "" && pst[i].Currency != currency {
+			return nil, fmt.Errorf("posting on %s at %s is in %s, balances can only be computed for postings in %s", pst[i].Account, pst[i].Date.Format("2006-01-02"), pst[i].Currency, currency)
+		}
+		rsl[pst[i].Account] += pst[i].Amount
+	}
+	return rsl, nil
+}
+
+// Balances maps full account names to their balance in the smallest unit of the
+// currency (cents). Debits are positive, credits are negative. All balances are in
+// the same currency.
+type Balances map[string]int64
+
+// Total returns the balance of the given account including all of its sub-accounts.
+func (b Balances) Total(account string) int64 {
+	var rsl int64
+	for name, amount := range b {
+		if name == account || strings.HasPrefix(name, account+AccountSeparator) {
+			rsl += amount
+		}
+	}
+	return rsl
+}
+
+// TotalByType returns the sum of all accounts of the given type.
+func (b Balances) TotalByType(typ AccountType) int64 {
+	var rsl int64
+	for name, amount := range b {
+		if AccountTypeOf(name) == typ {
+			rsl += amount
+		}
+	}
+	return rsl
+}
+
+// Accounts returns the names of all accounts with a balance.
+func (b Balances) Accounts() []string {
+	rsl := make([]string, 0, len(b))
+	for name := range b {
+		rsl = append(rsl, name)
+	}
+	sort.Strings(rsl)
+	return rsl
+}
+
+// Account is a node in the account tree. The root node has an empty name.
+type Account struct {
+	Name     string
+	FullName string
+	Children []*Account
+}
+
+// NewAccountTree builds the account hierarchy of all accounts which have a balance in
+// at least one of the given Balances. The children of each node are sorted by name.
+func NewAccountTree(balances ...Balances) *Account {
+	root := &Account{}
+	for i := range balances {
+		for name := range balances[i] {
+			root.add(name)
+		}
+	}
+	root.sort()
+	return root
+}
+
+// add inserts the given account and all its parents into the tree.
+func (a *Account) add(fullName string) {
+	node := a
+	parts := strings.Split(fullName, AccountSeparator)
+	for i := range parts {
+		child := node.Child(parts[i])
+		if child == nil {
+			child = &Account{
+				Name:     parts[i],
+				FullName: strings.Join(parts[:i+1], AccountSeparator),
+			}
+			node.Children = append(node.Children, child)
+		}
+		node = child
+	}
+}
+
+func (a *Account) sort() {
+	sort.Slice(a.Children, func(i, k int) bool {
+		return a.Children[i].Name < a.Children[k].Name
+	})
+	for i := range a.Children {
+		a.Children[i].sort()
+	}
+}
+
+// Child returns the direct child with the given name or nil if there is none.
+func (a Account) Child(name string) *Account {
+	for i := range a.Children {
+		if a.Children[i].Name == name {
+			return a.Children[i]
+		}
+	}
+	return nil
+}
+
+// Walk calls fn for the account and all its descendants (depth first). The depth of
+// the given account is 0.
+func (a *Account) Walk(fn func(depth int, acc *Account)) {
+	a.walk(0, fn)
+}
+
+func (a *Account) walk(depth int, fn func(depth int, acc *Account)) {
+	fn(depth, a)
+	for i := range a.Children {
+		a.Children[i].walk(depth+1, fn)
+	}
+}
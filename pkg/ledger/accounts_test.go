@@ -0,0 +1,88 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestResolveAlias(t *testing.T) {
+	jrn := NewJournal([][]string{
+		{"Personalaufwand", "expenses:Personalaufwand"},
+		{"Betriebsfremder Aufwand", "expenses:Betriebsfremder Aufwand"},
+	})
+	cases := [][2]string{
+		{"Personalaufwand", "expenses:Personalaufwand"},
+		{"Personalaufwand:Löhne", "expenses:Personalaufwand:Löhne"},
+		{"Personalaufwandsminderung", "Personalaufwandsminderung"},
+		{"assets:Bank", "assets:Bank"},
+	}
+	for i := range cases {
+		if rsl := jrn.ResolveAlias(cases[i][0]); rsl != cases[i][1] {
+			t.Errorf("got \"%s\" but expected \"%s\"", rsl, cases[i][1])
+		}
+	}
+}
+
+func TestBalances(t *testing.T) {
+	jrn := NewJournal([][]string{{"Aufwand", "expenses"}})
+	jrn.AddEntries([]Entry{
+		{
+			Date:     time.Date(2021, time.May, 1, 0, 0, 0, 0, time.UTC),
+			Account1: "Aufwand:Material",
+			Account2: "assets:Bank",
+			Amount:   util.NewMoney(10000, "CHF"),
+		},
+		{
+			Date:     time.Date(2022, time.May, 1, 0, 0, 0, 0, time.UTC),
+			Account1: "assets:Bank",
+			Account2: "revenues:Sales",
+			Amount:   util.NewMoney(25050, "CHF"),
+		},
+	})
+
+	all, err := jrn.Balances("CHF", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if all.Total("assets") != 15050 {
+		t.Errorf("assets should be 15050 but are %d", all.Total("assets"))
+	}
+	if all.Total("expenses") != 10000 {
+		t.Errorf("aliased expenses should be 10000 but are %d", all.Total("expenses"))
+	}
+	if all.TotalByType(RevenueAccount) != -25050 {
+		t.Errorf("revenues should be -25050 but are %d", all.TotalByType(RevenueAccount))
+	}
+
+	from, to := util.DateRangeFromYear(2021)
+	year, err := jrn.Balances("CHF", &from, &to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if year.Total("assets:Bank") != -10000 {
+		t.Errorf("bank in 2021 should be -10000 but is %d", year.Total("assets:Bank"))
+	}
+
+	jrn.AddEntries([]Entry{{
+		Date:     time.Date(2022, time.June, 1, 0, 0, 0, 0, time.UTC),
+		Account1: "Aufwand:Material",
+		Account2: "assets:Bank",
+		Amount:   util.NewMoney(5000, "EUR"),
+	}})
+	if _, err := jrn.Balances("CHF", nil, nil); err == nil {
+		t.Error("balances of postings in CHF and EUR were added")
+	}
+	if _, err := jrn.Balances("CHF", &from, &to); err != nil {
+		t.Errorf("posting in EUR outside of the range rejected: %s", err)
+	}
+
+	tree := NewAccountTree(all)
+	if len(tree.Children) != 3 {
+		t.Fatalf("tree should have 3 root accounts but has %d", len(tree.Children))
+	}
+	if tree.Children[0].FullName != "assets" || tree.Children[0].Children[0].FullName != "assets:Bank" {
+		t.Errorf("unexpected tree structure: %+v", tree.Children[0])
+	}
+}
@@ -0,0 +1,187 @@
+package ledger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// BeancountWriter writes journals for Beancount. Beancount is way stricter about account
+// names than hledger: Only the five root accounts Assets, Liabilities, Equity, Income and
+// Expenses are allowed and each component has to start with a capital letter. Therefore
+// all accounts are sanitised by BeancountAccount. As Beancount has no aliases, they are
+// resolved while writing. Each account gets an open directive at the date of its first
+// posting.
+type BeancountWriter struct{}
+
+// Render returns the Beancount journal. Entries without an amount are skipped.
+func (w BeancountWriter) Render(j Journal) string {
+	j = j.exportable()
+	var b strings.Builder
+	accounts, currencies := j.accountOpenings()
+
+	for _, cur := range sortedKeys(currencies) {
+		fmt.Fprintf(&b, "option \"operating_currency\" \"%s\"\n", cur)
+	}
+	if len(currencies) > 0 {
+		b.WriteString("\n")
+	}
+	for _, cur := range sortedKeys(currencies) {
+		fmt.Fprintf(&b, "%s commodity %s\n", currencies[cur].Format(HLedgerDateFormat), cur)
+	}
+	if len(currencies) > 0 {
+		b.WriteString("\n")
+	}
+
+	// Different hledger accounts can collapse into the same Beancount account, only the
+	// earliest opening is used.
+	openings := make(map[string]string)
+	for _, acc := range sortedKeys(accounts) {
+		name := BeancountAccount(acc)
+		date := accounts[acc].Format(HLedgerDateFormat)
+		if existing, ok := openings[name]; !ok || date < existing {
+			openings[name] = date
+		}
+	}
+	names := make([]string, 0, len(openings))
+	for name := range openings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s open %s\n", openings[name], name)
+	}
+
+	entries := j.sortedEntries()
+	for i := range entries {
+		b.WriteString("\n")
+		b.WriteString(w.transaction(j, entries[i]))
+	}
+	return b.String()
+}
+
+// Extension returns the file extension of Beancount journals.
+func (BeancountWriter) Extension() string {
+	return "beancount"
+}
+
+func (w BeancountWriter) transaction(j Journal, e Entry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s \"%s\"\n", e.Date.Format(HLedgerDateFormat), beancountFlag(e), beancountString(e.Description))
+	if e.Code != "" {
+		fmt.Fprintf(&b, "  code: \"%s\"\n", beancountString(e.Code))
+	}
+	if cmt := e.Comment.String(); cmt != "" {
+		fmt.Fprintf(&b, "  comment: \"%s\"\n", beancountString(cmt))
+	}
+	if e.ID != "" {
+		fmt.Fprintf(&b, "  %s: \"%s\"\n", IDTag, beancountString(e.ID))
+	}
+
+	amount, currency := entryAmount(e)
+	acc1 := BeancountAccount(j.ResolveAlias(e.Account1))
+	acc2 := BeancountAccount(j.ResolveAlias(e.Account2))
+	width := postingWidth(acc1, acc2)
+	fmt.Fprintf(&b, "  %-*s  %s %s\n", width, acc1, decimal(amount), currency)
+	fmt.Fprintf(&b, "  %-*s  %s %s\n", width, acc2, decimal(-amount), currency)
+	return b.String()
+}
+
+// beancountFlag returns the flag of the transaction. Entries which need a manual
+// correction are always flagged with «!».
+func beancountFlag(e Entry) string {
+	if e.Comment.DoManual || len(e.Comment.Errors) > 0 {
+		return "!"
+	}
+	switch e.Status {
+	case PendingStatus:
+		return "!"
+	case ClearedStatus:
+		return "*"
+	}
+	return "txn"
+}
+
+// beancountString escapes a string for the usage in a Beancount string literal.
+func beancountString(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", " ").Replace(s)
+}
+
+// beancountRoots maps the account types to the root accounts of Beancount.
+var beancountRoots = map[AccountType]string{
+	AssetAccount:     "Assets",
+	LiabilityAccount: "Liabilities",
+	EquityAccount:    "Equity",
+	RevenueAccount:   "Income",
+	ExpenseAccount:   "Expenses",
+}
+
+// BeancountAccount converts a hledger account name into a valid Beancount account name.
+// The root account is replaced by the matching Beancount root (accounts of unknown type
+// are moved below «Equity:Unclassified»). All components are transliterated to ASCII,
+// characters which aren't allowed are replaced with dashes and the first letter is
+// capitalised.
+func BeancountAccount(account string) string {
+	parts := strings.Split(account, AccountSeparator)
+	root, ok := beancountRoots[AccountTypeOf(account)]
+	var rsl []string
+	if ok {
+		rsl = []string{root}
+		parts = parts[1:]
+	} else {
+		rsl = []string{"Equity", "Unclassified"}
+	}
+	for i := range parts {
+		if cmp := beancountComponent(parts[i]); cmp != "" {
+			rsl = append(rsl, cmp)
+		}
+	}
+	return strings.Join(rsl, AccountSeparator)
+}
+
+// beancountComponent sanitises a single component of an account name.
+func beancountComponent(component string) string {
+	r := strings.NewReplacer(
+		"ä", "ae",
+		"a\u0308", "ae",
+		"Ä", "Ae",
+		"ö", "oe",
+		"o\u0308", "oe",
+		"Ö", "Oe",
+		"ü", "ue",
+		"u\u0308", "ue",
+		"Ü", "Ue",
+		"ß", "ss",
+		"à", "a",
+		"â", "a",
+		"é", "e",
+		"è", "e",
+		"ê", "e",
+		"ç", "c",
+		"&", "-")
+	component = r.Replace(strings.TrimSpace(component))
+
+	var b strings.Builder
+	dash := false
+	for _, char := range component {
+		if char < unicode.MaxASCII && (unicode.IsLetter(char) || unicode.IsDigit(char)) {
+			b.WriteRune(char)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	rsl := strings.TrimRight(b.String(), "-")
+	if rsl == "" {
+		return ""
+	}
+	first := rune(rsl[0])
+	if unicode.IsDigit(first) {
+		return rsl
+	}
+	return string(unicode.ToUpper(first)) + rsl[1:]
+}
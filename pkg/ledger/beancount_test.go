@@ -0,0 +1,84 @@
+package ledger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestBeancountAccount(t *testing.T) {
+	cases := [][2]string{
+		{"assets:Umlaufvermögen:Flüssige Mittel", "Assets:Umlaufvermoegen:Fluessige-Mittel"},
+		{"revenues:Betrieblicher Ertrag", "Income:Betrieblicher-Ertrag"},
+		{"expenses:IT & Software:1. Quartal", "Expenses:IT-Software:1-Quartal"},
+		{"liabilities:Darlehen (privat)", "Liabilities:Darlehen-privat"},
+		{"other:unknown", "Equity:Unclassified:Other:Unknown"},
+	}
+	for i := range cases {
+		if rsl := BeancountAccount(cases[i][0]); rsl != cases[i][1] {
+			t.Errorf("got \"%s\" but expected \"%s\"", rsl, cases[i][1])
+		}
+	}
+}
+
+// exportJournal returns a journal with an alias, a flagged entry and an entry without an
+// amount for the tests of the export formats.
+func exportJournal() Journal {
+	j := NewJournal([][]string{{"bank", "assets:Bank:PostFinance"}})
+	j.Entries = []Entry{
+		{
+			ID:          "exp-1",
+			Date:        time.Date(2022, 3, 4, 0, 0, 0, 0, time.UTC),
+			Status:      ClearedStatus,
+			Code:        "e-1",
+			Description: "Stage wood",
+			Comment:     NewComment("expense", "e-1"),
+			Account1:    "expenses:Material",
+			Account2:    "bank",
+			Amount:      util.NewMoney(50000, "CHF"),
+		},
+		{
+			Date:        time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC),
+			Description: "Hamlet \"premiere\"",
+			Comment:     NewManualComment("default", "t-1"),
+			Account1:    "bank",
+			Account2:    "revenues:Tickets",
+			Amount:      util.NewMoney(120050, "CHF"),
+		},
+		{
+			Date:        time.Date(2022, 3, 2, 0, 0, 0, 0, time.UTC),
+			Description: "Without amount",
+			Comment:     NewComment("expense", "e-2"),
+			Account1:    "expenses:Other",
+			Account2:    "bank",
+		},
+	}
+	return j
+}
+
+func TestBeancountRender(t *testing.T) {
+	expected := `option "operating_currency" "CHF"
+
+2022-03-01 commodity CHF
+
+2022-03-01 open Assets:Bank:PostFinance
+2022-03-04 open Expenses:Material
+2022-03-01 open Income:Tickets
+
+2022-03-01 ! "Hamlet \"premiere\""
+  comment: "TODO: manual correction needed"
+  Assets:Bank:PostFinance  1200.50 CHF
+  Income:Tickets           -1200.50 CHF
+
+2022-03-04 * "Stage wood"
+  code: "e-1"
+  comment: "parsed as expense"
+  acc-id: "exp-1"
+  Expenses:Material        500.00 CHF
+  Assets:Bank:PostFinance  -500.00 CHF
+`
+	if rsl := (BeancountWriter{}).Render(exportJournal()); rsl != expected {
+		t.Errorf("unexpected journal:\n%s\nexpected:\n%s", rsl, expected)
+	}
+}
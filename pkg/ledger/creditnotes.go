@@ -0,0 +1,91 @@
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+// CREDIT NOTE ENTRIES
+
+// EntriesForCreditNote returns the journal entries reversing the (partial) revenue and
+// receivable of the invoice credited by the given schema.CreditNote.
+func EntriesForCreditNote(s schema.Schema, crn schema.CreditNote) []Entry {
+	return recordEntries(entriesForCreditNote(s, crn), crn.Id, crn.JournalOverride)
+}
+
+func entriesForCreditNote(s schema.Schema, crn schema.CreditNote) []Entry {
+	cmt := NewComment("credit note", crn.String())
+
+	desc := "no invoice or customer found"
+	inv, err := s.Invoices.InvoiceByRef(crn.Invoice)
+	cmt.add(err)
+	if err == nil {
+		cmp, err := s.Parties.CustomerByRef(inv.Customer)
+		cmt.add(err)
+		if err == nil {
+			data := map[string]string{
+				"Identifier": crn.Identifier,
+				"Invoice":    inv.Identifier,
+				"Party":      fmt.Sprintf("%s (%s)", cmp.Name, cmp.Identifier),
+			}
+			desc = util.ApplyTemplate(
+				"credit note description",
+				s.JournalConfig.CreditNoteDescription,
+				data)
+		}
+	}
+
+	return []Entry{
+		{
+			Date:        crn.DateTime(),
+			Status:      UnmarkedStatus,
+			Code:        crn.Identifier,
+			Description: desc,
+			Comment:     cmt,
+			Account1:    s.JournalConfig.RevenueAccount,
+			Account2:    s.JournalConfig.ReceivableAccount,
+			Amount:      crn.Amount,
+		}}
+}
+
+// SETTLEMENT
+
+// SettlementEntriesForCreditNote returns the entries for the refund of a credit note
+// whose invoice was already paid by the customer.
+func SettlementEntriesForCreditNote(s schema.Schema, trn schema.Transaction, crn schema.CreditNote) []Entry {
+	cmt := NewComment("credit note refund", trn.String())
+	cmt.add(compareAmounts(trn.Amount, crn.Amount))
+
+	desc := "TODO no invoice or customer found"
+	inv, err := s.Invoices.InvoiceByRef(crn.Invoice)
+	cmt.add(err)
+	if err == nil {
+		cmp, err := s.Parties.CustomerByRef(inv.Customer)
+		cmt.add(err)
+		if err == nil {
+			data := map[string]string{
+				"Identifier": crn.Identifier,
+				"Invoice":    inv.Identifier,
+				"Party":      fmt.Sprintf("%s (%s)", cmp.Name, cmp.Identifier),
+			}
+			desc = util.ApplyTemplate(
+				"credit note settlement description",
+				s.JournalConfig.CreditNoteSettlementDescription,
+				data)
+		}
+	}
+
+	return []Entry{
+		{
+			Date:        trn.DateTime(),
+			Status:      UnmarkedStatus,
+			Code:        trn.Identifier,
+			Description: desc,
+			Comment:     cmt,
+			Account1:    s.JournalConfig.ReceivableAccount,
+			Account2:    s.JournalConfig.BankAccount,
+			Amount:      trn.Amount,
+		}}
+}
@@ -0,0 +1,47 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestEntriesForCreditNote(t *testing.T) {
+	s := schema.Schema{
+		Currency:      "CHF",
+		JournalConfig: schema.NewJournalConfig(),
+		Parties: schema.PartiesCollection{
+			Customers: []schema.Party{{Id: "cst-1", Identifier: "c-1", Name: "Theater"}},
+		},
+	}
+	inv := schema.NewInvoiceWithUuid()
+	inv.Identifier = "i-1"
+	inv.Amount = util.NewMoney(100000, "CHF")
+	inv.Customer = schema.NewRef("cst-1")
+	inv.DateOfSettlement = ""
+	s.Invoices = schema.Invoices{inv}
+
+	crn, err := s.CreditInvoice(inv, 30000, "Discount", "invoices")
+	if err != nil {
+		t.Fatal(err)
+	}
+	crn.Date = "2022-03-01"
+	if open := inv.OpenAmount(s).Amount(); open != 70000 {
+		t.Errorf("expected open amount of 700.00, got %d", open)
+	}
+	if _, err := s.CreditInvoice(inv, 80000, "", "invoices"); err == nil {
+		t.Errorf("credit exceeding the open amount should fail")
+	}
+
+	entries := EntriesForCreditNote(s, crn)
+	if len(entries) != 1 {
+		t.Fatalf("expected one entry, got %d", len(entries))
+	}
+	if entries[0].Account1 != s.JournalConfig.RevenueAccount || entries[0].Account2 != s.JournalConfig.ReceivableAccount {
+		t.Errorf("credit note has to reverse the invoicing entry, got %s / %s", entries[0].Account1, entries[0].Account2)
+	}
+	if entries[0].Amount.Amount() != 30000 || entries[0].Date.Format(HLedgerDateFormat) != "2022-03-01" {
+		t.Errorf("unexpected amount %d or date %s", entries[0].Amount.Amount(), entries[0].Date)
+	}
+}
@@ -13,9 +13,9 @@ import (
 // Depending on the nature of the expense the appropriate function will be called.
 func EntriesForExpense(s schema.Schema, exp schema.Expense) []Entry {
 	if exp.AdvancedByThirdParty {
-		return entriesForEmployeeAdvancedExpense(s, exp)
+		return recordEntries(entriesForEmployeeAdvancedExpense(s, exp), exp.Id, exp.JournalOverride)
 	}
-	return entriesForCompanyPaidExpenses(s, exp)
+	return recordEntries(entriesForCompanyPaidExpenses(s, exp), exp.Id, exp.JournalOverride)
 }
 
 // entriesForEmployeeAdvancedExpense returns the journal entries for expenses advanced
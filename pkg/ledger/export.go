@@ -0,0 +1,140 @@
+package ledger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Writer converts a Journal into the file format of a plain text accounting software.
+type Writer interface {
+	// Render returns the journal in the format of the Writer.
+	Render(j Journal) string
+	// Extension returns the common file extension (without the dot) of the format.
+	Extension() string
+}
+
+// WriterFromUserInput returns the Writer for the given format name. An empty input
+// returns the hledger writer.
+func WriterFromUserInput(input string) (Writer, error) {
+	switch strings.ToLower(input) {
+	case "", "hledger":
+		return HLedgerWriter{}, nil
+	case "ledger", "ledger-cli":
+		return LedgerWriter{}, nil
+	case "beancount", "bean":
+		return BeancountWriter{}, nil
+	}
+	return nil, fmt.Errorf("unknown journal format «%s», use hledger, ledger or beancount", input)
+}
+
+// SaveFile saves the Journal at the given path using the format of the given Writer.
+func (j Journal) SaveFile(path string, w Writer) {
+	if err := ioutil.WriteFile(path, []byte(w.Render(j)), 0644); err != nil {
+		logrus.Fatalf("error writing file %s: %s", path, err)
+	}
+}
+
+// HLedgerWriter writes hledger journals. This is the native format of acc.
+type HLedgerWriter struct{}
+
+// Render returns the hledger journal.
+func (HLedgerWriter) Render(j Journal) string {
+	return j.HLedger()
+}
+
+// Extension returns the file extension of hledger journals.
+func (HLedgerWriter) Extension() string {
+	return "journal"
+}
+
+// exportable returns a copy of the journal without the entries which have no amount. The
+// export formats require an amount with a commodity for each posting (Beancount rejects
+// «0.00» without currency), the skipped entries are reported as warning.
+func (j Journal) exportable() Journal {
+	rsl := Journal{Aliases: j.Aliases, Entries: make([]Entry, 0, len(j.Entries))}
+	for i := range j.Entries {
+		if j.Entries[i].Amount.Money == nil {
+			logrus.Warnf("entry «%s» (%s) has no amount and is skipped in the export", j.Entries[i].Description, j.Entries[i].Date.Format(HLedgerDateFormat))
+			continue
+		}
+		rsl.Entries = append(rsl.Entries, j.Entries[i])
+	}
+	return rsl
+}
+
+// accountOpenings returns the date of the first posting for each account (with resolved
+// aliases) and the used currencies. This is used for the account and commodity
+// declarations of the export formats.
+func (j Journal) accountOpenings() (accounts map[string]time.Time, currencies map[string]time.Time) {
+	accounts = make(map[string]time.Time)
+	currencies = make(map[string]time.Time)
+	pst := j.Postings()
+	for i := range pst {
+		if date, ok := accounts[pst[i].Account]; !ok || pst[i].Date.Before(date) {
+			accounts[pst[i].Account] = pst[i].Date
+		}
+		if pst[i].Currency == "" {
+			continue
+		}
+		if date, ok := currencies[pst[i].Currency]; !ok || pst[i].Date.Before(date) {
+			currencies[pst[i].Currency] = pst[i].Date
+		}
+	}
+	return accounts, currencies
+}
+
+// sortedEntries returns a copy of the entries sorted by date.
+func (j Journal) sortedEntries() []Entry {
+	rsl := make([]Entry, len(j.Entries))
+	copy(rsl, j.Entries)
+	sort.SliceStable(rsl, func(i, k int) bool {
+		return rsl[i].Date.Before(rsl[k].Date)
+	})
+	return rsl
+}
+
+// sortedKeys returns the keys of the given map in alphabetical order.
+func sortedKeys(m map[string]time.Time) []string {
+	rsl := make([]string, 0, len(m))
+	for key := range m {
+		rsl = append(rsl, key)
+	}
+	sort.Strings(rsl)
+	return rsl
+}
+
+// postingWidth returns the number of characters of the longest account name. This is used
+// to align the amounts of the postings.
+func postingWidth(accounts ...string) int {
+	rsl := 0
+	for i := range accounts {
+		if n := utf8.RuneCountInString(accounts[i]); n > rsl {
+			rsl = n
+		}
+	}
+	return rsl
+}
+
+// decimal formats an amount in cents as a decimal number with two decimal places.
+func decimal(amount int64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, amount/100, amount%100)
+}
+
+// entryAmount returns the amount in cents and the currency code of an entry.
+func entryAmount(e Entry) (int64, string) {
+	if e.Amount.Money == nil {
+		return 0, ""
+	}
+	return e.Amount.Amount(), e.Amount.Currency().Code
+}
@@ -0,0 +1,82 @@
+package ledger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// EntriesForFixedAsset returns the journal entries for a fixed asset. The acquisition is
+// capitalised by moving the cost from the expense category of the acquisition expense to
+// the asset account. For each year of the depreciation schedule an entry at the end of
+// the year is added. If the given year is > 0 only the entries of this year are returned.
+func EntriesForFixedAsset(s schema.Schema, fas schema.FixedAsset, year int) []Entry {
+	cmt := NewComment("fixed asset", fas.String())
+	account := fas.Account
+	if account == "" {
+		account = s.JournalConfig.FixedAssetsAccount
+	}
+	exp, err := s.Expenses.ExpenseByRef(fas.Expense)
+	if err != nil {
+		logrus.Warnf("no journal entries for %s: %s", fas.String(), err)
+		return nil
+	}
+	catAccount := defaultAccount
+	cat, err := s.JournalConfig.ExpenseCategories.CategoryByName(exp.ExpenseCategory)
+	cmt.add(err)
+	if err == nil {
+		catAccount = cat.Account
+	}
+
+	var rsl []Entry
+	date := exp.AccrualDateTime()
+	if year <= 0 || date.Year() == year {
+		rsl = append(rsl, Entry{
+			ID:          fmt.Sprintf("%s-capitalisation", fas.Id),
+			Date:        date,
+			Status:      UnmarkedStatus,
+			Code:        fas.Identifier,
+			Description: fixedAssetDescription("capitalisation description", s.JournalConfig.CapitalisationDescription, fas, date.Year()),
+			Comment:     cmt,
+			Account1:    account,
+			Account2:    catAccount,
+			Amount:      exp.Amount,
+		})
+	}
+
+	schedule, err := fas.Schedule(s)
+	if err != nil {
+		logrus.Warnf("no depreciation entries for %s: %s", fas.String(), err)
+		return applyOverride(rsl, fas.Id, fas.JournalOverride)
+	}
+	currency := exp.Amount.Currency().Code
+	for i := range schedule {
+		if (year > 0 && schedule[i].Year != year) || schedule[i].Depreciation == 0 {
+			continue
+		}
+		rsl = append(rsl, Entry{
+			ID:          fmt.Sprintf("%s-%d", fas.Id, schedule[i].Year),
+			Date:        time.Date(schedule[i].Year, time.December, 31, 0, 0, 0, 0, time.UTC),
+			Status:      UnmarkedStatus,
+			Code:        fas.Identifier,
+			Description: fixedAssetDescription("depreciation description", s.JournalConfig.DepreciationDescription, fas, schedule[i].Year),
+			Comment:     NewComment("depreciation", fas.String()),
+			Account1:    s.JournalConfig.DepreciationAccount,
+			Account2:    account,
+			Amount:      util.NewMoney(schedule[i].Depreciation, currency),
+		})
+	}
+	return applyOverride(rsl, fas.Id, fas.JournalOverride)
+}
+
+func fixedAssetDescription(name, tpl string, fas schema.FixedAsset, year int) string {
+	data := map[string]string{
+		"Name":       fas.Name,
+		"Identifier": fas.Identifier,
+		"Year":       fmt.Sprint(year),
+	}
+	return util.ApplyTemplate(name, tpl, data)
+}
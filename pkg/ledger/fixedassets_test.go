@@ -0,0 +1,46 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestEntriesForFixedAsset(t *testing.T) {
+	s := schema.Schema{
+		Currency: "CHF",
+		Expenses: schema.Expenses{
+			{
+				Id:            "exp-1",
+				Identifier:    "e-1",
+				Amount:        util.NewMoney(100000, "CHF"),
+				DateOfAccrual: "2022-05-01",
+			},
+		},
+		JournalConfig: schema.NewJournalConfig(),
+	}
+	fas := schema.FixedAsset{
+		Id:            "fa-1",
+		Identifier:    "fa-1",
+		Name:          "Laptop",
+		Expense:       schema.NewRef("exp-1"),
+		UsefulLife:    3,
+		Method:        schema.DecliningBalanceDepreciation,
+		DecliningRate: 50,
+		ResidualValue: util.NewMoney(10000, "CHF"),
+	}
+	expected := []int64{100000, 50000, 25000, 15000}
+	entries := EntriesForFixedAsset(s, fas, 0)
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+	for i := range entries {
+		if entries[i].Amount.Amount() != expected[i] {
+			t.Errorf("entry %d: expected %d, got %d", i, expected[i], entries[i].Amount.Amount())
+		}
+	}
+	if entries := EntriesForFixedAsset(s, fas, 2023); len(entries) != 1 || entries[0].ID != "fa-1-2023" {
+		t.Errorf("expected only the depreciation of 2023, got %+v", entries)
+	}
+}
@@ -0,0 +1,219 @@
+package ledger
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// ImportCorrections takes the transactions of a manually edited hledger journal and
+// saves the corrections as schema.JournalOverride on the records. The transactions are
+// matched with the generated entries by their id (IDTag), transactions of older journals
+// without id are matched by the transaction code if the record has only one entry. A
+// override is stored when the accounts or the amount differ from the generated entry or
+// when the generated entry needed a manual correction and the edited transaction no
+// longer contains a TODO comment (the entry was reviewed). Transactions without id and
+// code are considered as hand-written and ignored. Returns the number of altered records.
+func ImportCorrections(s *schema.Schema, edited []JournalTransaction) int {
+	generated := make(map[string]Entry)
+	byCode := make(map[string][]Entry)
+	jrn := JournalFromAcc(*s, 0)
+	for i := range jrn.Entries {
+		generated[jrn.Entries[i].ID] = jrn.Entries[i]
+		byCode[jrn.Entries[i].Code] = append(byCode[jrn.Entries[i].Code], jrn.Entries[i])
+	}
+	targets := newOverrideTargets(s)
+
+	count := 0
+	for i := range edited {
+		trn := edited[i]
+		if trn.ID == "" && trn.Code == "" {
+			continue
+		}
+		entry, ok := generated[trn.ID]
+		if trn.ID == "" && len(byCode[trn.Code]) == 1 {
+			entry, ok = byCode[trn.Code][0], true
+		}
+		if !ok {
+			logrus.Warnf("no record for the journal transaction «%s» in line %d found", trn.label(), trn.FirstLine+1)
+			continue
+		}
+		acc1, acc2, amount, err := trn.entryValues(entry.Amount)
+		if err != nil {
+			logrus.Warnf("journal transaction «%s» in line %d can't be imported: %s", trn.label(), trn.FirstLine+1, err)
+			continue
+		}
+		equal, _ := amount.Equals(entry.Amount.Money)
+		reviewed := entry.Comment.DoManual && !strings.Contains(trn.Comment, "TODO")
+		if !reviewed && equal && acc1 == entry.Account1 && acc2 == entry.Account2 {
+			continue
+		}
+		target, key, ok := targets.find(entry.ID)
+		if !ok {
+			logrus.Warnf("corrections of the journal entry «%s» can't be stored, no record with a journal override found", entry.ID)
+			continue
+		}
+		target.set(key, acc1, acc2, amount)
+		logrus.Infof("stored correction of the journal entry for «%s»", trn.label())
+		count++
+	}
+	return count
+}
+
+// label returns the code of the transaction or its id if there is no code.
+func (t JournalTransaction) label() string {
+	if t.Code != "" {
+		return t.Code
+	}
+	return t.ID
+}
+
+// entryValues returns the accounts and the amount of the transaction in the form of an
+// Entry (the first account receives the positive amount). As an Entry consists of
+// exactly two postings, transactions with more postings are not supported.
+func (t JournalTransaction) entryValues(fallback util.Money) (string, string, util.Money, error) {
+	if len(t.Postings) != 2 {
+		return "", "", util.Money{}, fmt.Errorf("only transactions with two postings are supported but found %d", len(t.Postings))
+	}
+	p1, p2 := t.Postings[0], t.Postings[1]
+	var amount int64
+	var currency string
+	switch {
+	case p1.HasAmount && p2.HasAmount && p1.Amount+p2.Amount != 0:
+		return "", "", util.Money{}, fmt.Errorf("the transaction is unbalanced")
+	case p1.HasAmount:
+		amount, currency = p1.Amount, p1.Currency
+	case p2.HasAmount:
+		amount, currency = -p2.Amount, p2.Currency
+	default:
+		return "", "", util.Money{}, fmt.Errorf("no amount found")
+	}
+	if currency == "" && fallback.Money != nil {
+		currency = fallback.Currency().Code
+	}
+	if amount < 0 {
+		return p2.Account, p1.Account, util.NewMoney(-amount, currency), nil
+	}
+	return p1.Account, p2.Account, util.NewMoney(amount, currency), nil
+}
+
+// overrideTarget is the JournalOverride of a record together with the amount of it.
+type overrideTarget struct {
+	override **schema.JournalOverride
+	amount   util.Money
+}
+
+// set saves the given values as JournalOverride of the record. If key is empty, the
+// values apply to all entries of the record, otherwise only to the entry with the given
+// id suffix. The amount of a record wide override is only stored when it differs from
+// the amount of the record.
+func (t overrideTarget) set(key, acc1, acc2 string, amount util.Money) {
+	if *t.override == nil {
+		*t.override = &schema.JournalOverride{}
+	}
+	o := *t.override
+	if key == "" {
+		o.Account1, o.Account2, o.Amount = acc1, acc2, nil
+		if equal, _ := amount.Equals(t.amount.Money); !equal {
+			o.Amount = &amount
+		}
+		return
+	}
+	if o.Entries == nil {
+		o.Entries = make(map[string]schema.JournalOverride)
+	}
+	o.Entries[key] = schema.JournalOverride{Account1: acc1, Account2: acc2, Amount: &amount}
+}
+
+// overrideTargets maps the ids of all records with a JournalOverride to the override.
+type overrideTargets map[string]overrideTarget
+
+func newOverrideTargets(s *schema.Schema) overrideTargets {
+	rsl := make(overrideTargets)
+	for i := range s.Expenses {
+		rsl[s.Expenses[i].Id] = overrideTarget{&s.Expenses[i].JournalOverride, s.Expenses[i].Amount}
+	}
+	for i := range s.Invoices {
+		rsl[s.Invoices[i].Id] = overrideTarget{&s.Invoices[i].JournalOverride, s.Invoices[i].Amount}
+	}
+	for i := range s.Statement.Transactions {
+		rsl[s.Statement.Transactions[i].Id] = overrideTarget{&s.Statement.Transactions[i].JournalOverride, s.Statement.Transactions[i].Amount}
+	}
+	for i := range s.Salaries {
+		rsl[s.Salaries[i].Id] = overrideTarget{&s.Salaries[i].JournalOverride, s.Salaries[i].Gross}
+	}
+	for i := range s.CreditNotes {
+		rsl[s.CreditNotes[i].Id] = overrideTarget{&s.CreditNotes[i].JournalOverride, s.CreditNotes[i].Amount}
+	}
+	for i := range s.FixedAssets {
+		rsl[s.FixedAssets[i].Id] = overrideTarget{&s.FixedAssets[i].JournalOverride, util.Money{}}
+	}
+	delete(rsl, "")
+	return rsl
+}
+
+// find returns the record of the entry with the given id and the suffix of the entry id
+// (empty if the entry id is the id of the record).
+func (t overrideTargets) find(entryID string) (overrideTarget, string, bool) {
+	if target, ok := t[entryID]; ok {
+		return target, "", true
+	}
+	for end := len(entryID); end > 0; {
+		end = strings.LastIndex(entryID[:end], "-")
+		if end <= 0 {
+			break
+		}
+		if target, ok := t[entryID[:end]]; ok {
+			return target, entryID[end+1:], true
+		}
+	}
+	return overrideTarget{}, "", false
+}
+
+// applyOverride alters the entries generated for the record with the given id according
+// to the manual corrections stored in the record.
+func applyOverride(entries []Entry, id string, o *schema.JournalOverride) []Entry {
+	if o == nil {
+		return entries
+	}
+	for i := range entries {
+		corrections := []schema.JournalOverride{*o}
+		if entry, ok := o.Entries[entryKey(entries[i].ID, id)]; ok {
+			corrections = append(corrections, entry)
+		}
+		corrected := false
+		for _, c := range corrections {
+			if c.IsEmpty() {
+				continue
+			}
+			if c.Account1 != "" {
+				entries[i].Account1 = c.Account1
+			}
+			if c.Account2 != "" {
+				entries[i].Account2 = c.Account2
+			}
+			if c.Amount != nil && c.Amount.Money != nil {
+				entries[i].Amount = *c.Amount
+			}
+			corrected = true
+		}
+		if !corrected {
+			continue
+		}
+		entries[i].Comment.DoManual = false
+		entries[i].Comment.Errors = nil
+		entries[i].Comment.Corrected = true
+	}
+	return entries
+}
+
+// entryKey returns the suffix of the entry id after the id of the record.
+func entryKey(entryID, recordID string) string {
+	if entryID == recordID || !strings.HasPrefix(entryID, recordID+"-") {
+		return ""
+	}
+	return strings.TrimPrefix(entryID, recordID+"-")
+}
@@ -0,0 +1,53 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestImportCorrections(t *testing.T) {
+	s := schema.Schema{
+		Currency:      "CHF",
+		JournalConfig: schema.NewJournalConfig(),
+		PayrollConfig: schema.NewPayrollConfig(),
+		Parties: schema.PartiesCollection{
+			Employees: []schema.Party{{Id: "emp-1", Identifier: "y-1", Name: "Anna"}},
+		},
+	}
+	sal := schema.NewSalary(s.PayrollConfig, util.NewMoney(500000, "CHF"))
+	sal.Id = "sal-1"
+	sal.Identifier = "s-1"
+	sal.Employee = schema.NewRef("emp-1")
+	sal.Month = "2022-02"
+	s.Salaries = schema.Salaries{sal}
+
+	edited, err := ParseHLedger(JournalFromAcc(s, 0).HLedger())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range edited {
+		if edited[i].ID == "sal-1-2" {
+			edited[i].Postings[1].Account = "liabilities:Sozialversicherungen"
+		}
+	}
+	if n := ImportCorrections(&s, edited); n != 1 {
+		t.Fatalf("expected one correction, got %d", n)
+	}
+	o := s.Salaries[0].JournalOverride
+	if o == nil || !o.IsEmpty() || o.Entries["2"].Account2 != "liabilities:Sozialversicherungen" {
+		t.Fatalf("correction not stored for the second entry: %+v", o)
+	}
+
+	entries := EntriesForSalary(s, s.Salaries[0])
+	if entries[0].Account2 == "liabilities:Sozialversicherungen" || entries[0].Comment.Corrected {
+		t.Errorf("correction of the second entry applied to the first one: %+v", entries[0])
+	}
+	if entries[1].Account2 != "liabilities:Sozialversicherungen" || !entries[1].Comment.Corrected {
+		t.Errorf("correction not applied to the second entry: %+v", entries[1])
+	}
+	if n := ImportCorrections(&s, edited); n != 0 {
+		t.Errorf("unchanged journal imported %d corrections", n)
+	}
+}
@@ -10,11 +10,15 @@ import (
 // INVOICING ENTRIES
 
 // EntriesForInvoicing returns the journal entries for invoicing of the given
-// schema.Invoice.
+// schema.Invoice. Drafts aren't booked.
 func EntriesForInvoicing(s schema.Schema, inv schema.Invoice) []Entry {
-	if inv.Revoked {
+	if inv.Revoked || inv.IsDraft() {
 		return []Entry{}
 	}
+	return recordEntries(entriesForInvoicing(s, inv), inv.Id, inv.JournalOverride)
+}
+
+func entriesForInvoicing(s schema.Schema, inv schema.Invoice) []Entry {
 	cmt := NewComment("invoice sent", inv.String())
 
 	cmp, err := s.Parties.CustomerByRef(inv.Customer)
@@ -48,10 +52,11 @@ func EntriesForInvoicing(s schema.Schema, inv schema.Invoice) []Entry {
 // SETTLEMENT
 
 // SettlementEntriesForInvoice returns the entries for the settlement (aka receiving the
-// money from the customer) of the related invoice.
+// money from the customer) of the related invoice. The expected amount is reduced by the
+// credit notes issued for the invoice.
 func SettlementEntriesForInvoice(s schema.Schema, trn schema.Transaction, inv schema.Invoice) []Entry {
 	cmt := NewComment("invoice settlement", trn.String())
-	cmt.add(compareAmounts(trn.Amount, inv.Amount))
+	cmt.add(compareAmounts(trn.Amount, inv.OpenAmount(s)))
 
 	cmp, err := s.Parties.CustomerByRef(inv.Customer)
 	cmt.add(err)
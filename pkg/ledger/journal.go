@@ -75,9 +75,23 @@ func JournalFromAcc(s schema.Schema, year int) Journal {
 	for i := range fAcc.Invoices {
 		rsl.AddEntries(EntriesForInvoicing(s, fAcc.Invoices[i]))
 	}
+	for i := range fAcc.CreditNotes {
+		rsl.AddEntries(EntriesForCreditNote(s, fAcc.CreditNotes[i]))
+	}
 	for i := range fAcc.Statement.Transactions {
 		rsl.AddEntries(EntriesForTransaction(s, fAcc.Statement.Transactions[i]))
 	}
+	for i := range fAcc.Salaries {
+		rsl.AddEntries(EntriesForSalary(s, fAcc.Salaries[i]))
+	}
+	for i := range s.FixedAssets {
+		rsl.AddEntries(EntriesForFixedAsset(s, s.FixedAssets[i], year))
+	}
+	for i := range rsl.Entries {
+		if rsl.Entries[i].Comment.DoManual {
+			logrus.Warnf("journal entry of «%s» needs manual correction", rsl.Entries[i].Comment.Element)
+		}
+	}
 	return rsl
 }
 
@@ -130,11 +144,13 @@ func (j Journal) Less(i, k int) bool {
 }
 
 // Comment reassembles a comment which can be generated based on the state of this struct.
+// Corrected states whether the entry was altered by a schema.JournalOverride.
 type Comment struct {
-	Mode     string
-	Element  string
-	DoManual bool
-	Errors   []error
+	Mode      string
+	Element   string
+	DoManual  bool
+	Corrected bool
+	Errors    []error
 }
 
 // NewComment returns a new Comment.
@@ -152,7 +168,6 @@ func NewComment(mode, element string) Comment {
 func NewManualComment(mode, element string) Comment {
 	cmt := NewComment(mode, element)
 	cmt.DoManual = true
-	logrus.Warnf("journal entry of «%s» needs manual correction", element)
 	return cmt
 }
 
@@ -169,6 +184,9 @@ func (c Comment) String() string {
 	if c.DoManual {
 		return "TODO: manual correction needed"
 	}
+	if c.Corrected {
+		return fmt.Sprintf("parsed as %s, manually corrected", c.Mode)
+	}
 	if len(c.Errors) == 0 {
 		return fmt.Sprint("parsed as ", c.Mode)
 	}
@@ -207,8 +225,11 @@ func (s EntryStatus) TrnEle() string {
 	return "UNDEFINED"
 }
 
-// Entry is a single journal entry.
+// Entry is a single journal entry. ID is a stable identifier derived from the record the
+// entry was generated from. It's written as a tag into the journal and used to update the
+// acc managed entries of an existing journal.
 type Entry struct {
+	ID              string
 	TransactionType util.TransactionType
 	Date            time.Time
 	Status          EntryStatus
@@ -222,13 +243,16 @@ type Entry struct {
 
 const trnTpl = `
 {{.Date}} {{if .Code }}({{.Code}}) {{end}}{{.Description}} {{if ne .Comment ""}}; {{.Comment}}{{end}}
-    {{.Account1}}{{.Space1}}{{.Amount1}}
+{{if .ID}}    ; {{.IDTag}}:{{.ID}}
+{{end}}    {{.Account1}}{{.Space1}}{{.Amount1}}
     {{.Account2}}{{.Space2}}{{.Amount2}}
 `
 
 // Transaction renders the hledger transaction for the given entry.
 func (e Entry) Transaction() string {
 	data := struct {
+		ID          string
+		IDTag       string
 		Date        string
 		Code        string
 		Description string
@@ -240,6 +264,8 @@ func (e Entry) Transaction() string {
 		Space2      string
 		Amount2     string
 	}{
+		ID:          e.ID,
+		IDTag:       IDTag,
 		Date:        e.trnDate(),
 		Code:        e.Code,
 		Description: e.Description,
@@ -286,10 +312,11 @@ func (e Entry) trnAmount(invers bool) string {
 	if invers {
 		sign = "-"
 	}
-	if ok, _ := e.Amount.Equals(e.Amount.Round()); ok {
+	// Don't use Money.Round as it alters the amount in place.
+	if e.Amount.Amount()%100 == 0 {
 		return fmt.Sprintf("CHF%s%d", sign, e.Amount.Amount()/100)
 	}
-	return fmt.Sprintf("CHF%s%s", sign, e.Amount.Value())
+	return fmt.Sprintf("CHF%s%s", sign, decimal(e.Amount.Amount()))
 }
 
 func compareAmounts(a util.Money, b util.Money) error {
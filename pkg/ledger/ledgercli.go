@@ -0,0 +1,65 @@
+package ledger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LedgerWriter writes journals for ledger-cli. As the alias semantics of ledger-cli
+// differ from hledger, the aliases are resolved while writing and all used accounts
+// are declared explicitly.
+type LedgerWriter struct{}
+
+// Render returns the ledger-cli journal. Entries without an amount are skipped.
+func (w LedgerWriter) Render(j Journal) string {
+	j = j.exportable()
+	var b strings.Builder
+	accounts, currencies := j.accountOpenings()
+
+	for _, cur := range sortedKeys(currencies) {
+		fmt.Fprintf(&b, "commodity %s\n", cur)
+	}
+	if len(currencies) > 0 {
+		b.WriteString("\n")
+	}
+	for _, acc := range sortedKeys(accounts) {
+		fmt.Fprintf(&b, "account %s\n", acc)
+	}
+
+	entries := j.sortedEntries()
+	for i := range entries {
+		b.WriteString("\n")
+		b.WriteString(w.transaction(j, entries[i]))
+	}
+	return b.String()
+}
+
+// Extension returns the file extension of ledger-cli journals.
+func (LedgerWriter) Extension() string {
+	return "ledger"
+}
+
+func (w LedgerWriter) transaction(j Journal, e Entry) string {
+	var b strings.Builder
+	b.WriteString(e.Date.Format(HLedgerDateFormat))
+	if status := e.Status.TrnEle(); status != "" {
+		fmt.Fprintf(&b, " %s", status)
+	}
+	if e.Code != "" {
+		fmt.Fprintf(&b, " (%s)", e.Code)
+	}
+	fmt.Fprintf(&b, " %s\n", e.Description)
+	if cmt := e.Comment.String(); cmt != "" {
+		fmt.Fprintf(&b, "    ; %s\n", cmt)
+	}
+	if e.ID != "" {
+		fmt.Fprintf(&b, "    ; %s: %s\n", IDTag, e.ID)
+	}
+
+	amount, currency := entryAmount(e)
+	acc1, acc2 := j.ResolveAlias(e.Account1), j.ResolveAlias(e.Account2)
+	width := postingWidth(acc1, acc2)
+	fmt.Fprintf(&b, "    %-*s  %s %s\n", width, acc1, currency, decimal(amount))
+	fmt.Fprintf(&b, "    %-*s  %s %s\n", width, acc2, currency, decimal(-amount))
+	return b.String()
+}
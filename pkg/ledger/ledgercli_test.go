@@ -0,0 +1,26 @@
+package ledger
+
+import "testing"
+
+func TestLedgerRender(t *testing.T) {
+	expected := `commodity CHF
+
+account assets:Bank:PostFinance
+account expenses:Material
+account revenues:Tickets
+
+2022-03-01 Hamlet "premiere"
+    ; TODO: manual correction needed
+    assets:Bank:PostFinance  CHF 1200.50
+    revenues:Tickets         CHF -1200.50
+
+2022-03-04 * (e-1) Stage wood
+    ; parsed as expense
+    ; acc-id: exp-1
+    expenses:Material        CHF 500.00
+    assets:Bank:PostFinance  CHF -500.00
+`
+	if rsl := (LedgerWriter{}).Render(exportJournal()); rsl != expected {
+		t.Errorf("unexpected journal:\n%s\nexpected:\n%s", rsl, expected)
+	}
+}
@@ -0,0 +1,246 @@
+package ledger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+)
+
+// JournalTransaction is a transaction read from a (possibly manually edited) hledger
+// journal. Only the parts of the hledger syntax used by acc are supported: a header line
+// with date, status, code, description and comment followed by the indented postings.
+type JournalTransaction struct {
+	// ID is the value of the IDTag, empty for transactions not managed by acc.
+	ID string
+	// FirstLine and LastLine are the zero based line numbers of the transaction in the journal.
+	FirstLine   int
+	LastLine    int
+	Date        time.Time
+	Status      EntryStatus
+	Code        string
+	Description string
+	Comment     string
+	Postings    []JournalPosting
+}
+
+// JournalPosting is a single posting of a JournalTransaction. HasAmount is false when
+// the amount was omitted and has to be inferred by balancing the transaction.
+type JournalPosting struct {
+	Account   string
+	HasAmount bool
+	Amount    int64
+	Currency  string
+}
+
+// journalDateRegex matches the primary date of a transaction header.
+var journalDateRegex = regexp.MustCompile(`^(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})(=\S*)?`)
+
+// postingRegex splits a posting into the account and the amount which are separated
+// by at least two spaces or a tab.
+var postingRegex = regexp.MustCompile(`^(.*?)(?: {2,}|\t)\s*(.*)$`)
+
+// idTagRegex matches a comment line containing the IDTag.
+var idTagRegex = regexp.MustCompile(`^[;#]\s*` + IDTag + `:\s*([^,\s]+)`)
+
+// OpenHLedgerFile reads and parses the hledger journal at the given path.
+func OpenHLedgerFile(path string) []JournalTransaction {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		logrus.Fatalf("error reading journal %s: %s", path, err)
+	}
+	rsl, err := ParseHLedger(string(raw))
+	if err != nil {
+		logrus.Fatalf("error parsing journal %s: %s", path, err)
+	}
+	return rsl
+}
+
+// ParseHLedger parses the transactions of the given hledger journal. Directives (alias,
+// include etc.) and comments outside of transactions are ignored.
+func ParseHLedger(content string) ([]JournalTransaction, error) {
+	var rsl []JournalTransaction
+	var current *JournalTransaction
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := range lines {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		indented := len(line) > 0 && (line[0] == ' ' || line[0] == '\t')
+
+		if current != nil && indented && trimmed != "" {
+			current.LastLine = i
+			if trimmed[0] == ';' || trimmed[0] == '#' {
+				if id := idTagRegex.FindStringSubmatch(trimmed); id != nil {
+					current.ID = id[1]
+					continue
+				}
+				if len(current.Postings) == 0 {
+					current.Comment = joinComment(current.Comment, trimmed[1:])
+				}
+				continue
+			}
+			pst, err := parsePosting(trimmed)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", i+1, err)
+			}
+			current.Postings = append(current.Postings, pst)
+			continue
+		}
+		if current != nil {
+			rsl = append(rsl, *current)
+			current = nil
+		}
+		if !journalDateRegex.MatchString(line) {
+			continue
+		}
+		trn, err := parseTransactionHeader(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", i+1, err)
+		}
+		trn.FirstLine = i
+		trn.LastLine = i
+		current = &trn
+	}
+	if current != nil {
+		rsl = append(rsl, *current)
+	}
+	return rsl, nil
+}
+
+// parseTransactionHeader parses the first line of a transaction.
+func parseTransactionHeader(line string) (JournalTransaction, error) {
+	rsl := JournalTransaction{}
+	match := journalDateRegex.FindStringSubmatch(line)
+	year, _ := strconv.Atoi(match[1])
+	month, _ := strconv.Atoi(match[2])
+	day, _ := strconv.Atoi(match[3])
+	rsl.Date = time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if rsl.Date.Month() != time.Month(month) || rsl.Date.Day() != day {
+		return rsl, fmt.Errorf("invalid date «%s»", match[0])
+	}
+
+	rest := strings.TrimSpace(line[len(match[0]):])
+	if i := strings.Index(rest, ";"); i >= 0 {
+		rsl.Comment = strings.TrimSpace(rest[i+1:])
+		rest = strings.TrimSpace(rest[:i])
+	}
+	switch {
+	case strings.HasPrefix(rest, "*"):
+		rsl.Status = ClearedStatus
+		rest = strings.TrimSpace(rest[1:])
+	case strings.HasPrefix(rest, "!"):
+		rsl.Status = PendingStatus
+		rest = strings.TrimSpace(rest[1:])
+	}
+	if strings.HasPrefix(rest, "(") {
+		end := strings.Index(rest, ")")
+		if end < 0 {
+			return rsl, fmt.Errorf("unclosed transaction code in «%s»", line)
+		}
+		rsl.Code = strings.TrimSpace(rest[1:end])
+		rest = strings.TrimSpace(rest[end+1:])
+	}
+	rsl.Description = rest
+	return rsl, nil
+}
+
+// parsePosting parses a (trimmed) posting line.
+func parsePosting(line string) (JournalPosting, error) {
+	if i := strings.Index(line, ";"); i >= 0 {
+		line = strings.TrimSpace(line[:i])
+	}
+	if strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "! ") {
+		line = strings.TrimSpace(line[2:])
+	}
+	match := postingRegex.FindStringSubmatch(line)
+	if match == nil || strings.TrimSpace(match[2]) == "" {
+		return JournalPosting{Account: strings.TrimSpace(line)}, nil
+	}
+	rsl := JournalPosting{Account: strings.TrimSpace(match[1])}
+	amount := match[2]
+	// Ignore balance assertions and prices.
+	if i := strings.IndexAny(amount, "=@"); i >= 0 {
+		amount = amount[:i]
+	}
+	value, currency, err := parseAmount(amount)
+	if err != nil {
+		return rsl, err
+	}
+	rsl.HasAmount = true
+	rsl.Amount = value
+	rsl.Currency = currency
+	return rsl, nil
+}
+
+// parseAmount parses an hledger amount (like «CHF-12.50», «-1'200.00 CHF» or «CHF 5»)
+// and returns the value in cents and the commodity.
+func parseAmount(input string) (int64, string, error) {
+	var commodity, number strings.Builder
+	negative := false
+	for _, char := range input {
+		switch {
+		case unicode.IsDigit(char) || char == '.' || char == ',':
+			number.WriteRune(char)
+		case char == '-':
+			negative = true
+		case char == '+' || char == '\'' || char == '"' || unicode.IsSpace(char):
+			continue
+		default:
+			commodity.WriteRune(char)
+		}
+	}
+	num := number.String()
+	if num == "" {
+		return 0, "", fmt.Errorf("no number found in amount «%s»", strings.TrimSpace(input))
+	}
+
+	// The last separator is the decimal mark if there are both or if a single comma is
+	// followed by one or two digits.
+	dot, comma := strings.LastIndex(num, "."), strings.LastIndex(num, ",")
+	switch {
+	case dot >= 0 && comma >= 0 && comma > dot:
+		num = strings.ReplaceAll(num[:comma], ".", "") + "." + num[comma+1:]
+	case dot >= 0:
+		num = strings.ReplaceAll(num, ",", "")
+	case comma >= 0 && strings.Count(num, ",") == 1 && len(num)-comma <= 3:
+		num = strings.Replace(num, ",", ".", 1)
+	default:
+		num = strings.ReplaceAll(num, ",", "")
+	}
+
+	parts := strings.SplitN(num, ".", 2)
+	if strings.Contains(parts[0], ".") || (len(parts) == 2 && strings.Contains(parts[1], ".")) {
+		return 0, "", fmt.Errorf("invalid number in amount «%s»", strings.TrimSpace(input))
+	}
+	units, err := strconv.ParseInt("0"+parts[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid number in amount «%s»", strings.TrimSpace(input))
+	}
+	var cents int64
+	if len(parts) == 2 {
+		frac := strings.TrimRight(parts[1], "0")
+		if len(frac) > 2 {
+			return 0, "", fmt.Errorf("amount «%s» has more than two decimal places", strings.TrimSpace(input))
+		}
+		frac = (frac + "00")[:2]
+		cents, _ = strconv.ParseInt(frac, 10, 64)
+	}
+	value := units*100 + cents
+	if negative {
+		value = -value
+	}
+	return value, strings.TrimSpace(commodity.String()), nil
+}
+
+func joinComment(existing, addition string) string {
+	addition = strings.TrimSpace(addition)
+	if existing == "" {
+		return addition
+	}
+	return fmt.Sprintf("%s %s", existing, addition)
+}
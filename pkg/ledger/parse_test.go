@@ -0,0 +1,82 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+const editedJournal = `
+alias Aufwand = expenses
+
+2022-03-01 (e-2) Aufwand für Rent March (e-2) ; TODO: manual correction needed
+    expenses:Raumaufwand        CHF1200
+    liabilities:Kreditoren        CHF-1200
+
+; hand-written entry
+2022/04/02 * Kaffee
+    ; some comment
+    expenses:Verpflegung    12.50 CHF  ; inline comment
+    assets:Kasse
+
+2022-05-02 (e-4) Bezahlung des Aufwands e-4
+    liabilities:Anna    CHF -1'080.50
+    expenses:Material
+`
+
+func TestParseHLedger(t *testing.T) {
+	trns, err := ParseHLedger(editedJournal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trns) != 3 {
+		t.Fatalf("expected 3 transactions but got %d", len(trns))
+	}
+	if trns[0].Code != "e-2" || trns[0].Comment != "TODO: manual correction needed" || trns[0].FirstLine != 3 || trns[0].LastLine != 5 {
+		t.Errorf("unexpected first transaction: %+v", trns[0])
+	}
+	if trns[0].Postings[1].Amount != -120000 || trns[0].Postings[1].Currency != "CHF" {
+		t.Errorf("unexpected posting: %+v", trns[0].Postings[1])
+	}
+	if trns[1].Status != ClearedStatus || trns[1].Description != "Kaffee" || trns[1].Comment != "some comment" {
+		t.Errorf("unexpected second transaction: %+v", trns[1])
+	}
+	if trns[1].Postings[0].Amount != 1250 || trns[1].Postings[1].HasAmount {
+		t.Errorf("unexpected postings: %+v", trns[1].Postings)
+	}
+
+	acc1, acc2, amount, err := trns[2].entryValues(util.NewMoney(0, "CHF"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc1 != "expenses:Material" || acc2 != "liabilities:Anna" || amount.Amount() != 108050 {
+		t.Errorf("unexpected entry values: %s, %s, %d", acc1, acc2, amount.Amount())
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := []struct {
+		input    string
+		amount   int64
+		currency string
+	}{
+		{"CHF500", 50000, "CHF"},
+		{"CHF-80.5", -8050, "CHF"},
+		{"-1,234.56 USD", -123456, "USD"},
+		{"1.234,56 EUR", 123456, "EUR"},
+		{"12,5", 1250, ""},
+	}
+	for i := range cases {
+		amount, currency, err := parseAmount(cases[i].input)
+		if err != nil {
+			t.Errorf("error parsing «%s»: %s", cases[i].input, err)
+			continue
+		}
+		if amount != cases[i].amount || currency != cases[i].currency {
+			t.Errorf("«%s» parsed as %d %s", cases[i].input, amount, currency)
+		}
+	}
+	if _, _, err := parseAmount("CHF1.005"); err == nil {
+		t.Error("amounts with three decimal places should fail")
+	}
+}
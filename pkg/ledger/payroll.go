@@ -0,0 +1,88 @@
+package ledger
+
+import (
+	"fmt"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+// EntriesForSalary returns the journal entries for the accrual of a salary: The gross
+// salary is booked as expense against the salary liabilities of the employee and the
+// social security deductions are moved from the salary liabilities to the social
+// security liabilities. The payment of the net salary is booked by the settlement
+// transaction (see SettlementEntriesForSalary).
+func EntriesForSalary(s schema.Schema, sal schema.Salary) []Entry {
+	cmt := NewComment("salary", sal.String())
+	date, err := sal.AccrualDateTime()
+	cmt.add(err)
+	emp, err := s.Parties.EmployeeByRef(sal.Employee)
+	cmt.add(err)
+
+	liabilities := s.PayrollConfig.SalaryLiabilitiesAccount
+	data := map[string]string{
+		"Identifier": sal.Identifier,
+		"Month":      sal.Month,
+		"Party":      "no employee found",
+	}
+	if err == nil {
+		liabilities = fmt.Sprintf("%s:%s", liabilities, emp.Name)
+		data["Party"] = fmt.Sprintf("%s (%s)", emp.Name, emp.Identifier)
+	}
+
+	rsl := []Entry{
+		{
+			Date:        date,
+			Status:      UnmarkedStatus,
+			Code:        sal.Identifier,
+			Description: util.ApplyTemplate("salary description", s.PayrollConfig.SalaryDescription, data),
+			Comment:     cmt,
+			Account1:    s.PayrollConfig.SalaryAccount,
+			Account2:    liabilities,
+			Amount:      sal.Gross,
+		}}
+	if sal.TotalDeductions() != 0 {
+		rsl = append(rsl, Entry{
+			Date:        date,
+			Status:      UnmarkedStatus,
+			Code:        sal.Identifier,
+			Description: util.ApplyTemplate("salary deductions description", s.PayrollConfig.DeductionsDescription, data),
+			Comment:     NewComment("salary deductions", sal.String()),
+			Account1:    liabilities,
+			Account2:    s.PayrollConfig.SocialSecurityAccount,
+			Amount:      util.NewMoney(sal.TotalDeductions(), sal.Gross.Currency().Code),
+		})
+	}
+	return recordEntries(rsl, sal.Id, sal.JournalOverride)
+}
+
+// SettlementEntriesForSalary returns the journal entries for the payment of the net salary.
+func SettlementEntriesForSalary(s schema.Schema, trn schema.Transaction, sal schema.Salary) []Entry {
+	cmt := NewComment("salary payment", trn.String())
+	cmt.add(compareAmounts(trn.Amount, sal.Net()))
+	emp, err := s.Parties.EmployeeByRef(sal.Employee)
+	cmt.add(err)
+
+	liabilities := s.PayrollConfig.SalaryLiabilitiesAccount
+	data := map[string]string{
+		"Identifier": sal.Identifier,
+		"Month":      sal.Month,
+		"Party":      "no employee found",
+	}
+	if err == nil {
+		liabilities = fmt.Sprintf("%s:%s", liabilities, emp.Name)
+		data["Party"] = fmt.Sprintf("%s (%s)", emp.Name, emp.Identifier)
+	}
+
+	return []Entry{
+		{
+			Date:        trn.DateTime(),
+			Status:      UnmarkedStatus,
+			Code:        trn.Identifier,
+			Description: util.ApplyTemplate("salary payment description", s.PayrollConfig.SalaryPaymentDescription, data),
+			Comment:     cmt,
+			Account1:    liabilities,
+			Account2:    s.JournalConfig.BankAccount,
+			Amount:      trn.Amount,
+		}}
+}
@@ -0,0 +1,39 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestEntriesForSalary(t *testing.T) {
+	s := schema.Schema{
+		Currency:      "CHF",
+		PayrollConfig: schema.NewPayrollConfig(),
+		Parties: schema.PartiesCollection{
+			Employees: []schema.Party{{Id: "emp-1", Identifier: "y-1", Name: "Anna"}},
+		},
+	}
+	sal := schema.NewSalary(s.PayrollConfig, util.NewMoney(500000, "CHF"))
+	sal.Identifier = "s-1"
+	sal.Employee = schema.NewRef("emp-1")
+	sal.Month = "2022-02"
+	if sal.NetAmount() != 468000 {
+		t.Errorf("expected net salary of 4680.00, got %d", sal.NetAmount())
+	}
+
+	entries := EntriesForSalary(s, sal)
+	if len(entries) != 2 {
+		t.Fatalf("expected two entries, got %d", len(entries))
+	}
+	if entries[0].Date.Format(HLedgerDateFormat) != "2022-02-28" {
+		t.Errorf("salary should be booked at the end of the month, got %s", entries[0].Date)
+	}
+	if entries[0].Amount.Amount() != 500000 || entries[1].Amount.Amount() != 32000 {
+		t.Errorf("unexpected amounts %d and %d", entries[0].Amount.Amount(), entries[1].Amount.Amount())
+	}
+	if entries[0].Account2 != entries[1].Account1 {
+		t.Errorf("deductions have to be booked against the salary liabilities of the employee")
+	}
+}
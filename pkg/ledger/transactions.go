@@ -10,10 +10,10 @@ import (
 
 // EntriesForTransaction returns the journal entries for a given schema.Transaction.
 func EntriesForTransaction(s schema.Schema, trn schema.Transaction) []Entry {
-	if trn.AssociatedDocument.Empty() {
-		return entriesForTransactionWithDocument(s, trn)
+	if !trn.AssociatedDocument.Empty() {
+		return recordEntries(entriesForTransactionWithDocument(s, trn), trn.Id, trn.JournalOverride)
 	}
-	return entrieForDefaultTransaction(s, trn, nil)
+	return recordEntries(entrieForDefaultTransaction(s, trn, nil), trn.Id, trn.JournalOverride)
 }
 
 // entriesForTransactionWithDocument returns the entries for transactions with an associated
@@ -27,7 +27,15 @@ func entriesForTransactionWithDocument(s schema.Schema, trn schema.Transaction)
 	if err == nil {
 		return SettlementEntriesForInvoice(s, trn, *inv)
 	}
-	return entrieForDefaultTransaction(s, trn, fmt.Errorf("no expense/invoice for id \"%s\" found", trn.AssociatedDocument))
+	sal, err := s.Salaries.SalaryByRef(trn.AssociatedDocument)
+	if err == nil {
+		return SettlementEntriesForSalary(s, trn, *sal)
+	}
+	crn, err := s.CreditNotes.CreditNoteByRef(trn.AssociatedDocument)
+	if err == nil {
+		return SettlementEntriesForCreditNote(s, trn, *crn)
+	}
+	return entrieForDefaultTransaction(s, trn, fmt.Errorf("no expense/invoice/salary/credit note for id \"%s\" found", trn.AssociatedDocument))
 }
 
 // entrieForDefaultTransaction is the fallback function. It is possible to give an additional
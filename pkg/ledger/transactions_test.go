@@ -0,0 +1,41 @@
+package ledger
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestEntriesForTransaction(t *testing.T) {
+	s := schema.Schema{
+		Currency:      "CHF",
+		JournalConfig: schema.NewJournalConfig(),
+	}
+	inv := schema.NewInvoiceWithUuid()
+	inv.Identifier = "i-1"
+	inv.Amount = util.NewMoney(50000, "CHF")
+	s.Invoices = schema.Invoices{inv}
+	trn := schema.Transaction{
+		Id:                 "trn-1",
+		Identifier:         "t-1",
+		Amount:             util.NewMoney(50000, "CHF"),
+		Date:               "2022-03-01",
+		TransactionType:    util.CreditTransaction,
+		AssociatedDocument: schema.NewRef(inv.Id),
+	}
+
+	// A transaction with an associated document settles the document, it was booked to the
+	// default account when the condition was inverted.
+	for _, entry := range EntriesForTransaction(s, trn) {
+		if entry.Account1 == defaultAccount || entry.Account2 == defaultAccount {
+			t.Errorf("transaction with associated invoice booked to %s", defaultAccount)
+		}
+	}
+
+	trn.AssociatedDocument = schema.NewRef("")
+	entries := EntriesForTransaction(s, trn)
+	if len(entries) != 1 || entries[0].Account2 != defaultAccount {
+		t.Errorf("transaction without document has to be booked to %s, got %+v", defaultAccount, entries)
+	}
+}
@@ -0,0 +1,172 @@
+package ledger
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/sirupsen/logrus"
+)
+
+// IDTag is the name of the hledger tag containing the stable ID of an entry. All
+// transactions with this tag are considered to be managed by acc.
+const IDTag = "acc-id"
+
+// recordEntries finalizes the entries generated for a record. Each entry gets a stable
+// ID based on the id of the record (or the code if the record has no id) and the manual
+// corrections are applied.
+func recordEntries(entries []Entry, id string, o *schema.JournalOverride) []Entry {
+	for i := range entries {
+		entries[i].ID = id
+		if entries[i].ID == "" {
+			entries[i].ID = entries[i].Code
+		}
+		if len(entries) > 1 {
+			entries[i].ID = fmt.Sprintf("%s-%d", entries[i].ID, i+1)
+		}
+	}
+	return applyOverride(entries, id, o)
+}
+
+// UpdateHLedgerFile updates the acc managed entries of the hledger journal at the given
+// path (see UpdateHLedger). If the file doesn't exist, a new journal is saved.
+func (j Journal) UpdateHLedgerFile(path string, from, to *time.Time) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		logrus.Infof("journal %s not found, will create a new one", path)
+		j.SaveHLedgerFile(path)
+		return
+	}
+	rsl, err := j.UpdateHLedger(string(raw), from, to)
+	if err != nil {
+		logrus.Fatalf("error updating journal %s: %s", path, err)
+	}
+	if err := ioutil.WriteFile(path, []byte(rsl), 0644); err != nil {
+		logrus.Fatalf("error writing file %s: %s", path, err)
+	}
+}
+
+// UpdateHLedger takes the content of an existing hledger journal and replaces all acc
+// managed transactions (the ones with the IDTag) with the current entries of the Journal.
+// Transactions without ID (hand-written or foreign ones), comments and directives like
+// include are left untouched. Managed transactions without matching entry within the
+// given date range are removed (from and to can be nil). New entries are inserted after
+// the last transaction with the same or an earlier date, entries older than all existing
+// transactions are appended (hledger sorts the transactions anyway). Missing aliases are
+// added at the top of the journal.
+func (j Journal) UpdateHLedger(existing string, from, to *time.Time) (string, error) {
+	trns, err := ParseHLedger(existing)
+	if err != nil {
+		return "", err
+	}
+	entries := j.sortedEntries()
+	rendered := make(map[string]string)
+	for i := range entries {
+		if entries[i].ID == "" {
+			return "", fmt.Errorf("entry «%s» has no ID", entries[i].Description)
+		}
+		rendered[entries[i].ID] = strings.Trim(entries[i].Transaction(), "\n")
+	}
+
+	lines := strings.Split(strings.TrimRight(strings.ReplaceAll(existing, "\r\n", "\n"), "\n"), "\n")
+	// replace maps the first line of a transaction to its new content (empty for removal).
+	replace := make(map[int]*string)
+	// insert maps a line to the new transactions which have to be appended after it.
+	insert := make(map[int][]string)
+	used := make(map[string]bool)
+	var kept []JournalTransaction
+	for i := range trns {
+		trn := trns[i]
+		if trn.ID == "" {
+			kept = append(kept, trn)
+			continue
+		}
+		if content, ok := rendered[trn.ID]; ok && !used[trn.ID] {
+			replace[trn.FirstLine] = &content
+			used[trn.ID] = true
+			kept = append(kept, trn)
+			continue
+		}
+		if (from != nil && trn.Date.Before(*from)) || (to != nil && trn.Date.After(*to)) {
+			kept = append(kept, trn)
+			continue
+		}
+		empty := ""
+		replace[trn.FirstLine] = &empty
+	}
+
+	var appendix []string
+	for i := range entries {
+		if used[entries[i].ID] {
+			continue
+		}
+		after := -1
+		for k := range kept {
+			if !kept[k].Date.After(entries[i].Date) {
+				after = kept[k].LastLine
+			}
+		}
+		content := rendered[entries[i].ID]
+		if after < 0 {
+			appendix = append(appendix, content)
+			continue
+		}
+		insert[after] = append(insert[after], content)
+	}
+
+	var b strings.Builder
+	for _, alias := range j.missingAliases(lines) {
+		fmt.Fprintf(&b, "%s\n", alias)
+	}
+	last := make(map[int]int)
+	for i := range trns {
+		last[trns[i].FirstLine] = trns[i].LastLine
+	}
+	skipBlank := false
+	for i := 0; i < len(lines); i++ {
+		if skipBlank && strings.TrimSpace(lines[i]) == "" {
+			skipBlank = false
+			continue
+		}
+		skipBlank = false
+		if content, ok := replace[i]; ok {
+			end := last[i]
+			if *content == "" {
+				skipBlank = true
+			} else {
+				fmt.Fprintf(&b, "%s\n", *content)
+			}
+			for _, add := range insert[end] {
+				fmt.Fprintf(&b, "\n%s\n", add)
+			}
+			i = end
+			continue
+		}
+		fmt.Fprintf(&b, "%s\n", lines[i])
+		for _, add := range insert[i] {
+			fmt.Fprintf(&b, "\n%s\n", add)
+		}
+	}
+	for _, add := range appendix {
+		fmt.Fprintf(&b, "\n%s\n", add)
+	}
+	return strings.TrimRight(b.String(), "\n") + "\n", nil
+}
+
+// missingAliases returns the alias directives of the journal not present in the given lines.
+func (j Journal) missingAliases(lines []string) []string {
+	existing := make(map[string]bool)
+	for i := range lines {
+		existing[strings.Join(strings.Fields(lines[i]), " ")] = true
+	}
+	var rsl []string
+	for i := range j.Aliases {
+		alias := fmt.Sprintf("alias %s = %s", j.Aliases[i][0], j.Aliases[i][1])
+		if !existing[strings.Join(strings.Fields(alias), " ")] {
+			rsl = append(rsl, alias)
+		}
+	}
+	return rsl
+}
@@ -0,0 +1,75 @@
+package ledger
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+const existingJournal = `include prices.journal
+
+2022-01-10 (e-1) Old description
+    ; acc-id:exp-1
+    expenses:Material        CHF100
+    assets:Bank        CHF-100
+
+2022-01-15 * Hand-written
+    expenses:Kaffee    CHF5
+    assets:Kasse
+
+2022-01-20 (e-2) Deleted expense
+    ; acc-id:exp-2
+    expenses:Material        CHF20
+    assets:Bank        CHF-20
+`
+
+func TestUpdateHLedger(t *testing.T) {
+	jrn := NewJournal(nil)
+	jrn.AddEntries([]Entry{
+		{
+			ID:          "exp-1",
+			Date:        time.Date(2022, time.January, 10, 0, 0, 0, 0, time.UTC),
+			Code:        "e-1",
+			Description: "New description",
+			Account1:    "expenses:Material",
+			Account2:    "assets:Bank",
+			Amount:      util.NewMoney(10000, "CHF"),
+		},
+		{
+			ID:          "exp-3",
+			Date:        time.Date(2022, time.January, 16, 0, 0, 0, 0, time.UTC),
+			Code:        "e-3",
+			Description: "Added expense",
+			Account1:    "expenses:Material",
+			Account2:    "assets:Bank",
+			Amount:      util.NewMoney(3050, "CHF"),
+		},
+	})
+	rsl, err := jrn.UpdateHLedger(existingJournal, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"include prices.journal", "New description", "Hand-written"} {
+		if !strings.Contains(rsl, expected) {
+			t.Errorf("updated journal doesn't contain «%s»:\n%s", expected, rsl)
+		}
+	}
+	for _, unexpected := range []string{"Old description", "Deleted expense"} {
+		if strings.Contains(rsl, unexpected) {
+			t.Errorf("updated journal still contains «%s»:\n%s", unexpected, rsl)
+		}
+	}
+	if strings.Index(rsl, "Added expense") < strings.Index(rsl, "Hand-written") {
+		t.Errorf("new entry should be inserted after the hand-written one:\n%s", rsl)
+	}
+
+	again, err := jrn.UpdateHLedger(rsl, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again != rsl {
+		t.Errorf("updating twice should not alter the journal:\n%s", again)
+	}
+}
@@ -0,0 +1,99 @@
+package mail
+
+import (
+	"fmt"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// InvoiceMessage returns the mail with the invoice document for the customer. The
+// document is either the path of the invoice or the PDF generated by acc invoices in the
+// given folder.
+func InvoiceMessage(cfg schema.MailConfig, company schema.Company, customer schema.Party, invoice schema.Invoice, folder string) (Message, error) {
+	if customer.Mail == "" {
+		return Message{}, fmt.Errorf("customer %s has no email address", customer.Short())
+	}
+	pth := invoice.Path
+	if pth == "" || !util.FileExist(pth) || filepath.Ext(pth) != ".pdf" {
+		pth = filepath.Join(folder, fmt.Sprintf("%s.pdf", invoice.FileString()))
+	}
+	att, err := NewAttachment(pth)
+	if err != nil {
+		return Message{}, err
+	}
+	data := struct {
+		Company  schema.Company
+		Customer schema.Party
+		Invoice  schema.Invoice
+		Amount   string
+	}{company, customer, invoice, invoice.Amount.Display()}
+	return Message{
+		From:        cfg.Sender(company),
+		To:          (&netmail.Address{Name: customer.Name, Address: customer.Mail}).String(),
+		Bcc:         cfg.Bcc,
+		Subject:     util.ApplyTemplate("invoice mail subject", cfg.InvoiceSubject, data),
+		Body:        util.ApplyTemplate("invoice mail body", cfg.InvoiceBody, data),
+		Date:        time.Now(),
+		Attachments: []Attachment{att},
+	}, nil
+}
+
+// SendInvoices emails all invoices which weren't sent yet to their customers, records the
+// date in the invoice and removes the draft flag. If ident is set only this invoice is
+// sent, resend includes invoices which were already emailed. With a non empty emlFolder
+// the mails are saved as .eml files instead (dry run) and the invoices stay untouched.
+// Returns the number of mails.
+func SendInvoices(s *schema.Schema, ident, folder, emlFolder string, resend bool) int {
+	if emlFolder == "" {
+		if rsl := util.Check(s.MailConfig); !rsl.Valid() {
+			logrus.Fatal("mail config in acc.yaml is not complete: ", rsl)
+		}
+	} else if err := os.MkdirAll(emlFolder, os.ModePerm); err != nil {
+		logrus.Fatal("creation of mail output folder failed: ", err)
+	}
+	n := 0
+	for i := range s.Invoices {
+		inv := &s.Invoices[i]
+		if ident != "" && inv.Identifier != ident {
+			continue
+		}
+		if inv.Revoked || (inv.MailDate != "" && !resend) {
+			continue
+		}
+		customer, err := s.Parties.CustomerByRef(inv.Customer)
+		if err != nil {
+			logrus.Errorf("no customer found for %s: %s", inv.String(), err)
+			continue
+		}
+		msg, err := InvoiceMessage(s.MailConfig, s.Company, *customer, *inv, folder)
+		if err != nil {
+			logrus.Errorf("%s not sent: %s", inv.String(), err)
+			continue
+		}
+		if emlFolder != "" {
+			pth := filepath.Join(emlFolder, fmt.Sprintf("%s.eml", inv.FileString()))
+			if err := msg.SaveEml(pth); err != nil {
+				logrus.Error(err)
+				continue
+			}
+			logrus.Infof("saved mail for %s as %s", inv.String(), pth)
+			n++
+			continue
+		}
+		if err := Send(s.MailConfig, msg); err != nil {
+			logrus.Errorf("error while sending %s: %s", inv.String(), err)
+			continue
+		}
+		inv.MailDate = time.Now().Format(util.DateFormat)
+		inv.Draft = false
+		logrus.Infof("sent %s to %s", inv.String(), msg.To)
+		n++
+	}
+	return n
+}
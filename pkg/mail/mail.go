@@ -0,0 +1,186 @@
+// Package mail composes emails with attachments and sends them over SMTP. As an
+// alternative to sending, the messages can be saved as .eml files for review.
+package mail
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/smtp"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+)
+
+// implicitTLSPort is the SMTP port on which the connection is encrypted from the start.
+var implicitTLSPort = 465
+
+// rootCAs are the certificate authorities trusted for implicit TLS, nil uses the system
+// pool.
+var rootCAs *x509.CertPool
+
+// Message is an email with optional attachments.
+type Message struct {
+	From        string
+	To          string
+	Bcc         string
+	Subject     string
+	Body        string
+	Date        time.Time
+	Attachments []Attachment
+}
+
+// Attachment is a file attached to a Message.
+type Attachment struct {
+	Name    string
+	Content []byte
+}
+
+// NewAttachment reads the file at the given path as an Attachment.
+func NewAttachment(path string) (Attachment, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Attachment{}, fmt.Errorf("error reading attachment %s: %s", path, err)
+	}
+	return Attachment{Name: filepath.Base(path), Content: raw}, nil
+}
+
+// Bytes returns the message in the internet message format (RFC 5322) with a multipart
+// body. The Bcc address is omitted from the headers.
+func (m Message) Bytes() []byte {
+	var buf bytes.Buffer
+	wrt := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", encodeAddress(m.From))
+	fmt.Fprintf(&buf, "To: %s\r\n", encodeAddress(m.To))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", m.Date.Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", wrt.Boundary())
+
+	body, _ := wrt.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	qp := quotedprintable.NewWriter(body)
+	_, _ = qp.Write([]byte(m.Body))
+	_ = qp.Close()
+
+	for i := range m.Attachments {
+		att := m.Attachments[i]
+		contentType := mime.TypeByExtension(filepath.Ext(att.Name))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		part, _ := wrt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", contentType, att.Name)},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", att.Name)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		encoded := base64.StdEncoding.EncodeToString(att.Content)
+		for len(encoded) > 76 {
+			_, _ = part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		_, _ = part.Write([]byte(encoded + "\r\n"))
+	}
+	_ = wrt.Close()
+	return buf.Bytes()
+}
+
+// encodeAddress returns the address with the name MIME-encoded if it contains non-ASCII
+// characters. Addresses which can't be parsed are returned unaltered.
+func encodeAddress(raw string) string {
+	addr, err := netmail.ParseAddress(raw)
+	if err != nil {
+		return raw
+	}
+	return addr.String()
+}
+
+// SaveEml saves the message as .eml file which can be opened by most mail clients.
+func (m Message) SaveEml(path string) error {
+	if err := ioutil.WriteFile(path, m.Bytes(), 0644); err != nil {
+		return fmt.Errorf("error writing mail to %s: %s", path, err)
+	}
+	return nil
+}
+
+// recipients returns the bare addresses of all receivers of the message.
+func (m Message) recipients() ([]string, error) {
+	rsl := []string{}
+	for _, raw := range []string{m.To, m.Bcc} {
+		if raw == "" {
+			continue
+		}
+		addr, err := netmail.ParseAddress(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid recipient «%s»: %s", raw, err)
+		}
+		rsl = append(rsl, addr.Address)
+	}
+	return rsl, nil
+}
+
+// Send delivers the message over the SMTP server of the config.
+func Send(cfg schema.MailConfig, msg Message) error {
+	from, err := netmail.ParseAddress(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender «%s»: %s", msg.From, err)
+	}
+	to, err := msg.recipients()
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		auth = smtp.PlainAuth("", cfg.Username, cfg.SmtpPassword(), cfg.Host)
+	}
+	addr := fmt.Sprintf("%s:%s", cfg.Host, strconv.Itoa(cfg.Port))
+	if cfg.Port != implicitTLSPort {
+		return smtp.SendMail(addr, auth, from.Address, to, msg.Bytes())
+	}
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{ServerName: cfg.Host, RootCAs: rootCAs})
+	if err != nil {
+		return fmt.Errorf("error connecting to %s: %s", addr, err)
+	}
+	clt, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		return err
+	}
+	defer clt.Close()
+	if auth != nil {
+		if err := clt.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := clt.Mail(from.Address); err != nil {
+		return err
+	}
+	for i := range to {
+		if err := clt.Rcpt(to[i]); err != nil {
+			return err
+		}
+	}
+	wrt, err := clt.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := wrt.Write(msg.Bytes()); err != nil {
+		return err
+	}
+	if err := wrt.Close(); err != nil {
+		return err
+	}
+	return clt.Quit()
+}
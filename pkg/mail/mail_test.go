@@ -0,0 +1,215 @@
+package mail
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+// fakeServer is a minimal local SMTP server which records the envelope and the data of
+// a single mail.
+type fakeServer struct {
+	listener   net.Listener
+	recipients []string
+	auth       string
+	data       string
+	done       chan struct{}
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serveFake(lst)
+}
+
+// newFakeTLSServer returns a fake server with implicit TLS. The certificate of the
+// httptest package is used and trusted for the tests.
+func newFakeTLSServer(t *testing.T) *fakeServer {
+	https := httptest.NewTLSServer(nil)
+	defer https.Close()
+	lst, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: https.TLS.Certificates})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rootCAs = x509.NewCertPool()
+	rootCAs.AddCert(https.Certificate())
+	return serveFake(lst)
+}
+
+func serveFake(lst net.Listener) *fakeServer {
+	srv := &fakeServer{listener: lst, done: make(chan struct{})}
+	go srv.serve()
+	return srv
+}
+
+func (f *fakeServer) serve() {
+	defer close(f.done)
+	conn, err := f.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	txt := textproto.NewConn(conn)
+	_ = txt.PrintfLine("220 localhost fake SMTP")
+	for {
+		line, err := txt.ReadLine()
+		if err != nil {
+			return
+		}
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "AUTH "):
+			f.auth = line
+			_ = txt.PrintfLine("235 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			f.recipients = append(f.recipients, strings.Trim(line[len("RCPT TO:"):], "<>"))
+			_ = txt.PrintfLine("250 OK")
+		case cmd == "DATA":
+			_ = txt.PrintfLine("354 go ahead")
+			raw, _ := txt.ReadDotBytes()
+			f.data = string(raw)
+			_ = txt.PrintfLine("250 OK")
+		case cmd == "QUIT":
+			_ = txt.PrintfLine("221 bye")
+			return
+		default:
+			_ = txt.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (f *fakeServer) port() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestSend(t *testing.T) {
+	srv := newFakeServer(t)
+	defer srv.listener.Close()
+	cfg := schema.NewMailConfig()
+	cfg.Host = "127.0.0.1"
+	cfg.Port = srv.port()
+
+	msg := Message{
+		From:        "Fortuna Inc. <info@fortuna.com>",
+		To:          "Théâtre Bern <office@theater.ch>",
+		Bcc:         "archive@fortuna.com",
+		Subject:     "Rechnung i-1: Lichtdesign für Räuber",
+		Body:        "Guten Tag\n\nIm Anhang finden Sie unsere Rechnung über CHF 1'000.00.",
+		Date:        time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+		Attachments: []Attachment{{Name: "i-1.pdf", Content: []byte("%PDF-1.4")}},
+	}
+	if err := Send(cfg, msg); err != nil {
+		t.Fatal(err)
+	}
+	<-srv.done
+
+	if rcp := strings.Join(srv.recipients, ","); rcp != "office@theater.ch,archive@fortuna.com" {
+		t.Errorf("unexpected recipients %s", rcp)
+	}
+	if strings.Contains(srv.data, "archive@fortuna.com") {
+		t.Errorf("bcc address should not be part of the headers")
+	}
+	for _, expected := range []string{
+		"From: \"Fortuna Inc.\" <info@fortuna.com>",
+		"To: =?utf-8?q?Th=C3=A9=C3=A2tre_Bern?= <office@theater.ch>",
+		"Subject: =?utf-8?q?Rechnung_i-1:_Lichtdesign_f=C3=BCr_R=C3=A4uber?=",
+		"=C3=BCber CHF 1'000.00",
+		"Content-Type: application/pdf; name=\"i-1.pdf\"",
+		"JVBERi0xLjQ=",
+	} {
+		if !strings.Contains(srv.data, expected) {
+			t.Errorf("mail doesn't contain «%s»:\n%s", expected, srv.data)
+		}
+	}
+}
+
+func TestSendImplicitTLS(t *testing.T) {
+	srv := newFakeTLSServer(t)
+	defer srv.listener.Close()
+	defer func(port int) {
+		implicitTLSPort = port
+		rootCAs = nil
+	}(implicitTLSPort)
+	implicitTLSPort = srv.port()
+	cfg := schema.NewMailConfig()
+	cfg.Host = "127.0.0.1"
+	cfg.Port = srv.port()
+	cfg.Username = "fortuna"
+	cfg.Password = "secret"
+
+	msg := Message{
+		From:    "info@fortuna.com",
+		To:      "office@theater.ch",
+		Subject: "Rechnung i-1",
+		Body:    "Guten Tag",
+		Date:    time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+	}
+	if err := Send(cfg, msg); err != nil {
+		t.Fatal(err)
+	}
+	<-srv.done
+
+	if rcp := strings.Join(srv.recipients, ","); rcp != "office@theater.ch" {
+		t.Errorf("unexpected recipients %s", rcp)
+	}
+	if srv.auth != "AUTH PLAIN AGZvcnR1bmEAc2VjcmV0" {
+		t.Errorf("unexpected authentication «%s»", srv.auth)
+	}
+	if !strings.Contains(srv.data, "Subject: Rechnung i-1") {
+		t.Errorf("unexpected mail:\n%s", srv.data)
+	}
+}
+
+func TestSendInvoices(t *testing.T) {
+	folder, err := ioutil.TempDir("", "acc-mail")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(folder)
+	for _, name := range []string{"i-1.pdf", "i-2.pdf"} {
+		if err := ioutil.WriteFile(filepath.Join(folder, name), []byte("%PDF-1.4"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	srv := newFakeServer(t)
+	defer srv.listener.Close()
+	s := schema.Schema{
+		Company:    schema.Company{Name: "Fortuna Inc.", Mail: "info@fortuna.com"},
+		MailConfig: schema.NewMailConfig(),
+		Parties:    schema.PartiesCollection{Customers: []schema.Party{{Id: "cst-1", Name: "Theater Bern", Mail: "office@theater.ch"}}},
+		Invoices: schema.Invoices{
+			{Id: "inv-1", Identifier: "i-1", Amount: util.NewMoney(100000, "CHF"), Customer: schema.NewRef("cst-1"), SendDate: "2022-02-01", MailDate: "2022-02-01"},
+			{Id: "inv-2", Identifier: "i-2", Amount: util.NewMoney(50000, "CHF"), Customer: schema.NewRef("cst-1"), SendDate: "2022-02-20", Draft: true},
+		},
+	}
+	s.MailConfig.Host = "127.0.0.1"
+	s.MailConfig.Port = srv.port()
+
+	if n := SendInvoices(&s, "", folder, "", false); n != 1 {
+		t.Fatalf("expected one mail, got %d", n)
+	}
+	<-srv.done
+	if !strings.Contains(srv.data, "Subject: Rechnung i-2") {
+		t.Errorf("expected mail for i-2, got:\n%s", srv.data)
+	}
+	sent := s.Invoices[1]
+	if sent.MailDate != time.Now().Format(util.DateFormat) || sent.SendDate != "2022-02-20" || sent.Draft {
+		t.Errorf("expected mail date of today, unchanged send date and no draft, got %+v", sent)
+	}
+	if s.Invoices[0].MailDate != "2022-02-01" {
+		t.Errorf("already emailed invoice was changed: %+v", s.Invoices[0])
+	}
+}
@@ -1,12 +1,19 @@
 package query
 
 import (
+	"fmt"
+	"io"
+
 	"github.com/72nd/acc/pkg/schema"
 	"github.com/72nd/acc/pkg/util"
 	"github.com/sirupsen/logrus"
 )
 
 var AccQueryables = Queryables{
+	{
+		Name: "credit-note",
+		Type: schema.CreditNote{},
+	},
 	{
 		Name: "customer",
 		Type: schema.Party{},
@@ -19,6 +26,10 @@ var AccQueryables = Queryables{
 		Name: "expense",
 		Type: schema.Expense{},
 	},
+	{
+		Name: "fixed-asset",
+		Type: schema.FixedAsset{},
+	},
 	{
 		Name: "invoice",
 		Type: schema.Invoice{},
@@ -27,10 +38,22 @@ var AccQueryables = Queryables{
 		Name: "misc-record",
 		Type: schema.MiscRecord{},
 	},
+	{
+		Name: "offer",
+		Type: schema.Offer{},
+	},
 	{
 		Name: "project",
 		Type: schema.Project{},
 	},
+	{
+		Name: "recurring",
+		Type: schema.RecurringTemplate{},
+	},
+	{
+		Name: "salary",
+		Type: schema.Salary{},
+	},
 	{
 		Name: "transaction",
 		Type: schema.Transaction{},
@@ -39,18 +62,28 @@ var AccQueryables = Queryables{
 
 func accElementsFromQueryable(s schema.Schema, q Queryable) []Element {
 	switch q.Name {
+	case "credit-note":
+		return NewElements(s.CreditNotes)
 	case "customer":
 		return NewElements(s.Parties.Customers)
 	case "employee":
 		return NewElements(s.Parties.Employees)
 	case "expense":
 		return NewElements(s.Expenses)
+	case "fixed-asset":
+		return NewElements(s.FixedAssets)
 	case "invoice":
 		return NewElements(s.Invoices)
 	case "misc-record":
 		return NewElements(s.MiscRecords)
+	case "offer":
+		return NewElements(s.Offers)
 	case "project":
 		return NewElements(s.Projects)
+	case "recurring":
+		return NewElements(s.RecurringTemplates)
+	case "salary":
+		return NewElements(s.Salaries)
 	case "transaction":
 		return NewElements(s.Statement.Transactions)
 	default:
@@ -59,22 +92,99 @@ func accElementsFromQueryable(s schema.Schema, q Queryable) []Element {
 	return []Element{}
 }
 
-func (q Queryables) QueryAcc(s schema.Schema, termsInput, dateInput, selectInput string, mode OutputMode, render, caseSensitive, openAttachment bool) {
+// QueryAcc writes the elements of the queryables matching the given filters to w. The elements
+// are ordered by sortInput (KEY[:asc|:desc]) and at most limit elements are printed (all
+// if zero). If the aggregation is active a summary is printed instead of the elements. An
+// error is returned if the query expression (whereInput) or the options can't be parsed.
+func (q Queryables) QueryAcc(w io.Writer, s schema.Schema, whereInput, termsInput, dateInput, selectInput, sortInput string, limit int, agg Aggregation, mode OutputMode, render, caseSensitive, openAttachment bool) error {
+	var expr Expr
+	if whereInput != "" {
+		var err error
+		if expr, err = ParseQuery(whereInput, caseSensitive); err != nil {
+			return err
+		}
+		if err := q.ValidateQuery(expr); err != nil {
+			return err
+		}
+	}
+	if err := q.ValidateKeys(agg.Keys()); err != nil {
+		return err
+	}
+	var sortKey string
+	var desc bool
+	if sortInput != "" {
+		var err error
+		if sortKey, desc, err = sortTermFromUserInput(sortInput); err != nil {
+			return err
+		}
+		if !agg.Active() {
+			if err := q.ValidateKeys([]string{sortKey}); err != nil {
+				return err
+			}
+		}
+	}
+	if limit < 0 {
+		return fmt.Errorf("limit has to be positive, got %d", limit)
+	}
+	var terms SearchTerms
+	if termsInput != "" {
+		var err error
+		if terms, err = searchTermsFromUserInput(termsInput, caseSensitive); err != nil {
+			return err
+		}
+	}
+	var ranges DateTerms
+	if dateInput != "" {
+		var err error
+		if ranges, err = dateTermsFromUserInput(dateInput); err != nil {
+			return err
+		}
+	}
+
 	var ele ElementGroup
 	for i := range q {
-		ele = append(ele, accElementsFromQueryable(s, q[i])...)
+		grp := ElementGroup(accElementsFromQueryable(s, q[i]))
+		if expr != nil {
+			grp = grp.Where(expr, q[i].Name)
+		}
+		ele = append(ele, grp...)
 	}
-	if termsInput != "" {
-		terms := searchTermsFromUserInput(termsInput, caseSensitive)
+	if terms != nil {
 		ele = ele.MatchTerm(terms, caseSensitive)
 	}
-	if dateInput != "" {
-		ranges := dateTermsFromUserInput(dateInput)
+	if ranges != nil {
 		ele = ele.DateMatch(ranges)
 	}
+
+	if agg.Active() {
+		rows := agg.Aggregate(s, ele, render)
+		if sortKey != "" {
+			if err := rows.Sort(agg, sortKey, desc); err != nil {
+				return err
+			}
+		}
+		if limit > 0 && limit < len(rows) {
+			rows = rows[:limit]
+		}
+		return rows.Write(w, agg, mode)
+	}
+
+	if sortKey != "" {
+		ele.Sort(sortKey, desc)
+	}
+	ele = ele.Limit(limit)
 	if selectInput != "" {
 		sel := util.EscapedSplit(selectInput, ",")
 		ele = ele.Select(sel, caseSensitive)
 	}
-	OutputsFromElements(s, ele).PPKeyValue(s, mode, render, openAttachment)
+	out := OutputsFromElements(s, ele)
+	if err := out.Write(w, s, mode, render); err != nil {
+		return err
+	}
+	if openAttachment {
+		for i := range out {
+			out[i].OpenAttachment()
+		}
+	}
+	return nil
 }
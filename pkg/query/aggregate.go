@@ -0,0 +1,288 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+	"github.com/olekukonko/tablewriter"
+	"gopkg.in/yaml.v3"
+)
+
+// countKey is the column name of the number of elements in an aggregation. It can be
+// used as sort key.
+const countKey = "Count"
+
+// noGroup is displayed for elements without a value for the group-by key.
+const noGroup = "(none)"
+
+// Aggregation summarizes the queried elements instead of printing them one by one.
+type Aggregation struct {
+	// GroupBy is the key by which the elements are grouped, all elements form one group
+	// if empty.
+	GroupBy string
+	// Sum contains the keys whose values are summed up per group.
+	Sum []string
+	// Count adds the number of elements per group.
+	Count bool
+}
+
+// Active returns whether any aggregation was requested.
+func (a Aggregation) Active() bool {
+	return a.GroupBy != "" || len(a.Sum) != 0 || a.Count
+}
+
+// Keys returns the element keys used by the aggregation.
+func (a Aggregation) Keys() []string {
+	if a.GroupBy == "" {
+		return a.Sum
+	}
+	return append([]string{a.GroupBy}, a.Sum...)
+}
+
+// Aggregate groups the elements and calculates the sums and counts of each group. The
+// rows are ordered by the group value.
+func (a Aggregation) Aggregate(s schema.Schema, ele ElementGroup, render bool) AggregateRows {
+	var rsl AggregateRows
+	index := make(map[string]int)
+	for i := range ele {
+		group := "Total"
+		display := group
+		if a.GroupBy != "" {
+			kv, ok := ele[i].KeyValue(a.GroupBy)
+			group, display = noGroup, noGroup
+			if ok && !kv.IsEmpty() {
+				group, display = kv.Value, kv.Value
+				if render {
+					display = kv.RenderValue(s)
+				}
+			}
+		}
+		j, ok := index[group]
+		if !ok {
+			j = len(rsl)
+			index[group] = j
+			row := AggregateRow{Group: display, Sums: make([]Sum, len(a.Sum))}
+			for k := range a.Sum {
+				row.Sums[k] = Sum{Key: a.Sum[k], Money: make(map[string]int64)}
+			}
+			rsl = append(rsl, row)
+		}
+		rsl[j].Count++
+		for k := range a.Sum {
+			if kv, ok := ele[i].KeyValue(a.Sum[k]); ok {
+				rsl[j].Sums[k].Add(kv)
+			}
+		}
+	}
+	sort.SliceStable(rsl, func(i, j int) bool {
+		return strings.ToLower(rsl[i].Group) < strings.ToLower(rsl[j].Group)
+	})
+	return rsl
+}
+
+// Header returns the column names of the aggregation output.
+func (a Aggregation) Header() []string {
+	rsl := []string{}
+	if a.GroupBy != "" {
+		rsl = append(rsl, a.GroupBy)
+	}
+	if a.Count || len(a.Sum) == 0 {
+		rsl = append(rsl, countKey)
+	}
+	for i := range a.Sum {
+		rsl = append(rsl, fmt.Sprintf("Sum %s", a.Sum[i]))
+	}
+	return rsl
+}
+
+// AggregateRows is the result of an aggregation.
+type AggregateRows []AggregateRow
+
+// AggregateRow contains the aggregated values of one group.
+type AggregateRow struct {
+	Group string
+	Count int
+	Sums  []Sum
+}
+
+// values returns the cells of the row in the order of Aggregation.Header. With export
+// set the sums are formatted for CSV, JSON and Markdown.
+func (r AggregateRow) values(a Aggregation, export bool) []string {
+	rsl := []string{}
+	if a.GroupBy != "" {
+		rsl = append(rsl, r.Group)
+	}
+	if a.Count || len(a.Sum) == 0 {
+		rsl = append(rsl, strconv.Itoa(r.Count))
+	}
+	for i := range r.Sums {
+		if export {
+			rsl = append(rsl, r.Sums[i].exportString())
+		} else {
+			rsl = append(rsl, r.Sums[i].String())
+		}
+	}
+	return rsl
+}
+
+// Sort orders the rows by the group key, the count or a summed key.
+func (r AggregateRows) Sort(a Aggregation, key string, desc bool) error {
+	var less func(i, j int) bool
+	switch {
+	case strings.EqualFold(key, a.GroupBy):
+		less = func(i, j int) bool { return strings.ToLower(r[i].Group) < strings.ToLower(r[j].Group) }
+	case strings.EqualFold(key, countKey):
+		less = func(i, j int) bool { return r[i].Count < r[j].Count }
+	default:
+		k := -1
+		for i := range a.Sum {
+			if strings.EqualFold(key, a.Sum[i]) {
+				k = i
+			}
+		}
+		if k == -1 {
+			return fmt.Errorf("can't sort aggregation by «%s», use the group-by key, count or a summed key", key)
+		}
+		if codes := r.currencies(k); len(codes) > 1 {
+			return fmt.Errorf("can't sort aggregation by «%s» as the sums are in different currencies (%s)", key, strings.Join(codes, ", "))
+		}
+		less = func(i, j int) bool { return r[i].Sums[k].Total() < r[j].Sums[k].Total() }
+	}
+	sort.SliceStable(r, func(i, j int) bool {
+		if desc {
+			return less(j, i)
+		}
+		return less(i, j)
+	})
+	return nil
+}
+
+// currencies returns the currency codes of the k-th sum of all rows.
+func (r AggregateRows) currencies(k int) []string {
+	seen := make(map[string]bool)
+	var rsl []string
+	for i := range r {
+		for code := range r[i].Sums[k].Money {
+			if !seen[code] {
+				seen[code] = true
+				rsl = append(rsl, code)
+			}
+		}
+	}
+	sort.Strings(rsl)
+	return rsl
+}
+
+// Write writes the rows in the given mode to w.
+func (r AggregateRows) Write(w io.Writer, a Aggregation, mode OutputMode) error {
+	header := a.Header()
+	rows := make([][]string, len(r))
+	for i := range r {
+		rows[i] = r[i].values(a, mode != YamlMode && mode != TableMode)
+	}
+	switch mode {
+	case YamlMode:
+		data := make([]map[string]string, len(rows))
+		for i := range rows {
+			data[i] = make(map[string]string)
+			for j := range header {
+				data[i][header[j]] = rows[i][j]
+			}
+		}
+		yml, err := yaml.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("error while marshaling \"%+v\": %s", data, err)
+		}
+		_, err = fmt.Fprint(w, string(yml))
+		return err
+	case TableMode:
+		tblStr := &bytes.Buffer{}
+		tbl := tablewriter.NewWriter(tblStr)
+		tbl.SetHeader(header)
+		tbl.SetAutoWrapText(false)
+		tbl.AppendBulk(rows)
+		tbl.Render()
+		_, err := fmt.Fprint(w, tblStr.String())
+		return err
+	case JsonMode:
+		data := make([]jsonObject, len(rows))
+		for i := range rows {
+			data[i] = make(jsonObject, len(header))
+			for j := range header {
+				data[i][j] = jsonField{Key: header[j], Value: rows[i][j]}
+			}
+		}
+		return writeJson(w, data)
+	case CsvMode, MarkdownMode:
+		return writeRows(w, mode, header, rows)
+	}
+	return fmt.Errorf("illegal output mode \"%d\"", mode)
+}
+
+// Sum adds up the values of a key. Amounts are summed up per currency, other numeric
+// values as numbers. Values which are neither are ignored.
+type Sum struct {
+	Key string
+	// Money contains the sum in cents per currency code.
+	Money map[string]int64
+	// Number is the sum of all numeric values which aren't amounts.
+	Number    float64
+	hasNumber bool
+}
+
+// Add adds the value of the field to the sum.
+func (s *Sum) Add(kv KeyValue) {
+	if mny, ok := kv.Raw.(util.Money); ok {
+		if mny.Money != nil {
+			s.Money[mny.Currency().Code] += mny.Amount()
+		}
+		return
+	}
+	if value, err := strconv.ParseFloat(kv.Value, 64); err == nil {
+		s.Number += value
+		s.hasNumber = true
+	}
+}
+
+// Total returns a single value used for sorting. Only meaningful if all amounts are in the
+// same currency, AggregateRows.Sort rejects sums with different currencies.
+func (s Sum) Total() float64 {
+	rsl := s.Number
+	for _, cents := range s.Money {
+		rsl += float64(cents) / 100
+	}
+	return rsl
+}
+
+// String returns the sums per currency and the sum of the numbers.
+func (s Sum) String() string {
+	return s.format(func(mny util.Money) string { return mny.Display() })
+}
+
+// exportString returns the sums with amounts without thousands separators (see
+// KeyValue.exportValue).
+func (s Sum) exportString() string {
+	return s.format(util.Money.Value)
+}
+
+func (s Sum) format(money func(util.Money) string) string {
+	codes := make([]string, 0, len(s.Money))
+	for code := range s.Money {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	var rsl []string
+	for i := range codes {
+		rsl = append(rsl, money(util.NewMoney(s.Money[codes[i]], codes[i])))
+	}
+	if s.hasNumber || len(rsl) == 0 {
+		rsl = append(rsl, strconv.FormatFloat(s.Number, 'f', -1, 64))
+	}
+	return strings.Join(rsl, ", ")
+}
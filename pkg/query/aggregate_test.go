@@ -0,0 +1,59 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestAggregate(t *testing.T) {
+	expense := func(ident, category string, amount int64, currency string) schema.Expense {
+		exp := schema.NewExpenseWithUuid()
+		exp.Identifier = ident
+		exp.ExpenseCategory = category
+		exp.Amount = util.NewMoney(amount, currency)
+		return exp
+	}
+	ele := NewElements([]schema.Expense{
+		expense("e-1", "Rent", 120000, "CHF"),
+		expense("e-2", "IT", 5000, "CHF"),
+		expense("e-3", "IT", 2000, "EUR"),
+		expense("e-4", "IT", 15000, "CHF"),
+	})
+
+	agg := Aggregation{GroupBy: "expenseCategory", Sum: []string{"amount"}, Count: true}
+	rows := agg.Aggregate(schema.Schema{}, ele, false)
+	if len(rows) != 2 || rows[0].Group != "IT" || rows[0].Count != 3 {
+		t.Fatalf("unexpected groups %+v", rows)
+	}
+	if sum := rows[0].Sums[0].String(); sum != util.NewMoney(20000, "CHF").Display()+", "+util.NewMoney(2000, "EUR").Display() {
+		t.Errorf("unexpected sum %s", sum)
+	}
+	if err := rows.Sort(agg, "amount", true); err == nil {
+		t.Error("expected error when sorting sums in different currencies")
+	}
+	chf := agg.Aggregate(schema.Schema{}, ElementGroup{ele[0], ele[1], ele[3]}, false)
+	if err := chf.Sort(agg, "amount", true); err != nil {
+		t.Fatal(err)
+	}
+	if chf[0].Group != "Rent" || chf[1].Group != "IT" {
+		t.Errorf("expected Rent as group with the highest sum, got %+v", chf)
+	}
+	if err := rows.Sort(agg, "count", true); err != nil || rows[0].Group != "IT" {
+		t.Errorf("expected IT as group with the most elements, got %+v (%v)", rows, err)
+	}
+
+	ele.Sort("amount", true)
+	order := ""
+	for i := range ele {
+		kv, _ := ele[i].KeyValue("identifier")
+		order += kv.Value + " "
+	}
+	if order != "e-3 e-1 e-4 e-2 " {
+		t.Errorf("expected amounts ordered by currency and value, got %s", order)
+	}
+	if len(ele.Limit(2)) != 2 || len(ele.Limit(0)) != 4 {
+		t.Errorf("limit doesn't work")
+	}
+}
@@ -0,0 +1,518 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// The query language combines comparisons of element keys with AND, OR, NOT and
+// parentheses. Example:
+//
+//     type:expense AND amount>500 CHF AND NOT settlementTransaction:empty
+//
+// Comparisons have the form KEY OPERATOR VALUE. The key is the name of a field
+// (case-insensitive) or `type` for the element type. Operators:
+//
+//     :          value is a regular expression matched against the field, `empty`
+//                matches empty fields (ex: unset references)
+//     = !=       equality, numeric for amounts and numbers
+//     < <= > >=  numeric or date (YYYY-MM-DD) comparison
+//
+// With all operators the value can be followed by a currency code (ex: 500 CHF), the
+// field then only matches if it's an amount in this currency.
+//
+// Values containing spaces or parentheses can be quoted with double quotes.
+
+// emptyValue is the unquoted value which matches empty fields.
+const emptyValue = "empty"
+
+// typeKey is the pseudo key for the type of the element.
+const typeKey = "type"
+
+// ParseError describes an invalid query expression.
+type ParseError struct {
+	Query    string
+	Position int
+	Message  string
+}
+
+func (p ParseError) Error() string {
+	return fmt.Sprintf("invalid query «%s» at position %d: %s", p.Query, p.Position+1, p.Message)
+}
+
+// Expr is a node of a parsed query expression.
+type Expr interface {
+	// Eval returns whether the element of the given type matches the expression.
+	Eval(ele Element, typ string) bool
+	// Keys returns the keys used by the comparisons in the expression.
+	Keys() []string
+	String() string
+}
+
+type andExpr struct {
+	Left  Expr
+	Right Expr
+}
+
+func (a andExpr) Eval(ele Element, typ string) bool {
+	return a.Left.Eval(ele, typ) && a.Right.Eval(ele, typ)
+}
+
+func (a andExpr) Keys() []string {
+	return append(a.Left.Keys(), a.Right.Keys()...)
+}
+
+func (a andExpr) String() string {
+	return fmt.Sprintf("(%s AND %s)", a.Left, a.Right)
+}
+
+type orExpr struct {
+	Left  Expr
+	Right Expr
+}
+
+func (o orExpr) Eval(ele Element, typ string) bool {
+	return o.Left.Eval(ele, typ) || o.Right.Eval(ele, typ)
+}
+
+func (o orExpr) Keys() []string {
+	return append(o.Left.Keys(), o.Right.Keys()...)
+}
+
+func (o orExpr) String() string {
+	return fmt.Sprintf("(%s OR %s)", o.Left, o.Right)
+}
+
+type notExpr struct {
+	Expr Expr
+}
+
+func (n notExpr) Eval(ele Element, typ string) bool {
+	return !n.Expr.Eval(ele, typ)
+}
+
+func (n notExpr) Keys() []string {
+	return n.Expr.Keys()
+}
+
+func (n notExpr) String() string {
+	return fmt.Sprintf("NOT %s", n.Expr)
+}
+
+// Operator of a comparison.
+type Operator string
+
+const (
+	MatchOperator        Operator = ":"
+	EqualOperator        Operator = "="
+	NotEqualOperator     Operator = "!="
+	LessOperator         Operator = "<"
+	LessEqualOperator    Operator = "<="
+	GreaterOperator      Operator = ">"
+	GreaterEqualOperator Operator = ">="
+)
+
+// ordering returns whether the operator compares the order of two values.
+func (o Operator) ordering() bool {
+	return o == LessOperator || o == LessEqualOperator || o == GreaterOperator || o == GreaterEqualOperator
+}
+
+type compareExpr struct {
+	Key      string
+	Operator Operator
+	Value    string
+	// Currency is the optional currency code following an amount.
+	Currency string
+	// Empty is set for the unquoted value `empty`.
+	Empty bool
+	regex *regexp.Regexp
+}
+
+func (c compareExpr) Eval(ele Element, typ string) bool {
+	if strings.EqualFold(c.Key, typeKey) {
+		match := Queryable{Name: typ}.matchTypeFromUserInput(c.Value)
+		return match == (c.Operator != NotEqualOperator)
+	}
+	kv, ok := ele.KeyValue(c.Key)
+	if !ok {
+		return false
+	}
+	switch c.Operator {
+	case MatchOperator:
+		if c.Empty {
+			return kv.IsEmpty()
+		}
+		if c.Currency != "" && !kv.hasCurrency(c.Currency) {
+			return false
+		}
+		return c.regex.MatchString(kv.Value)
+	case EqualOperator, NotEqualOperator:
+		equal := false
+		if c.Empty {
+			equal = kv.IsEmpty()
+		} else if cmp, ok := kv.Compare(c.Value, c.Currency); ok {
+			equal = cmp == 0
+		} else if c.Currency == "" {
+			equal = strings.EqualFold(kv.Value, c.Value)
+		}
+		return equal == (c.Operator == EqualOperator)
+	}
+	cmp, ok := kv.Compare(c.Value, c.Currency)
+	if !ok {
+		return false
+	}
+	switch c.Operator {
+	case LessOperator:
+		return cmp < 0
+	case LessEqualOperator:
+		return cmp <= 0
+	case GreaterOperator:
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func (c compareExpr) Keys() []string {
+	return []string{c.Key}
+}
+
+func (c compareExpr) String() string {
+	value := strconv.Quote(c.Value)
+	if c.Empty {
+		value = emptyValue
+	}
+	if c.Currency != "" {
+		value = fmt.Sprintf("%s %s", value, c.Currency)
+	}
+	return fmt.Sprintf("%s%s%s", c.Key, c.Operator, value)
+}
+
+type tokenKind int
+
+const (
+	wordToken tokenKind = iota
+	quotedToken
+	operatorToken
+	openToken
+	closeToken
+	endToken
+)
+
+type token struct {
+	Kind     tokenKind
+	Text     string
+	Position int
+}
+
+// keyword returns the uppercase keyword (AND, OR, NOT) if the token is one.
+func (t token) keyword() string {
+	if t.Kind != wordToken {
+		return ""
+	}
+	kw := strings.ToUpper(t.Text)
+	if kw == "AND" || kw == "OR" || kw == "NOT" {
+		return kw
+	}
+	return ""
+}
+
+func (t token) describe() string {
+	switch t.Kind {
+	case endToken:
+		return "end of query"
+	case quotedToken:
+		return strconv.Quote(t.Text)
+	default:
+		return fmt.Sprintf("«%s»", t.Text)
+	}
+}
+
+// isOperatorRune returns whether the rune can be part of an operator.
+func isOperatorRune(r rune) bool {
+	return r == ':' || r == '=' || r == '!' || r == '<' || r == '>'
+}
+
+// tokenize splits the query into tokens. After an operator everything up to the next
+// whitespace or closing parenthesis is read as a value, thus values can contain colons.
+func tokenize(query string) ([]token, error) {
+	var rsl []token
+	rns := []rune(query)
+	afterOperator := false
+	for i := 0; i < len(rns); {
+		r := rns[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '(' && !afterOperator:
+			rsl = append(rsl, token{Kind: openToken, Text: "(", Position: i})
+			i++
+		case r == ')':
+			rsl = append(rsl, token{Kind: closeToken, Text: ")", Position: i})
+			i++
+		case r == '"':
+			start := i
+			var txt strings.Builder
+			i++
+			for ; i < len(rns) && rns[i] != '"'; i++ {
+				if rns[i] == '\\' && i+1 < len(rns) {
+					i++
+				}
+				txt.WriteRune(rns[i])
+			}
+			if i >= len(rns) {
+				return nil, ParseError{Query: query, Position: start, Message: "missing closing quote"}
+			}
+			i++
+			rsl = append(rsl, token{Kind: quotedToken, Text: txt.String(), Position: start})
+		case isOperatorRune(r) && !afterOperator:
+			start := i
+			for i < len(rns) && isOperatorRune(rns[i]) && i-start < 2 {
+				i++
+			}
+			rsl = append(rsl, token{Kind: operatorToken, Text: string(rns[start:i]), Position: start})
+			afterOperator = true
+			continue
+		default:
+			start := i
+			for i < len(rns) && !unicode.IsSpace(rns[i]) && rns[i] != '(' && rns[i] != ')' &&
+				(afterOperator || !isOperatorRune(rns[i])) {
+				i++
+			}
+			if afterOperator {
+				for i < len(rns) && rns[i] == '(' {
+					i = skipGroup(rns, i)
+				}
+			}
+			rsl = append(rsl, token{Kind: wordToken, Text: string(rns[start:i]), Position: start})
+		}
+		afterOperator = false
+	}
+	rsl = append(rsl, token{Kind: endToken, Position: len(rns)})
+	return rsl, nil
+}
+
+// skipGroup returns the index after the parenthesised group (and the following
+// characters of the value) starting at i. This allows unquoted regex values like a(b|c).
+func skipGroup(rns []rune, i int) int {
+	depth := 0
+	for ; i < len(rns); i++ {
+		if rns[i] == '(' {
+			depth++
+		} else if rns[i] == ')' {
+			depth--
+			if depth == 0 {
+				i++
+				break
+			}
+		}
+	}
+	for i < len(rns) && !unicode.IsSpace(rns[i]) && rns[i] != '(' && rns[i] != ')' {
+		i++
+	}
+	return i
+}
+
+type parser struct {
+	query         string
+	tokens        []token
+	pos           int
+	caseSensitive bool
+}
+
+// ParseQuery parses the query expression into an Expr. Regular expressions are case
+// insensitive unless caseSensitive is set.
+func ParseQuery(query string, caseSensitive bool) (Expr, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{query: query, tokens: tokens, caseSensitive: caseSensitive}
+	if p.peek().Kind == endToken {
+		return nil, p.errorf(p.peek(), "query is empty")
+	}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tkn := p.peek(); tkn.Kind != endToken {
+		return nil, p.errorf(tkn, "expected AND, OR or end of query but got %s", tkn.describe())
+	}
+	return expr, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tkn := p.tokens[p.pos]
+	if tkn.Kind != endToken {
+		p.pos++
+	}
+	return tkn
+}
+
+func (p *parser) errorf(tkn token, format string, a ...interface{}) ParseError {
+	return ParseError{Query: p.query, Position: tkn.Position, Message: fmt.Sprintf(format, a...)}
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword() == "OR" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().keyword() == "AND" {
+		p.next()
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = andExpr{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if p.peek().keyword() == "NOT" {
+		p.next()
+		expr, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr{Expr: expr}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tkn := p.next()
+	switch {
+	case tkn.Kind == openToken:
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if cls := p.next(); cls.Kind != closeToken {
+			return nil, p.errorf(cls, "expected «)» but got %s", cls.describe())
+		}
+		return expr, nil
+	case tkn.Kind == wordToken && tkn.keyword() == "":
+		return p.parseComparison(tkn)
+	}
+	return nil, p.errorf(tkn, "expected comparison (KEY:VALUE) or «(» but got %s", tkn.describe())
+}
+
+func (p *parser) parseComparison(key token) (Expr, error) {
+	opTkn := p.next()
+	if opTkn.Kind != operatorToken {
+		return nil, p.errorf(opTkn, "expected operator (: = != < <= > >=) after key «%s» but got %s", key.Text, opTkn.describe())
+	}
+	op := Operator(opTkn.Text)
+	switch op {
+	case MatchOperator, EqualOperator, NotEqualOperator, LessOperator, LessEqualOperator, GreaterOperator, GreaterEqualOperator:
+	default:
+		return nil, p.errorf(opTkn, "unknown operator «%s», use one of : = != < <= > >=", opTkn.Text)
+	}
+	valTkn := p.next()
+	if valTkn.Kind != wordToken && valTkn.Kind != quotedToken {
+		return nil, p.errorf(valTkn, "expected value after «%s%s» but got %s", key.Text, op, valTkn.describe())
+	}
+	cmp := compareExpr{
+		Key:      key.Text,
+		Operator: op,
+		Value:    valTkn.Text,
+		Empty:    valTkn.Kind == wordToken && strings.EqualFold(valTkn.Text, emptyValue),
+	}
+
+	if strings.EqualFold(cmp.Key, typeKey) {
+		if op != MatchOperator && op != EqualOperator && op != NotEqualOperator {
+			return nil, p.errorf(opTkn, "the type can only be compared with : = or !=")
+		}
+		return cmp, nil
+	}
+	if op.ordering() && cmp.Empty {
+		return nil, p.errorf(valTkn, "«empty» can only be used with : = or !=")
+	}
+	if cur := p.peek(); !cmp.Empty && cur.Kind == wordToken && cur.keyword() == "" && isCurrencyCode(cur.Text) {
+		cmp.Currency = p.next().Text
+	}
+	if op == MatchOperator && !cmp.Empty {
+		expr := cmp.Value
+		if !p.caseSensitive {
+			expr = "(?i)" + expr
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, p.errorf(valTkn, "«%s» is not a valid regular expression: %s", cmp.Value, err)
+		}
+		cmp.regex = re
+	}
+	return cmp, nil
+}
+
+// isCurrencyCode returns whether the text looks like an ISO 4217 currency code.
+func isCurrencyCode(text string) bool {
+	if len(text) != 3 {
+		return false
+	}
+	for _, r := range text {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseCents parses a decimal number (ex: 500, 12.5, 1000.00) as amount in cents.
+func parseCents(value string) (int64, error) {
+	value = strings.ReplaceAll(value, "'", "")
+	parts := strings.Split(value, ".")
+	if len(parts) > 2 || len(parts) == 2 && len(parts[1]) > 2 {
+		return 0, fmt.Errorf("«%s» is not an amount", value)
+	}
+	units, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("«%s» is not an amount", value)
+	}
+	var cents int64
+	if len(parts) == 2 {
+		frac := parts[1]
+		if len(frac) == 1 {
+			frac += "0"
+		}
+		if cents, err = strconv.ParseInt(frac, 10, 64); err != nil {
+			return 0, fmt.Errorf("«%s» is not an amount", value)
+		}
+	}
+	if strings.HasPrefix(parts[0], "-") {
+		return units*100 - cents, nil
+	}
+	return units*100 + cents, nil
+}
+
+// ValidateQuery returns an error if the expression uses a key which doesn't exist in
+// any of the queryables.
+func (q Queryables) ValidateQuery(expr Expr) error {
+	return q.ValidateKeys(expr.Keys())
+}
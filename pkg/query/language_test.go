@@ -0,0 +1,69 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestParseQuery(t *testing.T) {
+	expr, err := ParseQuery("type:expense AND amount>500 CHF AND NOT settlementTransaction:empty", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `((type:"expense" AND amount>"500" CHF) AND NOT settlementTransaction:empty)`
+	if expr.String() != expected {
+		t.Errorf("expected %s, got %s", expected, expr.String())
+	}
+
+	for _, invalid := range []string{"", "amount>", "(type:expense", "name:\"open", "amount>empty", "amount=empty CHF", "type>expense", "AND name:x", "name:("} {
+		if _, err := ParseQuery(invalid, false); err == nil {
+			t.Errorf("expected parse error for «%s»", invalid)
+		} else if _, ok := err.(ParseError); !ok {
+			t.Errorf("expected ParseError for «%s», got %T", invalid, err)
+		}
+	}
+}
+
+func TestQueryEval(t *testing.T) {
+	exp := schema.NewExpenseWithUuid()
+	exp.Identifier = "e-1"
+	exp.Name = "Rent February"
+	exp.Amount = util.NewMoney(120000, "CHF")
+	exp.DateOfAccrual = "2022-02-01"
+	exp.SettlementTransaction = schema.NewRef("")
+	ele := NewElements([]schema.Expense{exp})[0]
+
+	cases := map[string]bool{
+		"type:expense AND amount>500 CHF": true,
+		"type:expenses":                   true,
+		"type:invoice":                    false,
+		"amount>500 EUR":                  false,
+		"amount<=1200":                    true,
+		"amount=1200.00":                  true,
+		"amount!=1200":                    false,
+		"amount=1200 CHF":                 true,
+		"amount=1200 EUR":                 false,
+		"amount!=1200 CHF":                false,
+		"amount!=1200 EUR":                true,
+		"amount!=500 CHF":                 true,
+		"amount:^1.200 CHF":               true,
+		"amount:^1.200 EUR":               false,
+		"name:rent CHF":                   false,
+		"settlementTransaction:empty":     true,
+		"NOT settlementTransaction:empty": false,
+		"name:rent AND (dateOfAccrual<2022-01-01 OR identifier=E-1)": true,
+		"name:\"^rent feb\"": true,
+		"dateOfAccrual>=2022-02-01 AND dateOfAccrual<2022-03-01": true,
+	}
+	for query, expected := range cases {
+		expr, err := ParseQuery(query, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rsl := expr.Eval(ele, "expense"); rsl != expected {
+			t.Errorf("«%s» should evaluate to %t", query, expected)
+		}
+	}
+}
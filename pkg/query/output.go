@@ -2,7 +2,13 @@ package query
 
 import (
 	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
 
 	"github.com/72nd/acc/pkg/schema"
 	"github.com/72nd/acc/pkg/util"
@@ -16,8 +22,28 @@ type OutputMode int
 const (
 	YamlMode OutputMode = iota
 	TableMode
+	CsvMode
+	JsonMode
+	MarkdownMode
 )
 
+// OutputModeFromUserInput returns the OutputMode for the given format name.
+func OutputModeFromUserInput(input string) (OutputMode, error) {
+	switch strings.ToLower(input) {
+	case "yaml", "yml":
+		return YamlMode, nil
+	case "table":
+		return TableMode, nil
+	case "csv":
+		return CsvMode, nil
+	case "json":
+		return JsonMode, nil
+	case "markdown", "md":
+		return MarkdownMode, nil
+	}
+	return TableMode, fmt.Errorf("unknown output format «%s», use one of table, yaml, csv, json or markdown", input)
+}
+
 type Outputs []Output
 
 func OutputsFromElements(s schema.Schema, ele []Element) Outputs {
@@ -28,13 +54,49 @@ func OutputsFromElements(s schema.Schema, ele []Element) Outputs {
 	return rsl
 }
 
-func (o Outputs) PPKeyValue(s schema.Schema, mode OutputMode, render, openAttachment bool) {
+// Write writes the outputs in the given mode to w. YAML and table print each element on
+// its own, CSV and Markdown print one table with a row per element and a column per key,
+// JSON prints an array of objects. In the JSON output references are resolved as nested
+// objects if render is set.
+func (o Outputs) Write(w io.Writer, s schema.Schema, mode OutputMode, render bool) error {
+	switch mode {
+	case YamlMode, TableMode:
+		for i := range o {
+			if _, err := fmt.Fprint(w, o[i].keyValue(&s, mode, render)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case JsonMode:
+		data := make([]jsonObject, len(o))
+		for i := range o {
+			data[i] = o[i].jsonObject(s, render)
+		}
+		return writeJson(w, data)
+	case CsvMode, MarkdownMode:
+		header := o.header()
+		rows := make([][]string, len(o))
+		for i := range o {
+			rows[i] = o[i].row(header, s, render)
+		}
+		return writeRows(w, mode, header, rows)
+	}
+	return fmt.Errorf("illegal output mode \"%d\"", mode)
+}
+
+// header returns all keys of the elements in the order of their first occurrence.
+func (o Outputs) header() []string {
+	var rsl []string
+	known := make(map[string]bool)
 	for i := range o {
-		o[i].PPKeyValue(&s, mode, render)
-		if openAttachment {
-			o[i].OpenAttachment()
+		for j := range o[i].Element {
+			if key := o[i].Element[j].Key; !known[key] {
+				known[key] = true
+				rsl = append(rsl, key)
+			}
 		}
 	}
+	return rsl
 }
 
 type Output struct {
@@ -51,15 +113,20 @@ func NewOutput(s schema.Schema, ele Element) Output {
 
 func (o Output) PPKeyValue(a *schema.Schema, mode OutputMode, render bool) {
 	switch mode {
-	case YamlMode:
-		fmt.Print(o.yamlKeyValue(a, render))
-	case TableMode:
-		fmt.Print(o.tableKeyValue(a, render))
+	case YamlMode, TableMode:
+		fmt.Print(o.keyValue(a, mode, render))
 	default:
 		logrus.Fatalf("illegal output mode \"%d\"", mode)
 	}
 }
 
+func (o Output) keyValue(a *schema.Schema, mode OutputMode, render bool) string {
+	if mode == YamlMode {
+		return o.yamlKeyValue(a, render)
+	}
+	return o.tableKeyValue(a, render)
+}
+
 func (o Output) OpenAttachment() {
 	for i := range o.Element {
 		if o.Element[i].Field.Tag.Get("query") == "path" {
@@ -85,10 +152,15 @@ func (o Output) yamlKeyValue(a *schema.Schema, render bool) string {
 	return string(yml)
 }
 
+// termWidthWarning reports the fallback terminal width only once per run.
+var termWidthWarning sync.Once
+
 func (o Output) tableKeyValue(a *schema.Schema, render bool) string {
 	termWidth, err := util.TerminalWidth()
-	if err != nil && render {
-		logrus.Warnf("%s using 80 as default instead", err)
+	if err != nil {
+		termWidthWarning.Do(func() {
+			logrus.Warnf("%s using 80 as default instead", err)
+		})
 		termWidth = 80
 	}
 	valueWidth := termWidth - o.Element.MaxKeyLength() - 7
@@ -103,7 +175,7 @@ func (o Output) tableKeyValue(a *schema.Schema, render bool) string {
 	tbl.SetAutoWrapText(false)
 	for i := range o.Element {
 		value := o.Element[i].Value
-		if a != nil {
+		if a != nil && render {
 			value = o.Element[i].RenderValue(*a)
 		}
 		ele := []string{o.Element[i].Key, multiline(value, valueWidth)}
@@ -114,6 +186,125 @@ func (o Output) tableKeyValue(a *schema.Schema, render bool) string {
 
 }
 
+// row returns the values of the element in the order of the header, missing keys are
+// left empty.
+func (o Output) row(header []string, s schema.Schema, render bool) []string {
+	rsl := make([]string, len(header))
+	for i := range header {
+		for j := range o.Element {
+			if o.Element[j].Key == header[i] {
+				rsl[i] = o.Element[j].exportValue(s, render)
+			}
+		}
+	}
+	return rsl
+}
+
+func (o Output) jsonObject(s schema.Schema, render bool) jsonObject {
+	rsl := make(jsonObject, len(o.Element))
+	for i := range o.Element {
+		kv := o.Element[i]
+		rsl[i] = jsonField{Key: kv.Key, Value: kv.exportValue(s, false)}
+		if !render {
+			continue
+		}
+		if ref, ok := kv.Reference(s); ok {
+			nested := NewElement(reflect.ValueOf(ref))
+			obj := make(jsonObject, len(nested))
+			for j := range nested {
+				obj[j] = jsonField{Key: nested[j].Key, Value: nested[j].exportValue(s, false)}
+			}
+			rsl[i].Value = obj
+		}
+	}
+	return rsl
+}
+
+// exportValue returns the value for CSV, JSON and Markdown. Amounts are written without
+// thousands separators (as in the YAML files) to be readable by spreadsheets.
+func (k KeyValue) exportValue(s schema.Schema, render bool) string {
+	if mny, ok := k.Raw.(util.Money); ok {
+		if mny.Money == nil {
+			return ""
+		}
+		return mny.Value()
+	}
+	if render {
+		return k.RenderValue(s)
+	}
+	return k.Value
+}
+
+// jsonObject is a JSON object which keeps the order of its fields.
+type jsonObject []jsonField
+
+type jsonField struct {
+	Key   string
+	Value interface{}
+}
+
+func (j jsonObject) MarshalJSON() ([]byte, error) {
+	buf := bytes.NewBufferString("{")
+	for i := range j {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		key, err := json.Marshal(j[i].Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(j[i].Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.Bytes(), nil
+}
+
+func writeJson(w io.Writer, data interface{}) error {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error while marshaling JSON: %s", err)
+	}
+	_, err = fmt.Fprintln(w, string(raw))
+	return err
+}
+
+// writeRows writes a table with the given header as CSV or Markdown.
+func writeRows(w io.Writer, mode OutputMode, header []string, rows [][]string) error {
+	if mode == CsvMode {
+		wrt := csv.NewWriter(w)
+		if err := wrt.Write(header); err != nil {
+			return err
+		}
+		if err := wrt.WriteAll(rows); err != nil {
+			return err
+		}
+		return wrt.Error()
+	}
+	line := func(cells []string) string {
+		esc := make([]string, len(cells))
+		for i := range cells {
+			esc[i] = strings.ReplaceAll(strings.ReplaceAll(cells[i], "|", "\\|"), "\n", "<br>")
+		}
+		return fmt.Sprintf("| %s |\n", strings.Join(esc, " | "))
+	}
+	sep := make([]string, len(header))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	rsl := line(header) + line(sep)
+	for i := range rows {
+		rsl += line(rows[i])
+	}
+	_, err := fmt.Fprint(w, rsl)
+	return err
+}
+
 func multiline(text string, width int) string {
 	for i := width; i < len(text); i += width {
 		text = text[:i] + "\n" + text[i:]
@@ -0,0 +1,46 @@
+package query
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestOutputsWrite(t *testing.T) {
+	s := schema.Schema{
+		Parties: schema.PartiesCollection{
+			Customers: []schema.Party{{Id: "cst-1", Identifier: "c-1", Name: "Theater | AG"}},
+		},
+	}
+	inv := schema.NewInvoiceWithUuid()
+	inv.Identifier = "i-1"
+	inv.Amount = util.NewMoney(300000, "CHF")
+	inv.Customer = schema.NewRef("cst-1")
+	ele := NewElements([]schema.Invoice{inv}).Select([]string{"identifier", "amount", "customer"}, false)
+	out := OutputsFromElements(s, ele)
+
+	cases := []struct {
+		mode     OutputMode
+		render   bool
+		expected string
+	}{
+		{CsvMode, false, "Identifier,Amount,Customer\ni-1,3000.00 CHF,cst-1\n"},
+		{MarkdownMode, true, "| Identifier | Amount | Customer |\n| --- | --- | --- |\n| i-1 | 3000.00 CHF | cst-1, Theater \\| AG (c-1) |\n"},
+		{JsonMode, false, `"Customer": "cst-1"`},
+		{JsonMode, true, `"Customer": {
+      "Id": "cst-1",
+      "Identifier": "c-1",`},
+	}
+	for _, c := range cases {
+		var buf bytes.Buffer
+		if err := out.Write(&buf, s, c.mode, c.render); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), c.expected) {
+			t.Errorf("output of mode %d doesn't contain\n%s\ngot\n%s", c.mode, c.expected, buf.String())
+		}
+	}
+}
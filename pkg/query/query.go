@@ -71,6 +71,28 @@ func (q Queryables) QueryablesFromUserInput(input string) (Queryables, error) {
 	return rsl, nil
 }
 
+// ValidateKeys returns an error if one of the keys doesn't exist in any of the
+// queryables. The pseudo key type is always valid.
+func (q Queryables) ValidateKeys(keys []string) error {
+	available := q.keys()
+	for _, key := range keys {
+		if strings.EqualFold(key, typeKey) {
+			continue
+		}
+		found := false
+		for i := range available {
+			if strings.EqualFold(available[i].Name, key) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown key «%s» for %s, use 'acc query keys' to list the keys", key, q.String())
+		}
+	}
+	return nil
+}
+
 func (q Queryables) String() string {
 	if len(q) == 0 {
 		return ""
@@ -3,9 +3,13 @@ package query
 import (
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
 	"github.com/sirupsen/logrus"
 )
 
@@ -43,6 +47,41 @@ func (g ElementGroup) DateMatch(ranges DateTerms) ElementGroup {
 	return rsl
 }
 
+// Where returns the elements of the given type matching the query expression.
+func (g ElementGroup) Where(expr Expr, typ string) ElementGroup {
+	var rsl ElementGroup
+	for i := range g {
+		if expr.Eval(g[i], typ) {
+			rsl = append(rsl, g[i])
+		}
+	}
+	return rsl
+}
+
+// Sort orders the elements by the value of the given key, elements without the key are
+// placed at the end.
+func (g ElementGroup) Sort(key string, desc bool) {
+	sort.SliceStable(g, func(i, j int) bool {
+		a, okA := g[i].KeyValue(key)
+		b, okB := g[j].KeyValue(key)
+		if !okA || !okB {
+			return okA && !okB
+		}
+		if desc {
+			return a.CompareTo(b) > 0
+		}
+		return a.CompareTo(b) < 0
+	})
+}
+
+// Limit returns at most the first n elements, all if n isn't positive.
+func (g ElementGroup) Limit(n int) ElementGroup {
+	if n <= 0 || n >= len(g) {
+		return g
+	}
+	return g[:n]
+}
+
 func (g ElementGroup) Select(sel []string, caseSensitive bool) ElementGroup {
 	rsl := make(ElementGroup, len(g))
 	if !caseSensitive {
@@ -58,13 +97,23 @@ func (g ElementGroup) Select(sel []string, caseSensitive bool) ElementGroup {
 
 type Element []KeyValue
 
+// fieldValue returns the textual value of a field, references are represented by the
+// referenced id.
+func fieldValue(v reflect.Value) string {
+	if ref, ok := v.Interface().(schema.Ref); ok {
+		return ref.Id
+	}
+	return fmt.Sprint(v)
+}
+
 func NewElement(v reflect.Value) Element {
 	var rsl Element
 	t := v.Type()
 	for i := 0; i < v.NumField(); i++ {
 		rsl = append(rsl, KeyValue{
 			Key:   t.Field(i).Name,
-			Value: fmt.Sprint(v.Field(i)),
+			Value: fieldValue(v.Field(i)),
+			Raw:   v.Field(i).Interface(),
 			Field: t.Field(i),
 		})
 	}
@@ -117,6 +166,16 @@ func (e Element) Select(sel []string, caseSensitive bool) Element {
 	return rsl
 }
 
+// KeyValue returns the field with the given key (case-insensitive).
+func (e Element) KeyValue(key string) (KeyValue, bool) {
+	for i := range e {
+		if strings.EqualFold(e[i].Key, key) {
+			return e[i], true
+		}
+	}
+	return KeyValue{}, false
+}
+
 func (e Element) MaxKeyLength() int {
 	var rsl int
 	for i := range e {
@@ -130,9 +189,106 @@ func (e Element) MaxKeyLength() int {
 type KeyValue struct {
 	Key   string
 	Value string
+	// Raw is the original value of the field, used for comparisons.
+	Raw   interface{}
 	Field reflect.StructField
 }
 
+// IsEmpty returns whether the field is not set (ex: empty string, reference or nil pointer).
+func (k KeyValue) IsEmpty() bool {
+	switch raw := k.Raw.(type) {
+	case schema.Ref:
+		return raw.Empty()
+	case util.Money:
+		return raw.Money == nil
+	}
+	if k.Raw != nil {
+		v := reflect.ValueOf(k.Raw)
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+			return v.IsNil() || (v.Kind() != reflect.Ptr && v.Len() == 0)
+		}
+	}
+	return k.Value == ""
+}
+
+// hasCurrency returns whether the field is an amount in the given currency.
+func (k KeyValue) hasCurrency(code string) bool {
+	mny, ok := k.Raw.(util.Money)
+	return ok && mny.Money != nil && mny.Currency().Code == code
+}
+
+// Compare compares the field with the value of a query and returns -1, 0 or +1. Amounts
+// are compared in cents (only if the currency matches when given), numbers numerically and
+// dates (YYYY-MM-DD) chronologically. The second return value is false if the field can't
+// be compared with the value.
+func (k KeyValue) Compare(value, currency string) (int, bool) {
+	if mny, ok := k.Raw.(util.Money); ok {
+		if mny.Money == nil || (currency != "" && mny.Currency().Code != currency) {
+			return 0, false
+		}
+		cents, err := parseCents(value)
+		if err != nil {
+			return 0, false
+		}
+		return compareInt(mny.Amount(), cents), true
+	}
+	if currency != "" {
+		return 0, false
+	}
+	if a, err := time.Parse(util.DateFormat, k.Value); err == nil {
+		b, err := time.Parse(util.DateFormat, value)
+		if err != nil {
+			return 0, false
+		}
+		return compareInt(a.Unix(), b.Unix()), true
+	}
+	a, err := strconv.ParseFloat(k.Value, 64)
+	if err != nil {
+		return 0, false
+	}
+	b, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case a < b:
+		return -1, true
+	case a > b:
+		return 1, true
+	}
+	return 0, true
+}
+
+// CompareTo orders two fields of the same key. Amounts are ordered by currency and value,
+// dates and numbers by their value and everything else alphabetically.
+func (k KeyValue) CompareTo(other KeyValue) int {
+	a, okA := k.Raw.(util.Money)
+	b, okB := other.Raw.(util.Money)
+	if okA && okB && a.Money != nil && b.Money != nil {
+		if a.Currency().Code != b.Currency().Code {
+			return strings.Compare(a.Currency().Code, b.Currency().Code)
+		}
+		return compareInt(a.Amount(), b.Amount())
+	}
+	if !okA && !okB {
+		if cmp, ok := k.Compare(other.Value, ""); ok {
+			return cmp
+		}
+	}
+	return strings.Compare(strings.ToLower(k.Value), strings.ToLower(other.Value))
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
 func (k KeyValue) RenderValue(s schema.Schema) string {
 	switch k.Field.Tag.Get("query") {
 	case "customer":
@@ -189,3 +345,38 @@ func (k KeyValue) RenderValue(s schema.Schema) string {
 
 	return k.Value
 }
+
+// Reference returns the element referenced by the field (ex: the customer of an invoice).
+// The second return value is false if the field isn't a reference or the element doesn't
+// exist.
+func (k KeyValue) Reference(s schema.Schema) (interface{}, bool) {
+	if k.Value == "" {
+		return nil, false
+	}
+	ref := schema.NewRef(k.Value)
+	for _, typ := range strings.Split(k.Field.Tag.Get("query"), ",") {
+		switch typ {
+		case "customer":
+			if cst, err := s.Parties.CustomerByRef(ref); err == nil {
+				return *cst, true
+			}
+		case "employee":
+			if emp, err := s.Parties.EmployeeByRef(ref); err == nil {
+				return *emp, true
+			}
+		case "expense":
+			if exp, err := s.Expenses.ExpenseByRef(ref); err == nil {
+				return *exp, true
+			}
+		case "invoice":
+			if inv, err := s.Invoices.InvoiceByRef(ref); err == nil {
+				return *inv, true
+			}
+		case "transaction":
+			if trn, err := s.Statement.TransactionByRef(ref); err == nil {
+				return *trn, true
+			}
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,219 @@
+package query
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+// parameterRegex matches the parameters of saved queries (ex: {{year}}).
+var parameterRegex = regexp.MustCompile(`{{\s*([A-Za-z0-9_-]+)\s*}}`)
+
+// SavedQueries is a collection of SavedQuery elements stored in the project config.
+type SavedQueries []SavedQuery
+
+// QueryByName returns the saved query with the given name.
+func (s SavedQueries) QueryByName(name string) (*SavedQuery, error) {
+	for i := range s {
+		if s[i].Name == name {
+			return &s[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no saved query «%s» found, use 'acc query saved' to list them", name)
+}
+
+// PPSaved prints the names, descriptions and parameters of the saved queries.
+func (s SavedQueries) PPSaved(mode OutputMode) {
+	data := make(Element, len(s))
+	for i := range s {
+		desc := s[i].Description
+		if params := s[i].Parameters(); len(params) != 0 {
+			desc = strings.TrimSpace(fmt.Sprintf("%s (parameters: %s)", desc, strings.Join(params, ", ")))
+		}
+		data[i] = KeyValue{Key: s[i].Name, Value: desc}
+	}
+	out := Output{
+		Header:  []string{"Name", "Description"},
+		Element: data,
+	}
+	out.PPKeyValue(nil, mode, false)
+}
+
+// Validate all SavedQueries.
+func (s SavedQueries) Validate() util.ValidateResults {
+	var rsl util.ValidateResults
+	names := make(map[string]bool)
+	for i := range s {
+		res := util.Check(s[i])
+		if names[s[i].Name] {
+			res.Conditions = append(res.Conditions, util.Conditions{{
+				Condition: true,
+				Message:   fmt.Sprintf("name «%s» is used by multiple saved queries", s[i].Name),
+			}}...)
+		}
+		names[s[i].Name] = true
+		rsl = append(rsl, res)
+	}
+	return rsl
+}
+
+// SavedQuery is a named acc query with its options. All string options can contain
+// parameters like {{year}} which are given on the command line when running the query.
+type SavedQuery struct {
+	// Name used to run the query with acc query run NAME.
+	Name string `yaml:"name"`
+	// Description states the purpose of the query.
+	Description string `yaml:"description,omitempty"`
+	// Types to be queried separated by comma, all if empty.
+	Types string `yaml:"types,omitempty"`
+	// Where is a query expression (ex: type:expense AND amount>500 CHF).
+	Where string `yaml:"where,omitempty"`
+	// Match filters key:value combinations as REGEX:REGEX.
+	Match string `yaml:"match,omitempty"`
+	// Date filters date ranges as REGEX:YYYY-MM-DD:YYYY-MM-DD.
+	Date string `yaml:"date,omitempty"`
+	// Select contains the displayed keys separated by comma.
+	Select string `yaml:"select,omitempty"`
+	// Sort orders the result by KEY[:asc|:desc].
+	Sort string `yaml:"sort,omitempty"`
+	// Limit is the maximal number of printed elements (or groups), all if zero.
+	Limit int `yaml:"limit,omitempty"`
+	// GroupBy groups the elements by the value of the key.
+	GroupBy string `yaml:"groupBy,omitempty"`
+	// Sum contains the keys whose values are summed up separated by comma.
+	Sum string `yaml:"sum,omitempty"`
+	// Count prints the number of elements (per group).
+	Count bool `yaml:"count,omitempty"`
+	// Format is the output mode: table (default), yaml, csv, json or markdown.
+	Format string `yaml:"format,omitempty"`
+}
+
+// fields returns pointers to all string options which can contain parameters.
+func (q *SavedQuery) fields() []*string {
+	return []*string{&q.Types, &q.Where, &q.Match, &q.Date, &q.Select, &q.Sort, &q.GroupBy, &q.Sum, &q.Format}
+}
+
+// Parameters returns the sorted names of all parameters used in the query.
+func (q SavedQuery) Parameters() []string {
+	known := make(map[string]bool)
+	for _, field := range q.fields() {
+		for _, match := range parameterRegex.FindAllStringSubmatch(*field, -1) {
+			known[match[1]] = true
+		}
+	}
+	rsl := make([]string, 0, len(known))
+	for name := range known {
+		rsl = append(rsl, name)
+	}
+	sort.Strings(rsl)
+	return rsl
+}
+
+// Apply returns a copy of the query with all parameters replaced by the given values. An
+// error is returned if a value for a parameter is missing or a value isn't used.
+func (q SavedQuery) Apply(params map[string]string) (SavedQuery, error) {
+	var missing []string
+	for _, name := range q.Parameters() {
+		if _, ok := params[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) != 0 {
+		return q, fmt.Errorf("query «%s» needs a value for the parameter(s) %s (ex: %s=VALUE)", q.Name, strings.Join(missing, ", "), missing[0])
+	}
+	used := q.Parameters()
+	for name := range params {
+		if !contains(used, name) {
+			return q, fmt.Errorf("query «%s» has no parameter «%s»", q.Name, name)
+		}
+	}
+	for _, field := range q.fields() {
+		*field = parameterRegex.ReplaceAllStringFunc(*field, func(match string) string {
+			return params[parameterRegex.FindStringSubmatch(match)[1]]
+		})
+	}
+	return q, nil
+}
+
+// Run executes the saved query and writes the result to w. If format isn't empty it
+// overrides the format of the query.
+func (q SavedQuery) Run(w io.Writer, s schema.Schema, format string, render bool) error {
+	qry, err := AccQueryables.QueryablesFromUserInput(q.Types)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = q.Format
+	}
+	mode := TableMode
+	if format != "" {
+		if mode, err = OutputModeFromUserInput(format); err != nil {
+			return err
+		}
+	}
+	agg := Aggregation{GroupBy: q.GroupBy, Count: q.Count}
+	if q.Sum != "" {
+		agg.Sum = util.EscapedSplit(q.Sum, ",")
+	}
+	return qry.QueryAcc(w, s, q.Where, q.Match, q.Date, q.Select, q.Sort, q.Limit, agg, mode, render, false, false)
+}
+
+// Type returns a string with the type name of the element.
+func (q SavedQuery) Type() string {
+	return "Saved-Query"
+}
+
+// String returns a human readable representation of the element.
+func (q SavedQuery) String() string {
+	return q.Name
+}
+
+// Conditions returns the validation conditions.
+func (q SavedQuery) Conditions() util.Conditions {
+	_, formatErr := OutputModeFromUserInput(q.Format)
+	return util.Conditions{
+		{
+			Condition: q.Name == "",
+			Message:   "name not set (Name is empty)",
+		},
+		{
+			Condition: strings.ContainsAny(q.Name, " \t"),
+			Message:   fmt.Sprintf("name «%s» contains whitespace", q.Name),
+		},
+		{
+			Condition: q.Format != "" && !parameterRegex.MatchString(q.Format) && formatErr != nil,
+			Message:   fmt.Sprintf("unknown format «%s», use table, yaml, csv, json or markdown", q.Format),
+		},
+		{
+			Condition: q.Limit < 0,
+			Message:   "limit has to be positive",
+		},
+	}
+}
+
+// ParametersFromUserInput parses the query parameters given as KEY=VALUE.
+func ParametersFromUserInput(input []string) (map[string]string, error) {
+	rsl := make(map[string]string)
+	for i := range input {
+		ele := strings.SplitN(input[i], "=", 2)
+		if len(ele) != 2 || ele[0] == "" {
+			return nil, fmt.Errorf("parameter «%s» couldn't be parsed as KEY=VALUE", input[i])
+		}
+		rsl[ele[0]] = ele[1]
+	}
+	return rsl, nil
+}
+
+func contains(list []string, value string) bool {
+	for i := range list {
+		if list[i] == value {
+			return true
+		}
+	}
+	return false
+}
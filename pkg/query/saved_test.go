@@ -0,0 +1,30 @@
+package query
+
+import "testing"
+
+func TestSavedQueryApply(t *testing.T) {
+	qry := SavedQuery{
+		Name:  "expenses",
+		Types: "expense",
+		Where: "dateOfAccrual>={{year}}-{{ month }}-01 AND dateOfAccrual<={{year}}-{{month}}-31",
+	}
+	if params := qry.Parameters(); len(params) != 2 || params[0] != "month" || params[1] != "year" {
+		t.Errorf("unexpected parameters %v", params)
+	}
+	if _, err := qry.Apply(map[string]string{"year": "2023"}); err == nil {
+		t.Error("missing parameter month should fail")
+	}
+	if _, err := qry.Apply(map[string]string{"year": "2023", "month": "03", "day": "1"}); err == nil {
+		t.Error("unknown parameter day should fail")
+	}
+	rsl, err := qry.Apply(map[string]string{"year": "2023", "month": "03"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "dateOfAccrual>=2023-03-01 AND dateOfAccrual<=2023-03-31"; rsl.Where != expected {
+		t.Errorf("expected %s, got %s", expected, rsl.Where)
+	}
+	if qry.Where == rsl.Where {
+		t.Error("the saved query itself should stay untouched")
+	}
+}
@@ -1,23 +1,27 @@
 package query
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 	"time"
 
 	"github.com/72nd/acc/pkg/util"
-	"github.com/sirupsen/logrus"
 )
 
 type SearchTerms []SearchTerm
 
-func searchTermsFromUserInput(input string, caseSensitive bool) SearchTerms {
+func searchTermsFromUserInput(input string, caseSensitive bool) (SearchTerms, error) {
 	var rsl SearchTerms
 	ele := util.EscapedSplit(input, ",")
 	for i := range ele {
-		rsl = append(rsl, searchTermFromUserInput(ele[i], caseSensitive))
+		term, err := searchTermFromUserInput(ele[i], caseSensitive)
+		if err != nil {
+			return nil, err
+		}
+		rsl = append(rsl, term)
 	}
-	return rsl
+	return rsl, nil
 }
 
 type SearchTerm struct {
@@ -25,29 +29,29 @@ type SearchTerm struct {
 	Value *regexp.Regexp
 }
 
-func newSearchTerm(key, value string, caseSensitive bool) SearchTerm {
+func newSearchTerm(key, value string, caseSensitive bool) (SearchTerm, error) {
 	if !caseSensitive {
 		key = strings.ToLower(key)
 		value = strings.ToLower(value)
 	}
 	keyRe, err := regexp.Compile(key)
 	if err != nil {
-		logrus.Fatalf("error while parsing \"%s\" as key-regex from term \"%s:%s\"", key, key, value)
+		return SearchTerm{}, fmt.Errorf("error while parsing \"%s\" as key-regex from term \"%s:%s\": %s", key, key, value, err)
 	}
 	valRe, err := regexp.Compile(value)
 	if err != nil {
-		logrus.Fatalf("error while parsing \"%s\" as value-regex from term \"%s:%s\"", value, key, value)
+		return SearchTerm{}, fmt.Errorf("error while parsing \"%s\" as value-regex from term \"%s:%s\": %s", value, key, value, err)
 	}
 	return SearchTerm{
 		Key:   keyRe,
 		Value: valRe,
-	}
+	}, nil
 }
 
-func searchTermFromUserInput(input string, caseSensitive bool) SearchTerm {
+func searchTermFromUserInput(input string, caseSensitive bool) (SearchTerm, error) {
 	ele := util.EscapedSplit(input, ":")
 	if len(ele) != 2 {
-		logrus.Fatalf("input \"%s\" couldn't be parsed as KEY:VALUE, use \\: to escape colons inside your pattern", input)
+		return SearchTerm{}, fmt.Errorf("input \"%s\" couldn't be parsed as KEY:VALUE, use \\: to escape colons inside your pattern", input)
 	}
 	return newSearchTerm(ele[0], ele[1], caseSensitive)
 }
@@ -69,14 +73,17 @@ func (s SearchTerm) matchValue(input string, caseSensitive bool) bool {
 
 type DateTerms []DateTerm
 
-func dateTermsFromUserInput(input string) DateTerms {
+func dateTermsFromUserInput(input string) (DateTerms, error) {
 	var rsl DateTerms
 	ele := util.EscapedSplit(input, ",")
 	for i := range ele {
-		rsl = append(rsl, dateTermFromUserInput(ele[i]))
+		term, err := dateTermFromUserInput(ele[i])
+		if err != nil {
+			return nil, err
+		}
+		rsl = append(rsl, term)
 	}
-	return rsl
-
+	return rsl, nil
 }
 
 type DateTerm struct {
@@ -85,38 +92,58 @@ type DateTerm struct {
 	To   time.Time
 }
 
-func dateTermFromUserInput(input string) DateTerm {
+func dateTermFromUserInput(input string) (DateTerm, error) {
 	ele := util.EscapedSplit(input, ":")
 	if len(ele) != 3 {
-		logrus.Fatalf("input \"%s\" couldn't be parsed as KEY:FROM:TO, use \\: to escape colons inside your pattern", input)
+		return DateTerm{}, fmt.Errorf("input \"%s\" couldn't be parsed as KEY:FROM:TO, use \\: to escape colons inside your pattern", input)
 	}
 	key, err := regexp.Compile(ele[0])
 	if err != nil {
-		logrus.Fatalf("error while parsing \"%s\" as key-regex from term \"%s:%s:%s\"", ele[0], ele[0], ele[1], ele[2])
+		return DateTerm{}, fmt.Errorf("error while parsing \"%s\" as key-regex from term \"%s\": %s", ele[0], input, err)
 	}
 	from, err := time.Parse(util.DateFormat, ele[1])
 	if err != nil {
-		logrus.Fatalf("error while parsing \"%s\" as from-date from term \"%s:%s:%s\"", ele[1], ele[0], ele[1], ele[2])
+		return DateTerm{}, fmt.Errorf("error while parsing \"%s\" as from-date from term \"%s\"", ele[1], input)
 	}
-	to, err := time.Parse(util.DateFormat, ele[1])
+	to, err := time.Parse(util.DateFormat, ele[2])
 	if err != nil {
-		logrus.Fatalf("error while parsing \"%s\" as to-date from term \"%s:%s:%s\"", ele[2], ele[0], ele[1], ele[2])
+		return DateTerm{}, fmt.Errorf("error while parsing \"%s\" as to-date from term \"%s\"", ele[2], input)
 	}
 	return DateTerm{
 		Key:  key,
 		From: from,
 		To:   to,
-	}
+	}, nil
 }
 
 func (d DateTerm) matchKey(input string) bool {
 	return d.Key.MatchString(input)
 }
 
+// matchRange returns whether the date is within the range, values which aren't a date
+// (ex: unset dates) don't match.
 func (d DateTerm) matchRange(input string) bool {
 	date, err := time.Parse(util.DateFormat, input)
 	if err != nil {
-		logrus.Fatalf("\"%s\" couldn't be parsed as date: %s", input, err)
+		return false
 	}
 	return !date.Before(d.From) && !date.After(d.To)
 }
+
+// sortTermFromUserInput parses the sort input KEY[:asc|:desc].
+func sortTermFromUserInput(input string) (key string, desc bool, err error) {
+	ele := strings.Split(input, ":")
+	if len(ele) > 2 || ele[0] == "" {
+		return "", false, fmt.Errorf("sort «%s» couldn't be parsed as KEY[:asc|:desc]", input)
+	}
+	if len(ele) == 2 {
+		switch strings.ToLower(ele[1]) {
+		case "asc":
+		case "desc":
+			desc = true
+		default:
+			return "", false, fmt.Errorf("unknown sort order «%s» in «%s», use asc or desc", ele[1], input)
+		}
+	}
+	return ele[0], desc, nil
+}
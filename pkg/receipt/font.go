@@ -0,0 +1,307 @@
+package receipt
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// font translates the character codes of shown strings into text and glyph widths.
+type font struct {
+	// toUnicode maps character codes to text (from the ToUnicode CMap).
+	toUnicode map[int]string
+	// codespace contains the byte lengths of the codes by their first byte. A length of
+	// 0 means the default length is used.
+	codespace [256]int
+	// codeLength is the default length of a character code in bytes.
+	codeLength int
+	// encoding maps the codes of simple fonts to text (base encoding and Differences).
+	encoding map[int]string
+	// widths of the glyphs in thousandths of text space units.
+	widths map[int]float64
+	// defaultWidth is used for codes without a width.
+	defaultWidth float64
+}
+
+// glyph is a single character code of a shown string.
+type glyph struct {
+	text  string
+	width float64
+	space bool
+}
+
+// newFont reads the font dictionary.
+func (d *document) newFont(fd dict) *font {
+	f := &font{
+		codeLength:   1,
+		encoding:     make(map[int]string),
+		widths:       make(map[int]float64),
+		defaultWidth: 500,
+	}
+	if fd == nil {
+		return f
+	}
+	if fd["Subtype"] == name("Type0") {
+		f.codeLength = 2
+		f.defaultWidth = 1000
+		descendants, _ := d.resolve(fd["DescendantFonts"]).(array)
+		if len(descendants) != 0 {
+			if cid, ok := d.resolve(descendants[0]).(dict); ok {
+				d.cidWidths(f, cid)
+			}
+		}
+	} else {
+		d.simpleEncoding(f, fd)
+		first, _ := d.resolve(fd["FirstChar"]).(float64)
+		widths, _ := d.resolve(fd["Widths"]).(array)
+		for i := range widths {
+			if w, ok := d.resolve(widths[i]).(float64); ok {
+				f.widths[int(first)+i] = w
+			}
+		}
+	}
+	if stm, ok := d.resolve(fd["ToUnicode"]).(stream); ok {
+		if data, err := d.decode(stm); err == nil {
+			f.readCMap(data)
+		}
+	}
+	return f
+}
+
+// cidWidths reads the widths of a CID font (W and DW).
+func (d *document) cidWidths(f *font, cid dict) {
+	if dw, ok := d.resolve(cid["DW"]).(float64); ok {
+		f.defaultWidth = dw
+	}
+	w, _ := d.resolve(cid["W"]).(array)
+	for i := 0; i < len(w); {
+		first, ok := d.resolve(w[i]).(float64)
+		if !ok || i+1 >= len(w) {
+			return
+		}
+		if list, ok := d.resolve(w[i+1]).(array); ok {
+			for j := range list {
+				if width, ok := d.resolve(list[j]).(float64); ok {
+					f.widths[int(first)+j] = width
+				}
+			}
+			i += 2
+			continue
+		}
+		last, ok1 := d.resolve(w[i+1]).(float64)
+		if i+2 >= len(w) {
+			return
+		}
+		width, ok2 := d.resolve(w[i+2]).(float64)
+		if ok1 && ok2 && last-first < 65536 {
+			for code := int(first); code <= int(last); code++ {
+				f.widths[code] = width
+			}
+		}
+		i += 3
+	}
+}
+
+// simpleEncoding sets the encoding of a simple font. Windows-1252 (WinAnsiEncoding) is
+// used as base encoding for all fonts as the other standard encodings only differ in
+// rarely used characters.
+func (d *document) simpleEncoding(f *font, fd dict) {
+	for code := 0; code < 256; code++ {
+		f.encoding[code] = string(charmap.Windows1252.DecodeByte(byte(code)))
+	}
+	enc, ok := d.resolve(fd["Encoding"]).(dict)
+	if !ok {
+		return
+	}
+	diff, _ := d.resolve(enc["Differences"]).(array)
+	code := 0
+	for i := range diff {
+		switch value := d.resolve(diff[i]).(type) {
+		case float64:
+			code = int(value)
+		case name:
+			if text, ok := glyphText(string(value)); ok {
+				f.encoding[code] = text
+			}
+			code++
+		}
+	}
+}
+
+// glyphNames contains the text of common glyph names which don't consist of a single
+// letter or aren't in the uniXXXX form.
+var glyphNames = map[string]string{
+	"space": " ", "exclam": "!", "quotedbl": "\"", "numbersign": "#", "dollar": "$",
+	"percent": "%", "ampersand": "&", "quotesingle": "'", "parenleft": "(", "parenright": ")",
+	"asterisk": "*", "plus": "+", "comma": ",", "hyphen": "-", "period": ".", "slash": "/",
+	"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4", "five": "5", "six": "6",
+	"seven": "7", "eight": "8", "nine": "9", "colon": ":", "semicolon": ";", "less": "<",
+	"equal": "=", "greater": ">", "question": "?", "at": "@", "bracketleft": "[",
+	"backslash": "\\", "bracketright": "]", "asciicircum": "^", "underscore": "_",
+	"grave": "`", "braceleft": "{", "bar": "|", "braceright": "}", "asciitilde": "~",
+	"quoteleft": "‘", "quoteright": "’", "quotedblleft": "“", "quotedblright": "”",
+	"quotesinglbase": "‚", "quotedblbase": "„", "guillemotleft": "«", "guillemotright": "»",
+	"endash": "–", "emdash": "—", "bullet": "•", "ellipsis": "…", "periodcentered": "·",
+	"degree": "°", "section": "§", "paragraph": "¶", "copyright": "©", "registered": "®",
+	"trademark": "™", "euro": "€", "Euro": "€", "sterling": "£", "yen": "¥", "cent": "¢",
+	"minus": "−", "multiply": "×", "divide": "÷", "plusminus": "±", "fi": "fi", "fl": "fl",
+	"nbspace": " ", "nonbreakingspace": " ", "germandbls": "ß",
+	"adieresis": "ä", "odieresis": "ö", "udieresis": "ü", "edieresis": "ë", "idieresis": "ï",
+	"Adieresis": "Ä", "Odieresis": "Ö", "Udieresis": "Ü", "eacute": "é", "egrave": "è",
+	"ecircumflex": "ê", "Eacute": "É", "Egrave": "È", "agrave": "à", "acircumflex": "â",
+	"aacute": "á", "Agrave": "À", "ccedilla": "ç", "Ccedilla": "Ç", "icircumflex": "î",
+	"iacute": "í", "ocircumflex": "ô", "oacute": "ó", "ograve": "ò", "ucircumflex": "û",
+	"uacute": "ú", "ugrave": "ù", "ntilde": "ñ", "oslash": "ø", "aring": "å",
+}
+
+// glyphText returns the text of a glyph name.
+func glyphText(glyphName string) (string, bool) {
+	if i := strings.Index(glyphName, "."); i > 0 {
+		glyphName = glyphName[:i]
+	}
+	if text, ok := glyphNames[glyphName]; ok {
+		return text, true
+	}
+	if len(glyphName) == 1 {
+		return glyphName, true
+	}
+	for _, prefix := range []string{"uni", "u"} {
+		if strings.HasPrefix(glyphName, prefix) && len(glyphName) >= len(prefix)+4 {
+			if value, err := strconv.ParseUint(glyphName[len(prefix):len(prefix)+4], 16, 32); err == nil {
+				return string(rune(value)), true
+			}
+		}
+	}
+	return "", false
+}
+
+// readCMap reads the codespace ranges and mappings of a ToUnicode CMap.
+func (f *font) readCMap(data []byte) {
+	f.toUnicode = make(map[int]string)
+	l := &lexer{data: data}
+	var operands []interface{}
+	for {
+		obj := l.object()
+		if obj == nil && l.pos >= len(l.data) {
+			return
+		}
+		op, ok := obj.(keyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+		switch op {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				lo, ok1 := operands[i].(string)
+				hi, ok2 := operands[i+1].(string)
+				if !ok1 || !ok2 || len(lo) == 0 || len(hi) == 0 {
+					continue
+				}
+				for b := int(lo[0]); b <= int(hi[0]); b++ {
+					f.codespace[b] = len(lo)
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				src, ok := operands[i].(string)
+				if !ok {
+					continue
+				}
+				if dst, ok := operands[i+1].(string); ok {
+					f.toUnicode[codeValue(src)] = decodeUTF16(dst)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				lo, ok1 := operands[i].(string)
+				hi, ok2 := operands[i+1].(string)
+				if !ok1 || !ok2 {
+					continue
+				}
+				f.bfrange(codeValue(lo), codeValue(hi), operands[i+2])
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// bfrange adds a range mapping of a ToUnicode CMap. The destination is either the text
+// of the first code (incremented for the following codes) or an array with the text of
+// each code.
+func (f *font) bfrange(lo, hi int, dst interface{}) {
+	if hi < lo || hi-lo > 65535 {
+		return
+	}
+	switch value := dst.(type) {
+	case string:
+		units := utf16.Decode(utf16Units(value))
+		if len(units) == 0 {
+			return
+		}
+		for code := lo; code <= hi; code++ {
+			text := append([]rune{}, units...)
+			text[len(text)-1] += rune(code - lo)
+			f.toUnicode[code] = string(text)
+		}
+	case array:
+		for i := range value {
+			if text, ok := value[i].(string); ok && lo+i <= hi {
+				f.toUnicode[lo+i] = decodeUTF16(text)
+			}
+		}
+	}
+}
+
+// codeValue returns the big endian value of a character code.
+func codeValue(code string) int {
+	value := 0
+	for i := 0; i < len(code); i++ {
+		value = value<<8 | int(code[i])
+	}
+	return value
+}
+
+func utf16Units(raw string) []uint16 {
+	units := make([]uint16, 0, len(raw)/2)
+	for i := 0; i+1 < len(raw); i += 2 {
+		units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+	}
+	return units
+}
+
+func decodeUTF16(raw string) string {
+	if len(raw) == 1 {
+		return raw
+	}
+	return string(utf16.Decode(utf16Units(raw)))
+}
+
+// glyphs splits a shown string into its glyphs.
+func (f *font) glyphs(raw string) []glyph {
+	var rsl []glyph
+	for i := 0; i < len(raw); {
+		length := f.codespace[raw[i]]
+		if length == 0 {
+			length = f.codeLength
+		}
+		if i+length > len(raw) {
+			length = len(raw) - i
+		}
+		code := codeValue(raw[i : i+length])
+		g := glyph{width: f.defaultWidth, space: length == 1 && code == 32}
+		if w, ok := f.widths[code]; ok {
+			g.width = w
+		}
+		if text, ok := f.toUnicode[code]; ok {
+			g.text = text
+		} else if text, ok := f.encoding[code]; ok {
+			g.text = text
+		}
+		rsl = append(rsl, g)
+		i += length
+	}
+	return rsl
+}
@@ -0,0 +1,539 @@
+package receipt
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// This file contains a minimal reader for the object structure of PDF files. It only
+// supports what is needed to extract the text of unencrypted documents: indirect objects
+// (also within object streams) and the Flate, ASCIIHex and ASCII85 filters. The cross
+// reference table isn't used, instead the file is scanned for objects which also works
+// with slightly damaged files.
+
+// name is a PDF name object (ex: /Type).
+type name string
+
+// keyword is a bare word in a PDF (ex: true, null, obj, content stream operators).
+type keyword string
+
+// ref is a reference to an indirect object (ex: 12 0 R).
+type ref struct {
+	num int
+	gen int
+}
+
+// dict is a PDF dictionary.
+type dict map[name]interface{}
+
+// array is a PDF array.
+type array []interface{}
+
+// stream is a PDF stream with its dictionary and the raw (still encoded) data.
+type stream struct {
+	dict dict
+	data []byte
+}
+
+// lexer splits PDF data into tokens.
+type lexer struct {
+	data []byte
+	pos  int
+}
+
+// delimiter bytes end names, numbers and keywords.
+func isDelimiter(b byte) bool {
+	switch b {
+	case '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return true
+	}
+	return false
+}
+
+func isSpace(b byte) bool {
+	switch b {
+	case 0, '\t', '\n', '\f', '\r', ' ':
+		return true
+	}
+	return false
+}
+
+// skipSpace skips whitespace and comments.
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.data) {
+		switch {
+		case isSpace(l.data[l.pos]):
+			l.pos++
+		case l.data[l.pos] == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// token returns the next token: a number (float64), name, keyword, string (literal or
+// hex string as raw bytes) or one of the delimiter keywords <<, >>, [, ], { and }. Nil
+// is returned at the end of the data.
+func (l *lexer) token() interface{} {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil
+	}
+	b := l.data[l.pos]
+	switch {
+	case b == '/':
+		l.pos++
+		start := l.pos
+		for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+			l.pos++
+		}
+		return name(decodeName(l.data[start:l.pos]))
+	case b == '(':
+		return l.literalString()
+	case b == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		l.pos += 2
+		return keyword("<<")
+	case b == '>' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '>':
+		l.pos += 2
+		return keyword(">>")
+	case b == '<':
+		return l.hexString()
+	case isDelimiter(b):
+		l.pos++
+		return keyword(string(b))
+	}
+	start := l.pos
+	for l.pos < len(l.data) && !isSpace(l.data[l.pos]) && !isDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	word := string(l.data[start:l.pos])
+	if value, err := strconv.ParseFloat(word, 64); err == nil {
+		return value
+	}
+	return keyword(word)
+}
+
+// decodeName resolves the #xx escapes of a name.
+func decodeName(raw []byte) string {
+	if !bytes.Contains(raw, []byte("#")) {
+		return string(raw)
+	}
+	var rsl []byte
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '#' && i+2 < len(raw) {
+			if b, err := hex.DecodeString(string(raw[i+1 : i+3])); err == nil {
+				rsl = append(rsl, b[0])
+				i += 2
+				continue
+			}
+		}
+		rsl = append(rsl, raw[i])
+	}
+	return string(rsl)
+}
+
+func (l *lexer) literalString() string {
+	l.pos++
+	var rsl []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		b := l.data[l.pos]
+		l.pos++
+		switch b {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return string(rsl)
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return string(rsl)
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				b = '\n'
+			case 'r':
+				b = '\r'
+			case 't':
+				b = '\t'
+			case 'b':
+				b = '\b'
+			case 'f':
+				b = '\f'
+			case '\r':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						value = value*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					b = byte(value)
+				} else {
+					b = e
+				}
+			}
+		}
+		rsl = append(rsl, b)
+	}
+	return string(rsl)
+}
+
+func (l *lexer) hexString() string {
+	l.pos++
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if !isSpace(l.data[l.pos]) {
+			digits = append(digits, l.data[l.pos])
+		}
+		l.pos++
+	}
+	l.pos++
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	rsl, _ := hex.DecodeString(string(digits))
+	return string(rsl)
+}
+
+// object parses the next object. References (12 0 R) are resolved to ref values.
+// Operators (keywords which aren't part of an object) are returned as keyword.
+func (l *lexer) object() interface{} {
+	tok := l.token()
+	switch t := tok.(type) {
+	case keyword:
+		switch t {
+		case "<<":
+			d := make(dict)
+			for {
+				key := l.object()
+				if key == nil || key == keyword(">>") {
+					return d
+				}
+				k, ok := key.(name)
+				if !ok {
+					continue
+				}
+				value := l.object()
+				if value == keyword(">>") {
+					d[k] = nil
+					return d
+				}
+				d[k] = value
+			}
+		case "[":
+			a := array{}
+			for {
+				value := l.object()
+				if value == nil || value == keyword("]") {
+					return a
+				}
+				a = append(a, value)
+			}
+		case "true":
+			return true
+		case "false":
+			return false
+		case "null":
+			return nil
+		}
+		return t
+	case float64:
+		// A number may be the start of a reference (num gen R).
+		save := l.pos
+		if gen, ok := l.token().(float64); ok {
+			if l.token() == keyword("R") {
+				return ref{int(t), int(gen)}
+			}
+		}
+		l.pos = save
+		return t
+	}
+	return tok
+}
+
+// document is a parsed PDF file.
+type document struct {
+	objects map[int]interface{}
+}
+
+// objectRegex matches the start of an indirect object.
+var objectRegex = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// encryptRegex matches the reference to the encryption dictionary in the trailer.
+var encryptRegex = regexp.MustCompile(`/Encrypt\s*(\d+\s+\d+\s+R|<<)`)
+
+// parseDocument reads all objects of the PDF data.
+func parseDocument(data []byte) (*document, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, " \t\r\n"), []byte("%PDF")) {
+		return nil, fmt.Errorf("not a PDF file")
+	}
+	if encryptRegex.Match(data) {
+		return nil, fmt.Errorf("encrypted PDF files are not supported")
+	}
+	doc := &document{objects: make(map[int]interface{})}
+	for _, match := range objectRegex.FindAllSubmatchIndex(data, -1) {
+		num, _ := strconv.Atoi(string(data[match[2]:match[3]]))
+		l := &lexer{data: data, pos: match[1]}
+		obj := l.object()
+		if d, ok := obj.(dict); ok {
+			save := l.pos
+			if l.token() == keyword("stream") {
+				obj = stream{dict: d, data: streamData(data, l.pos, d)}
+			} else {
+				l.pos = save
+			}
+		}
+		doc.objects[num] = obj
+	}
+	doc.readObjectStreams()
+	return doc, nil
+}
+
+// streamData returns the raw data of a stream starting after the stream keyword at pos.
+func streamData(data []byte, pos int, d dict) []byte {
+	if pos < len(data) && data[pos] == '\r' {
+		pos++
+	}
+	if pos < len(data) && data[pos] == '\n' {
+		pos++
+	}
+	// The length is taken from the file and may be wrong, negative or too large.
+	if length, ok := d["Length"].(float64); ok && length >= 0 && length <= float64(len(data)-pos) {
+		end := pos + int(length)
+		if bytes.HasPrefix(bytes.TrimLeft(data[end:], " \t\r\n"), []byte("endstream")) {
+			return data[pos:end]
+		}
+	}
+	end := bytes.Index(data[pos:], []byte("endstream"))
+	if end == -1 {
+		return data[pos:]
+	}
+	return bytes.TrimRight(data[pos:pos+end], "\r\n")
+}
+
+// readObjectStreams adds the objects stored in object streams (PDF 1.5). Objects which
+// are defined directly in the file take precedence.
+func (d *document) readObjectStreams() {
+	nums := make([]int, 0, len(d.objects))
+	for num := range d.objects {
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	for _, num := range nums {
+		stm, ok := d.objects[num].(stream)
+		if !ok || stm.dict["Type"] != name("ObjStm") {
+			continue
+		}
+		data, err := d.decode(stm)
+		if err != nil {
+			continue
+		}
+		n, _ := d.resolve(stm.dict["N"]).(float64)
+		first, _ := d.resolve(stm.dict["First"]).(float64)
+		header := &lexer{data: data}
+		for i := 0; i < int(n); i++ {
+			objNum, ok1 := header.token().(float64)
+			offset, ok2 := header.token().(float64)
+			if !ok1 || !ok2 || int(first+offset) >= len(data) {
+				break
+			}
+			if _, ok := d.objects[int(objNum)]; ok {
+				continue
+			}
+			l := &lexer{data: data, pos: int(first + offset)}
+			d.objects[int(objNum)] = l.object()
+		}
+	}
+}
+
+// resolve returns the object a reference points to, other objects are returned as is.
+func (d *document) resolve(obj interface{}) interface{} {
+	for i := 0; i < 32; i++ {
+		r, ok := obj.(ref)
+		if !ok {
+			return obj
+		}
+		obj = d.objects[r.num]
+	}
+	return nil
+}
+
+// dict returns the resolved dictionary of the key, nil if there is none. The dictionary
+// of a stream is also returned.
+func (d *document) dict(parent dict, key name) dict {
+	switch obj := d.resolve(parent[key]).(type) {
+	case dict:
+		return obj
+	case stream:
+		return obj.dict
+	}
+	return nil
+}
+
+// decode applies the filters of the stream and returns the decoded data.
+func (d *document) decode(stm stream) ([]byte, error) {
+	var filters []name
+	switch f := d.resolve(stm.dict["Filter"]).(type) {
+	case name:
+		filters = []name{f}
+	case array:
+		for i := range f {
+			if n, ok := d.resolve(f[i]).(name); ok {
+				filters = append(filters, n)
+			}
+		}
+	}
+	data := stm.data
+	for _, filter := range filters {
+		var err error
+		switch filter {
+		case "FlateDecode", "Fl":
+			data, err = inflate(data)
+		case "ASCIIHexDecode", "AHx":
+			l := &lexer{data: append(append([]byte("<"), data...), '>')}
+			data = []byte(l.hexString())
+		case "ASCII85Decode", "A85":
+			data, err = decodeASCII85(data)
+		default:
+			err = fmt.Errorf("unsupported filter %s", filter)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// inflate decompresses zlib data. Data which was decompressed before an error occurred
+// (ex: missing checksum) is still returned.
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	rsl, err := ioutil.ReadAll(r)
+	if len(rsl) != 0 {
+		return rsl, nil
+	}
+	return rsl, err
+}
+
+func decodeASCII85(data []byte) ([]byte, error) {
+	data = bytes.TrimSpace(data)
+	data = bytes.TrimPrefix(data, []byte("<~"))
+	if i := bytes.Index(data, []byte("~>")); i != -1 {
+		data = data[:i]
+	}
+	rsl := make([]byte, len(data))
+	n, _, err := ascii85.Decode(rsl, data, true)
+	return rsl[:n], err
+}
+
+// pages returns the page dictionaries in the order of the page tree. If no page tree
+// is found, all page objects are returned in the order of their object numbers.
+func (d *document) pages() []dict {
+	var roots []dict
+	for _, num := range d.sortedNums() {
+		if obj, ok := d.objects[num].(dict); ok && obj["Type"] == name("Pages") && obj["Parent"] == nil {
+			roots = append(roots, obj)
+		}
+	}
+	var rsl []dict
+	var walk func(node dict, depth int)
+	walk = func(node dict, depth int) {
+		if depth > 32 {
+			return
+		}
+		kids, _ := d.resolve(node["Kids"]).(array)
+		for i := range kids {
+			kid, ok := d.resolve(kids[i]).(dict)
+			if !ok {
+				continue
+			}
+			if kid["Type"] == name("Pages") {
+				walk(kid, depth+1)
+			} else {
+				rsl = append(rsl, kid)
+			}
+		}
+	}
+	for i := range roots {
+		walk(roots[i], 0)
+	}
+	if len(rsl) != 0 {
+		return rsl
+	}
+	for _, num := range d.sortedNums() {
+		if obj, ok := d.objects[num].(dict); ok && obj["Type"] == name("Page") {
+			rsl = append(rsl, obj)
+		}
+	}
+	return rsl
+}
+
+func (d *document) sortedNums() []int {
+	rsl := make([]int, 0, len(d.objects))
+	for num := range d.objects {
+		rsl = append(rsl, num)
+	}
+	sort.Ints(rsl)
+	return rsl
+}
+
+// inherited returns the value of the key of the page or of one of its parents.
+func (d *document) inherited(page dict, key name) interface{} {
+	node := page
+	for i := 0; i < 32 && node != nil; i++ {
+		if value, ok := node[key]; ok {
+			return d.resolve(value)
+		}
+		node, _ = d.resolve(node["Parent"]).(dict)
+	}
+	return nil
+}
+
+// contents returns the decoded content streams of the page.
+func (d *document) contents(page dict) []byte {
+	var streams []interface{}
+	switch c := d.resolve(page["Contents"]).(type) {
+	case stream:
+		streams = append(streams, c)
+	case array:
+		streams = c
+	}
+	var rsl []byte
+	for i := range streams {
+		stm, ok := d.resolve(streams[i]).(stream)
+		if !ok {
+			continue
+		}
+		data, err := d.decode(stm)
+		if err != nil {
+			continue
+		}
+		rsl = append(append(rsl, data...), '\n')
+	}
+	return rsl
+}
@@ -0,0 +1,121 @@
+package receipt
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+// QRBill contains the payment data of a Swiss QR-bill.
+type QRBill struct {
+	// IBAN (or QR-IBAN) of the creditor without spaces.
+	IBAN string
+	// Creditor is the name of the creditor.
+	Creditor string
+	// Amount of the bill, Amount.Money is nil if the bill has no amount.
+	Amount util.Money
+	// ReferenceType is QRR, SCOR or NON. Empty if the bill was read from the payment part.
+	ReferenceType string
+	// Reference is the payment reference.
+	Reference string
+	// Message is the unstructured message (additional information).
+	Message string
+}
+
+// ParseQRBill returns the data of a QR-bill in the text, nil if there is none. The data
+// is read from the payload of the QR-code (starting with SPC) if the text contains it,
+// otherwise from the human readable payment part. The QR-code image itself isn't decoded.
+func ParseQRBill(text string) *QRBill {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+	for i := range lines {
+		if strings.TrimSpace(lines[i]) == "SPC" && i+1 < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i+1]), "02") {
+			return parsePayload(lines[i:])
+		}
+	}
+	return parsePaymentPart(lines)
+}
+
+// parsePayload reads the fields of the QR-code payload (Swiss Implementation Guidelines
+// QR-bill, version 2).
+func parsePayload(fields []string) *QRBill {
+	field := func(i int) string {
+		if i < len(fields) {
+			return strings.TrimSpace(fields[i])
+		}
+		return ""
+	}
+	rsl := &QRBill{
+		IBAN:          strings.Replace(field(3), " ", "", -1),
+		Creditor:      field(5),
+		ReferenceType: field(27),
+		Reference:     field(28),
+		Message:       field(29),
+	}
+	if value := field(18); value != "" {
+		if amount, err := util.NewMonyFromDotNotation(value, field(19)); err == nil {
+			rsl.Amount = amount
+		}
+	}
+	return rsl
+}
+
+// Headings of the payment part in German, English, French and Italian.
+var (
+	accountHeadings   = []string{"konto / zahlbar an", "account / payable to", "compte / payable à", "conto / pagabile a"}
+	referenceHeadings = []string{"referenz", "reference", "référence", "riferimento"}
+	messageHeadings   = []string{"zusätzliche informationen", "additional information", "informations supplémentaires", "informazioni supplementari"}
+	amountHeadings    = []string{"währung betrag", "currency amount", "monnaie montant", "valuta importo"}
+)
+
+// qrAmountRegex matches the amount in the payment part, which uses spaces as thousands
+// separator.
+var qrAmountRegex = regexp.MustCompile(`\b(CHF|EUR)\s+(\d{1,3}(?: \d{3})*\.\d{2})\b`)
+
+// parsePaymentPart reads the data from the headings of the payment part. The receipt and
+// the payment part are side by side, thus the values are often on the same line twice.
+func parsePaymentPart(lines []string) *QRBill {
+	var rsl *QRBill
+	value := func(i int) string {
+		if i < len(lines) {
+			return dedupe(lines[i])
+		}
+		return ""
+	}
+	for i := range lines {
+		lower := strings.ToLower(lines[i])
+		switch {
+		case containsAny(lower, accountHeadings):
+			iban := findIBAN(value(i + 1))
+			if iban == "" {
+				continue
+			}
+			rsl = &QRBill{IBAN: iban, Creditor: value(i + 2)}
+		case rsl == nil:
+			continue
+		case containsAny(lower, amountHeadings):
+			if match := qrAmountRegex.FindStringSubmatch(value(i + 1)); match != nil {
+				if amount, err := util.NewMonyFromDotNotation(strings.Replace(match[2], " ", "", -1), match[1]); err == nil {
+					rsl.Amount = amount
+				}
+			}
+		case containsAny(lower, messageHeadings):
+			rsl.Message = value(i + 1)
+		case containsAny(lower, referenceHeadings) && rsl.Reference == "":
+			rsl.Reference = value(i + 1)
+		}
+	}
+	return rsl
+}
+
+// dedupe returns the first half of a line which consists of the same text twice.
+func dedupe(line string) string {
+	words := strings.Fields(line)
+	if len(words)%2 == 0 && len(words) != 0 {
+		first := strings.Join(words[:len(words)/2], " ")
+		if first == strings.Join(words[len(words)/2:], " ") {
+			return first
+		}
+	}
+	return strings.Join(words, " ")
+}
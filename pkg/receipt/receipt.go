@@ -0,0 +1,421 @@
+// Package receipt extracts the text of PDF receipts and parses the values needed for an
+// expense (total amount, currency, date, supplier, IBAN and Swiss QR-bill data). The
+// extraction is done in pure Go and only works for PDFs with a text layer, scanned
+// receipts are not supported.
+package receipt
+
+import (
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+// Receipt contains the values found in the text of a receipt. Values which couldn't be
+// found are empty, Amount.Money is nil if no amount was found.
+type Receipt struct {
+	// Text is the extracted text, line by line.
+	Text string
+	// Amount is the total amount of the receipt.
+	Amount util.Money
+	// Date of the receipt in the YYYY-MM-DD format.
+	Date string
+	// Supplier is the name of the issuer of the receipt.
+	Supplier string
+	// IBAN of the supplier without spaces.
+	IBAN string
+	// QRBill contains the data of the payment part of a Swiss QR-bill if there is one.
+	QRBill *QRBill
+}
+
+// ReadReceipt extracts the text of a PDF receipt and parses it. The currency is used if
+// the receipt doesn't state one.
+func ReadReceipt(path, currency string) (Receipt, error) {
+	if strings.ToLower(filepath.Ext(path)) != ".pdf" {
+		return Receipt{}, fmt.Errorf("only PDF receipts are supported")
+	}
+	text, err := ExtractText(path)
+	if err != nil {
+		return Receipt{}, fmt.Errorf("error reading %s: %s", path, err)
+	}
+	return Parse(text, currency), nil
+}
+
+// Parse returns the values found in the text of a receipt. The currency is used if the
+// text doesn't state one. Values of a QR-bill take precedence.
+func Parse(text, currency string) Receipt {
+	lines := strings.Split(text, "\n")
+	rsl := Receipt{
+		Text:     text,
+		Date:     findDate(lines),
+		Supplier: findSupplier(lines),
+		IBAN:     findIBAN(text),
+		QRBill:   ParseQRBill(text),
+	}
+	rsl.Amount = findTotal(lines, currency)
+	if qr := rsl.QRBill; qr != nil {
+		if qr.IBAN != "" {
+			rsl.IBAN = qr.IBAN
+		}
+		if qr.Creditor != "" {
+			rsl.Supplier = qr.Creditor
+		}
+		if qr.Amount.Money != nil && qr.Amount.Amount() != 0 {
+			rsl.Amount = qr.Amount
+		}
+	}
+	return rsl
+}
+
+// Reference returns the payment reference of the QR-bill, empty if there is none.
+func (r Receipt) Reference() string {
+	if r.QRBill == nil {
+		return ""
+	}
+	return r.QRBill.Reference
+}
+
+// Summary returns the found values as a single line for the interactive mode.
+func (r Receipt) Summary() string {
+	var parts []string
+	if r.Supplier != "" {
+		parts = append(parts, r.Supplier)
+	}
+	if r.Amount.Money != nil {
+		parts = append(parts, r.Amount.Display())
+	}
+	if r.Date != "" {
+		parts = append(parts, r.Date)
+	}
+	if r.IBAN != "" {
+		parts = append(parts, "IBAN "+r.IBAN)
+	}
+	if r.Reference() != "" {
+		parts = append(parts, "reference "+r.Reference())
+	}
+	if len(parts) == 0 {
+		return "no values found"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// amountRegex matches amounts with two decimal places (or .– / .-) and optional
+// thousands separators (1'200.00, 1.200,00, 1,200.00).
+var amountRegex = regexp.MustCompile(`(\d{1,3}(?:['’.,]\d{3})+|\d+)([.,])(\d{2}|[–-]{1,2})`)
+
+// amount is an amount found in the text.
+type amount struct {
+	cents int64
+	start int
+	end   int
+}
+
+// findAmounts returns all amounts of a line. Numbers which are part of a date, a
+// percentage or a longer number are ignored.
+func findAmounts(line string) []amount {
+	var rsl []amount
+	for _, match := range amountRegex.FindAllStringSubmatchIndex(line, -1) {
+		start, end := match[0], match[1]
+		if start > 0 && strings.ContainsAny(line[start-1:start], "0123456789.,") {
+			continue
+		}
+		rest := line[end:]
+		if rest != "" && (strings.ContainsAny(rest[:1], "0123456789") ||
+			(strings.ContainsAny(rest[:1], ".,") && len(rest) > 1 && strings.ContainsAny(rest[1:2], "0123456789")) ||
+			strings.HasPrefix(strings.TrimLeft(rest, " "), "%")) {
+			continue
+		}
+		units := strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' {
+				return r
+			}
+			return -1
+		}, line[match[2]:match[3]])
+		value, err := strconv.ParseInt(units, 10, 64)
+		if err != nil {
+			continue
+		}
+		cents := value * 100
+		if decimals := line[match[6]:match[7]]; decimals[0] >= '0' && decimals[0] <= '9' {
+			frac, _ := strconv.ParseInt(decimals, 10, 64)
+			cents += frac
+		}
+		rsl = append(rsl, amount{cents: cents, start: start, end: end})
+	}
+	return rsl
+}
+
+// totalKeywords mark the line with the total amount. subtotalKeywords mark lines which
+// contain one of the keywords but not the total.
+var (
+	totalKeywords = []string{
+		"total", "gesamt", "summe", "zu bezahlen", "zu zahlen", "zahlbetrag", "betrag",
+		"endbetrag", "montant", "à payer", "a payer", "amount", "balance due", "importo", "totale",
+	}
+	subtotalKeywords = []string{
+		"subtotal", "sub-total", "sub total", "zwischensumme", "zwischentotal", "sous-total",
+		"netto", "exkl", "excl", "ohne mwst", "hors taxe", "mwst-betrag", "steuerbetrag",
+	}
+)
+
+// findTotal returns the total amount. The largest amount on a line with a total keyword
+// is used, if there is none the largest amount of the receipt.
+func findTotal(lines []string, currency string) util.Money {
+	var best, largest *amount
+	bestLine, largestLine := "", ""
+	for i, line := range lines {
+		lower := strings.ToLower(line)
+		amounts := findAmounts(line)
+		for j := range amounts {
+			if largest == nil || amounts[j].cents > largest.cents {
+				largest, largestLine = &amounts[j], line
+			}
+		}
+		if !containsAny(lower, totalKeywords) || containsAny(lower, subtotalKeywords) {
+			continue
+		}
+		// The amount may be on the following line (ex: in a table).
+		candidate := line
+		if len(amounts) == 0 && i+1 < len(lines) {
+			candidate = lines[i+1]
+			amounts = findAmounts(candidate)
+		}
+		for j := range amounts {
+			if best == nil || amounts[j].cents > best.cents {
+				best, bestLine = &amounts[j], candidate
+			}
+		}
+	}
+	if best == nil {
+		best, bestLine = largest, largestLine
+	}
+	if best == nil {
+		return util.Money{}
+	}
+	if code := findCurrency(bestLine); code != "" {
+		currency = code
+	} else if code := findCurrency(strings.Join(lines, "\n")); code != "" {
+		currency = code
+	}
+	return util.NewMoney(best.cents, currency)
+}
+
+// currencyRegex matches currency codes and symbols.
+var currencyRegex = regexp.MustCompile(`\b(CHF|EUR|USD|GBP|SFr|Fr)\b|[€$£]`)
+
+// findCurrency returns the ISO code of the first currency in the text.
+func findCurrency(text string) string {
+	match := currencyRegex.FindString(text)
+	switch match {
+	case "":
+		return ""
+	case "SFr", "Fr":
+		return "CHF"
+	case "€":
+		return "EUR"
+	case "$":
+		return "USD"
+	case "£":
+		return "GBP"
+	}
+	return match
+}
+
+// months maps German, English and French month names and abbreviations to their number.
+var months = map[string]int{
+	"januar": 1, "january": 1, "janvier": 1, "jan": 1, "jänner": 1,
+	"februar": 2, "february": 2, "février": 2, "feb": 2, "fév": 2, "févr": 2,
+	"märz": 3, "march": 3, "mars": 3, "mar": 3, "mär": 3,
+	"april": 4, "avril": 4, "apr": 4, "avr": 4,
+	"mai": 5, "may": 5,
+	"juni": 6, "june": 6, "juin": 6, "jun": 6,
+	"juli": 7, "july": 7, "juillet": 7, "jul": 7, "juil": 7,
+	"august": 8, "août": 8, "aug": 8,
+	"september": 9, "septembre": 9, "sep": 9, "sept": 9,
+	"oktober": 10, "october": 10, "octobre": 10, "okt": 10, "oct": 10,
+	"november": 11, "novembre": 11, "nov": 11,
+	"dezember": 12, "december": 12, "décembre": 12, "dez": 12, "dec": 12, "déc": 12,
+}
+
+var (
+	numericDateRegex = regexp.MustCompile(`(?:^|[^\d.])(\d{1,2})[./](\d{1,2})[./](\d{4}|\d{2})(?:[^\d]|$)`)
+	isoDateRegex     = regexp.MustCompile(`(?:^|[^\d])(\d{4})-(\d{2})-(\d{2})(?:[^\d]|$)`)
+	nameDateRegex    = regexp.MustCompile(`(?i)(\d{1,2})\.?\s+([a-zäéû]{3,9})\.?\s+(\d{4})`)
+	nameFirstRegex   = regexp.MustCompile(`(?i)([a-zäéû]{3,9})\.?\s+(\d{1,2}),?\s+(\d{4})`)
+)
+
+// dateKeywords mark the line with the date of the receipt, dueKeywords lines with other
+// dates.
+var (
+	dateKeywords = []string{"datum", "date", "data", "ausgestellt", "kaufdatum"}
+	dueKeywords  = []string{"fällig", "zahlbar bis", "due", "échéance", "scadenza", "gültig", "valid", "lieferdatum"}
+)
+
+// findDate returns the date of the receipt. Dates on lines with a date keyword are
+// preferred, otherwise the first date of the receipt is used.
+func findDate(lines []string) string {
+	first := ""
+	for _, line := range lines {
+		lower := strings.ToLower(line)
+		if containsAny(lower, dueKeywords) {
+			continue
+		}
+		date := lineDate(line)
+		if date == "" {
+			continue
+		}
+		if containsAny(lower, dateKeywords) {
+			return date
+		}
+		if first == "" {
+			first = date
+		}
+	}
+	return first
+}
+
+// lineDate returns the first valid date of a line in the YYYY-MM-DD format.
+func lineDate(line string) string {
+	for _, match := range isoDateRegex.FindAllStringSubmatch(line, -1) {
+		if date := validDate(match[1], match[2], match[3]); date != "" {
+			return date
+		}
+	}
+	for _, match := range numericDateRegex.FindAllStringSubmatch(line, -1) {
+		if date := validDate(match[3], match[2], match[1]); date != "" {
+			return date
+		}
+		// US notation (MM/DD/YYYY) if the day comes first can't be valid.
+		if date := validDate(match[3], match[1], match[2]); date != "" {
+			return date
+		}
+	}
+	for _, match := range nameDateRegex.FindAllStringSubmatch(line, -1) {
+		if month, ok := months[strings.ToLower(match[2])]; ok {
+			if date := validDate(match[3], strconv.Itoa(month), match[1]); date != "" {
+				return date
+			}
+		}
+	}
+	for _, match := range nameFirstRegex.FindAllStringSubmatch(line, -1) {
+		if month, ok := months[strings.ToLower(match[1])]; ok {
+			if date := validDate(match[3], strconv.Itoa(month), match[2]); date != "" {
+				return date
+			}
+		}
+	}
+	return ""
+}
+
+// validDate returns the date in the YYYY-MM-DD format, two digit years are in this
+// century. An empty string is returned for invalid dates.
+func validDate(year, month, day string) string {
+	if len(year) == 2 {
+		year = "20" + year
+	}
+	y, _ := strconv.Atoi(year)
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	if y < 1990 || y > 2100 {
+		return ""
+	}
+	date := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if date.Year() != y || int(date.Month()) != m || date.Day() != d {
+		return ""
+	}
+	return date.Format(util.DateFormat)
+}
+
+// documentTitles are lines at the top of a receipt which aren't the supplier.
+var documentTitles = []string{
+	"rechnung", "quittung", "beleg", "kassenbon", "kassenzettel", "receipt", "invoice",
+	"facture", "quittance", "fattura", "ricevuta", "bestellung", "order", "seite", "page",
+}
+
+// findSupplier returns the first line which looks like a name: contains letters, no
+// amount, date, contact detail or document title.
+func findSupplier(lines []string) string {
+	for i, line := range lines {
+		if i > 10 {
+			break
+		}
+		lower := strings.ToLower(line)
+		if len([]rune(line)) < 3 || !strings.ContainsAny(lower, "abcdefghijklmnopqrstuvwxyzäöüéèà") {
+			continue
+		}
+		if len(findAmounts(line)) != 0 || lineDate(line) != "" || strings.ContainsAny(line, "@:") {
+			continue
+		}
+		if strings.HasPrefix(lower, "www.") || strings.HasPrefix(lower, "http") || containsAny(lower, documentTitles) {
+			continue
+		}
+		return line
+	}
+	return ""
+}
+
+// ibanRegex matches possible IBANs, the candidates are checked with findIBAN.
+var ibanRegex = regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]){11,30}`)
+
+// ibanLengths contains the length of the IBANs of common countries.
+var ibanLengths = map[string]int{
+	"AT": 20, "BE": 16, "CH": 21, "DE": 22, "DK": 18, "ES": 24, "FR": 27, "GB": 22,
+	"IE": 22, "IT": 27, "LI": 21, "LU": 20, "NL": 18, "NO": 15, "PL": 28, "PT": 25, "SE": 24,
+}
+
+// findIBAN returns the first valid IBAN in the text without spaces.
+func findIBAN(text string) string {
+	for _, match := range ibanRegex.FindAllString(text, -1) {
+		compact := strings.Replace(match, " ", "", -1)
+		if length, ok := ibanLengths[compact[:2]]; ok {
+			if len(compact) >= length && ValidIBAN(compact[:length]) {
+				return compact[:length]
+			}
+			continue
+		}
+		for length := len(compact); length >= 15; length-- {
+			if ValidIBAN(compact[:length]) {
+				return compact[:length]
+			}
+		}
+	}
+	return ""
+}
+
+// ValidIBAN checks the checksum of an IBAN. Spaces are ignored.
+func ValidIBAN(iban string) bool {
+	iban = strings.ToUpper(strings.Replace(iban, " ", "", -1))
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+	var digits strings.Builder
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r >= 'A' && r <= 'Z':
+			digits.WriteString(strconv.Itoa(int(r-'A') + 10))
+		default:
+			return false
+		}
+	}
+	value, ok := new(big.Int).SetString(digits.String(), 10)
+	if !ok {
+		return false
+	}
+	return new(big.Int).Mod(value, big.NewInt(97)).Int64() == 1
+}
+
+func containsAny(text string, keywords []string) bool {
+	for i := range keywords {
+		if strings.Contains(text, keywords[i]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package receipt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/72nd/acc/pkg/document/utils"
+	"github.com/signintech/gopdf"
+)
+
+// simplePdf uses a standard font with WinAnsiEncoding and Differences, the positioning
+// operators and a TJ array with kerning.
+const simplePdf = `%PDF-1.4
+1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj
+2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /Font << /F1 4 0 R >> >> >> endobj
+3 0 obj << /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Contents 5 0 R >> endobj
+4 0 obj << /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding << /Differences [128 /Euro] >> >> endobj
+5 0 obj << /Length 0 >>
+stream
+BT /F1 12 Tf 14 TL 50 780 Td (B\344ckerei M\374ller) Tj T* [(Datum: 03.02.2020)] TJ
+0 -28 Td (Brot) Tj 200 0 Td (4.50) Tj -200 -14 Td [(To) 80 (tal)] TJ 200 0 Td (\200 12.80) Tj ET
+endstream
+endobj
+trailer << /Root 1 0 R >>
+%%EOF`
+
+func TestExtractText(t *testing.T) {
+	text, err := extractText([]byte(simplePdf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "Bäckerei Müller\nDatum: 03.02.2020\nBrot 4.50\nTotal € 12.80"
+	if text != expected {
+		t.Errorf("expected %q, got %q", expected, text)
+	}
+	for _, length := range []string{"-40", "1e30"} {
+		broken := strings.Replace(simplePdf, "/Length 0", "/Length "+length, 1)
+		if text, err := extractText([]byte(broken)); err != nil || text != expected {
+			t.Errorf("stream with length %s: got %q (%v)", length, text, err)
+		}
+	}
+
+	// gopdf embeds the font as a compressed CID font with a ToUnicode CMap.
+	pdf := gopdf.GoPdf{}
+	pdf.Start(gopdf.Config{PageSize: *gopdf.PageSizeA4, Unit: gopdf.Unit_MM})
+	if err := pdf.AddTTFFontByReaderWithOption("lato", bytes.NewBuffer(utils.LatoRegular()), gopdf.TtfOption{UseKerning: true}); err != nil {
+		t.Fatal(err)
+	}
+	pdf.AddPage()
+	if err := pdf.SetFont("lato", "", 11); err != nil {
+		t.Fatal(err)
+	}
+	for i, line := range []string{"Brocki Bern AG", "Total", "IBAN CH93 0076 2011 6238 5295 7"} {
+		pdf.SetX(20)
+		pdf.SetY(float64(20 + i*7))
+		pdf.Cell(nil, line)
+	}
+	pdf.SetX(120)
+	pdf.SetY(27)
+	pdf.Cell(nil, "CHF 1'120.50")
+	dir, err := ioutil.TempDir("", "acc-receipt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "receipt.pdf")
+	if err := pdf.WritePdf(path); err != nil {
+		t.Fatal(err)
+	}
+	rcp, err := ReadReceipt(path, "EUR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rcp.Supplier != "Brocki Bern AG" || rcp.Amount.Value() != "1120.50 CHF" || rcp.IBAN != "CH9300762011623852957" {
+		t.Errorf("unexpected receipt %s from text %q", rcp.Summary(), rcp.Text)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text     string
+		amount   string
+		date     string
+		supplier string
+		iban     string
+	}{
+		{
+			text:     "Quittung\nCafé Fédéral\nBundesplatz 1, 3011 Bern\n14.03.20 12:31\n2 Espresso 9.00\nMwSt 7.7% 0.84\nTotal CHF 11.80\nBar 20.00",
+			amount:   "11.80 CHF",
+			date:     "2020-03-14",
+			supplier: "Café Fédéral",
+		},
+		{
+			text:     "Papeterie Dupont SA\nFacture n° 2020-118\nDate : 5 février 2020\nÉchéance : 06.03.2020\nSous-total 1.250,00\nMontant à payer EUR 1.346,25",
+			amount:   "1346.25 EUR",
+			date:     "2020-02-05",
+			supplier: "Papeterie Dupont SA",
+		},
+		{
+			text:     "Invoice\nHosting Ltd.\nInvoice date: March 3, 2020\nServer 120.00\nAmount due 120.00\nPay to CH44 3199 9123 0008 8901 2",
+			amount:   "120.00 CHF",
+			date:     "2020-03-03",
+			supplier: "Hosting Ltd.",
+			iban:     "CH4431999123000889012",
+		},
+		{
+			text: "Verein Theater\nEmpfangsschein Zahlteil\nKonto / Zahlbar an Konto / Zahlbar an\nCH44 3199 9123 0008 8901 2 CH44 3199 9123 0008 8901 2\n" +
+				"Robert Schneider AG Robert Schneider AG\nReferenz Referenz\n21 00000 00003 13947 14300 09017 21 00000 00003 13947 14300 09017\n" +
+				"Währung Betrag Währung Betrag\nCHF 1 949.75 CHF 1 949.75",
+			amount:   "1949.75 CHF",
+			supplier: "Robert Schneider AG",
+			iban:     "CH4431999123000889012",
+		},
+	}
+	for i, test := range tests {
+		rcp := Parse(test.text, "CHF")
+		if rcp.Amount.Money == nil || rcp.Amount.Value() != test.amount {
+			t.Errorf("%d: expected amount %s, got %s", i, test.amount, rcp.Summary())
+		}
+		if rcp.Date != test.date || rcp.Supplier != test.supplier || rcp.IBAN != test.iban {
+			t.Errorf("%d: expected %s/%s/%s, got %s", i, test.date, test.supplier, test.iban, rcp.Summary())
+		}
+	}
+
+	payload := strings.Join([]string{"SPC", "0200", "1", "CH4431999123000889012", "S", "Robert Schneider AG", "Rue du Lac", "1268", "2501", "Biel", "CH",
+		"", "", "", "", "", "", "", "1949.75", "CHF", "S", "Pia-Maria Rutschmann-Schnyder", "Grosse Marktgasse", "28", "9400", "Rorschach", "CH",
+		"QRR", "210000000003139471430009017", "Order of 15 June 2020", "EPD"}, "\n")
+	qr := ParseQRBill(payload)
+	if qr == nil || qr.Creditor != "Robert Schneider AG" || qr.Amount.Value() != "1949.75 CHF" || qr.ReferenceType != "QRR" || qr.Message != "Order of 15 June 2020" {
+		t.Errorf("unexpected QR-bill %+v", qr)
+	}
+}
@@ -0,0 +1,353 @@
+package receipt
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// matrix is a PDF transformation matrix [a b c d e f].
+type matrix [6]float64
+
+var identity = matrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns m × n.
+func (m matrix) multiply(n matrix) matrix {
+	return matrix{
+		m[0]*n[0] + m[1]*n[2],
+		m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2],
+		m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4],
+		m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// positioned is a glyph placed on the page.
+type positioned struct {
+	text string
+	x    float64
+	endX float64
+	y    float64
+	size float64
+}
+
+// textState contains the parameters of the text state and the graphics state relevant
+// for the text extraction.
+type textState struct {
+	ctm       matrix
+	font      *font
+	fontSize  float64
+	charSpace float64
+	wordSpace float64
+	scale     float64
+	leading   float64
+	rise      float64
+}
+
+// interpreter runs content streams and collects the shown glyphs.
+type interpreter struct {
+	doc    *document
+	fonts  map[interface{}]*font
+	glyphs []positioned
+}
+
+// ExtractText returns the text of all pages of a PDF file. The text is reconstructed
+// line by line from the position of the glyphs.
+func ExtractText(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return extractText(data)
+}
+
+func extractText(data []byte) (string, error) {
+	doc, err := parseDocument(data)
+	if err != nil {
+		return "", err
+	}
+	var pages []string
+	for _, page := range doc.pages() {
+		in := &interpreter{doc: doc, fonts: make(map[interface{}]*font)}
+		resources, _ := doc.inherited(page, "Resources").(dict)
+		in.run(doc.contents(page), resources, textState{ctm: identity, scale: 1}, 0)
+		if text := layout(in.glyphs); text != "" {
+			pages = append(pages, text)
+		}
+	}
+	if len(pages) == 0 {
+		return "", fmt.Errorf("PDF contains no text layer")
+	}
+	return strings.Join(pages, "\n"), nil
+}
+
+// run interprets a content stream.
+func (in *interpreter) run(content []byte, resources dict, state textState, depth int) {
+	if depth > 8 {
+		return
+	}
+	var stack []textState
+	var tm, tlm matrix
+	var operands []interface{}
+	l := &lexer{data: content}
+	number := func(i int) float64 {
+		if i < len(operands) {
+			if value, ok := operands[i].(float64); ok {
+				return value
+			}
+		}
+		return 0
+	}
+	newLine := func(tx, ty float64) {
+		tlm = matrix{1, 0, 0, 1, tx, ty}.multiply(tlm)
+		tm = tlm
+	}
+	for {
+		obj := l.object()
+		if obj == nil && l.pos >= len(l.data) {
+			return
+		}
+		op, ok := obj.(keyword)
+		if !ok || op == "[" || op == "]" || op == "<<" || op == ">>" {
+			operands = append(operands, obj)
+			continue
+		}
+		switch op {
+		case "q":
+			stack = append(stack, state)
+		case "Q":
+			if len(stack) != 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(operands) == 6 {
+				state.ctm = matrix{number(0), number(1), number(2), number(3), number(4), number(5)}.multiply(state.ctm)
+			}
+		case "BT":
+			tm, tlm = identity, identity
+		case "Tf":
+			if len(operands) == 2 {
+				state.font = in.font(resources, operands[0])
+				state.fontSize = number(1)
+			}
+		case "Tc":
+			state.charSpace = number(0)
+		case "Tw":
+			state.wordSpace = number(0)
+		case "Tz":
+			state.scale = number(0) / 100
+		case "TL":
+			state.leading = number(0)
+		case "Ts":
+			state.rise = number(0)
+		case "Td":
+			newLine(number(0), number(1))
+		case "TD":
+			state.leading = -number(1)
+			newLine(number(0), number(1))
+		case "Tm":
+			if len(operands) == 6 {
+				tlm = matrix{number(0), number(1), number(2), number(3), number(4), number(5)}
+				tm = tlm
+			}
+		case "T*":
+			newLine(0, -state.leading)
+		case "Tj":
+			if len(operands) == 1 {
+				in.show(&tm, state, operands[0])
+			}
+		case "'":
+			newLine(0, -state.leading)
+			if len(operands) == 1 {
+				in.show(&tm, state, operands[0])
+			}
+		case "\"":
+			if len(operands) == 3 {
+				state.wordSpace = number(0)
+				state.charSpace = number(1)
+				newLine(0, -state.leading)
+				in.show(&tm, state, operands[2])
+			}
+		case "TJ":
+			if len(operands) == 1 {
+				in.show(&tm, state, operands[0])
+			}
+		case "Do":
+			if len(operands) == 1 {
+				in.form(resources, operands[0], state, depth)
+			}
+		case "BI":
+			skipInlineImage(l)
+		}
+		operands = operands[:0]
+	}
+}
+
+// skipInlineImage moves the lexer behind the data of an inline image (BI … ID … EI).
+func skipInlineImage(l *lexer) {
+	for {
+		obj := l.token()
+		if obj == nil || obj == keyword("ID") {
+			break
+		}
+	}
+	for l.pos < len(l.data) {
+		i := bytes.Index(l.data[l.pos:], []byte("EI"))
+		if i == -1 {
+			l.pos = len(l.data)
+			return
+		}
+		end := l.pos + i
+		l.pos = end + 2
+		if end > 0 && isSpace(l.data[end-1]) && (l.pos >= len(l.data) || isSpace(l.data[l.pos])) {
+			return
+		}
+	}
+}
+
+// font returns the font with the given resource name.
+func (in *interpreter) font(resources dict, fontName interface{}) *font {
+	fonts := in.doc.dict(resources, "Font")
+	key, _ := fontName.(name)
+	obj := fonts[key]
+	cacheKey := obj
+	if _, ok := obj.(ref); !ok {
+		cacheKey = key
+	}
+	if f, ok := in.fonts[cacheKey]; ok {
+		return f
+	}
+	fd, _ := in.doc.resolve(obj).(dict)
+	f := in.doc.newFont(fd)
+	in.fonts[cacheKey] = f
+	return f
+}
+
+// form runs the content of a form XObject.
+func (in *interpreter) form(resources dict, xName interface{}, state textState, depth int) {
+	key, _ := xName.(name)
+	stm, ok := in.doc.resolve(in.doc.dict(resources, "XObject")[key]).(stream)
+	if !ok || stm.dict["Subtype"] != name("Form") {
+		return
+	}
+	data, err := in.doc.decode(stm)
+	if err != nil {
+		return
+	}
+	if m, ok := in.doc.resolve(stm.dict["Matrix"]).(array); ok && len(m) == 6 {
+		var fm matrix
+		for i := range m {
+			fm[i], _ = in.doc.resolve(m[i]).(float64)
+		}
+		state.ctm = fm.multiply(state.ctm)
+	}
+	if res, ok := in.doc.resolve(stm.dict["Resources"]).(dict); ok {
+		resources = res
+	}
+	in.run(data, resources, state, depth+1)
+}
+
+// show places the glyphs of a string (Tj) or an array of strings and adjustments (TJ)
+// and advances the text matrix.
+func (in *interpreter) show(tm *matrix, state textState, obj interface{}) {
+	if state.font == nil {
+		state.font = in.doc.newFont(nil)
+	}
+	var items array
+	switch value := obj.(type) {
+	case string:
+		items = array{value}
+	case array:
+		items = value
+	default:
+		return
+	}
+	for _, item := range items {
+		switch value := item.(type) {
+		case float64:
+			*tm = matrix{1, 0, 0, 1, -value / 1000 * state.fontSize * state.scale, 0}.multiply(*tm)
+		case string:
+			for _, g := range state.font.glyphs(value) {
+				trm := matrix{state.fontSize * state.scale, 0, 0, state.fontSize, 0, state.rise}.multiply(*tm).multiply(state.ctm)
+				advance := g.width/1000*state.fontSize + state.charSpace
+				if g.space {
+					advance += state.wordSpace
+				}
+				*tm = matrix{1, 0, 0, 1, advance * state.scale, 0}.multiply(*tm)
+				end := matrix{1, 0, 0, 1, 0, state.rise}.multiply(*tm).multiply(state.ctm)
+				in.glyphs = append(in.glyphs, positioned{
+					text: g.text,
+					x:    trm[4],
+					endX: end[4],
+					y:    trm[5],
+					size: math.Hypot(trm[2], trm[3]),
+				})
+			}
+		}
+	}
+}
+
+// layout groups the glyphs into lines from top to bottom and joins them from left to
+// right. Gaps between glyphs are replaced by spaces.
+func layout(glyphs []positioned) string {
+	var filtered []positioned
+	for _, g := range glyphs {
+		g.text = strings.Map(func(r rune) rune {
+			if r == unicode.ReplacementChar || (unicode.IsControl(r) && r != '\t') {
+				return -1
+			}
+			if unicode.IsSpace(r) {
+				return ' '
+			}
+			return r
+		}, g.text)
+		if g.text != "" {
+			if g.size <= 0 {
+				g.size = 1
+			}
+			filtered = append(filtered, g)
+		}
+	}
+	sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].y > filtered[j].y })
+
+	var lines [][]positioned
+	for _, g := range filtered {
+		n := len(lines)
+		if n != 0 && math.Abs(lines[n-1][0].y-g.y) < math.Max(lines[n-1][0].size, g.size)*0.4 {
+			lines[n-1] = append(lines[n-1], g)
+			continue
+		}
+		lines = append(lines, []positioned{g})
+	}
+
+	var rsl []string
+	for _, line := range lines {
+		sort.SliceStable(line, func(i, j int) bool { return line[i].x < line[j].x })
+		var text strings.Builder
+		var last *positioned
+		for i := range line {
+			g := line[i]
+			if last != nil {
+				// Skip glyphs printed twice at the same position (faked bold).
+				if g.text == last.text && math.Abs(g.x-last.x) < g.size*0.1 {
+					continue
+				}
+				if g.x-last.endX > g.size*0.15 && !strings.HasSuffix(text.String(), " ") && !strings.HasPrefix(g.text, " ") {
+					text.WriteString(" ")
+				}
+			}
+			text.WriteString(g.text)
+			last = &line[i]
+		}
+		if trimmed := strings.Join(strings.Fields(text.String()), " "); trimmed != "" {
+			rsl = append(rsl, trimmed)
+		}
+	}
+	return strings.Join(rsl, "\n")
+}
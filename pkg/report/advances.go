@@ -0,0 +1,135 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+// EmployeeAdvances returns for each employee the expenses advanced with private money.
+// Open items are not reimbursed yet, settled ones are listed with the date and the
+// transaction of the reimbursement. The balance is the amount the company still owes
+// the employee. Balances are summed per currency, expenses without an amount are listed
+// but not summed.
+func EmployeeAdvances(s schema.Schema) Table {
+	tbl := Table{
+		Title:    "Employee Advances",
+		Subtitle: fmt.Sprintf("as of %s", displayDate(time.Now())),
+		Header:   []string{"Expense", "Date", "Amount", "Reimbursed", "Open"},
+	}
+	var total currencySums
+	for i := range s.Parties.Employees {
+		emp := s.Parties.Employees[i]
+		exp := s.Expenses.AdvancedBy(emp)
+		if len(exp) == 0 {
+			continue
+		}
+		tbl.AddEmphasizedRow(fmt.Sprintf("%s (%s)", emp.Name, emp.Identifier), "", "", "", "")
+		var balance currencySums
+		for k := range exp {
+			open := ""
+			if !exp[k].IsSettled() && balance.add(exp[k].Amount) {
+				total.add(exp[k].Amount)
+				open = displayMoney(exp[k].Amount)
+			}
+			tbl.AddRow(
+				exp[k].Short(),
+				displayDate(exp[k].AccrualDateTime()),
+				displayMoney(exp[k].Amount),
+				reimbursement(s, exp[k]),
+				open)
+		}
+		balance.each(s.Currency, func(currency string, amount int64) {
+			tbl.AddEmphasizedRow("Balance", "", "", "", displayAmount(amount, currency))
+		})
+		tbl.AddRow(emptyRow(len(tbl.Header))...)
+	}
+	total.each(s.Currency, func(currency string, amount int64) {
+		tbl.AddEmphasizedRow("Total owed to employees", "", "", "", displayAmount(amount, currency))
+	})
+	return tbl
+}
+
+// ReimbursementStatement returns the list of the open advances of the given employee.
+func ReimbursementStatement(s schema.Schema, emp schema.Party) Table {
+	tbl := Table{
+		Title:    "Reimbursement Statement",
+		Subtitle: fmt.Sprintf("open advances of %s (%s) as of %s", emp.Name, emp.Identifier, displayDate(time.Now())),
+		Header:   []string{"Expense", "Date", "Amount"},
+	}
+	var balance currencySums
+	exp := s.Expenses.AdvancedBy(emp)
+	for i := range exp {
+		if exp[i].IsSettled() {
+			continue
+		}
+		balance.add(exp[i].Amount)
+		tbl.AddRow(
+			exp[i].Short(),
+			displayDate(exp[i].AccrualDateTime()),
+			displayMoney(exp[i].Amount))
+	}
+	tbl.AddRow(emptyRow(len(tbl.Header))...)
+	balance.each(s.Currency, func(currency string, amount int64) {
+		tbl.AddEmphasizedRow("Amount to be reimbursed", "", displayAmount(amount, currency))
+	})
+	return tbl
+}
+
+// reimbursement describes the settlement of a advanced expense.
+func reimbursement(s schema.Schema, exp schema.Expense) string {
+	if !exp.IsSettled() {
+		return ""
+	}
+	trn, err := s.Statement.TransactionByRef(exp.SettlementTransaction)
+	if err != nil {
+		return exp.DateOfSettlement
+	}
+	return fmt.Sprintf("%s (%s)", displayDate(trn.DateTime()), trn.Identifier)
+}
+
+// displayMoney displays the given amount in its own currency, a missing amount is marked
+// as such.
+func displayMoney(m util.Money) string {
+	if m.Money == nil {
+		return "missing amount"
+	}
+	return displayAmount(m.Amount(), m.Currency().Code)
+}
+
+// currencySums sums up amounts separately for each currency.
+type currencySums struct {
+	codes   []string
+	amounts map[string]int64
+}
+
+// add adds the given amount to the sum of its currency. Returns false if the amount is
+// missing and thus wasn't added.
+func (c *currencySums) add(m util.Money) bool {
+	if m.Money == nil {
+		return false
+	}
+	code := m.Currency().Code
+	if c.amounts == nil {
+		c.amounts = make(map[string]int64)
+	}
+	if _, ok := c.amounts[code]; !ok {
+		c.codes = append(c.codes, code)
+	}
+	c.amounts[code] += m.Amount()
+	return true
+}
+
+// each calls fn for every currency in the order of their first occurrence. If nothing
+// was added fn is called once with zero in the given fallback currency.
+func (c currencySums) each(fallback string, fn func(currency string, amount int64)) {
+	if len(c.codes) == 0 {
+		fn(fallback, 0)
+		return
+	}
+	for i := range c.codes {
+		fn(c.codes[i], c.amounts[c.codes[i]])
+	}
+}
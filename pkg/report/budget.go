@@ -0,0 +1,75 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/72nd/acc/pkg/schema"
+)
+
+// ProjectBudget returns the spent and invoiced amounts of a project per expense category.
+// If budget is true the planned and remaining amounts of the budget are added. The
+// invoiced amount of a category is the part of the expenses re-invoiced to the customer,
+// the total amount of the invoices sent to the customer is stated below the table.
+func ProjectBudget(s schema.Schema, prj schema.Project, budget bool) Table {
+	tbl := Table{
+		Title:    "Project Report",
+		Subtitle: prj.Short(),
+		Header:   []string{"Category", "Spent", "Invoiced"},
+	}
+	if budget {
+		tbl.Title = "Project Budget"
+		tbl.Header = []string{"Category", "Planned", "Spent", "Invoiced", "Remaining"}
+	}
+	var total schema.BudgetUsage
+	usage := prj.BudgetUsage(s)
+	for i := range usage {
+		total.Planned += usage[i].Planned
+		total.Spent += usage[i].Spent
+		total.Invoiced += usage[i].Invoiced
+		tbl.AddRow(budgetRow(s, usage[i], categoryName(usage[i].ExpenseCategory), budget)...)
+	}
+	tbl.AddRow(emptyRow(len(tbl.Header))...)
+	tbl.AddEmphasizedRow(budgetRow(s, total, "Total", budget)...)
+	invoices := emptyRow(len(tbl.Header))
+	invoices[0] = "Invoices to customer"
+	invoices[indexOf(tbl.Header, "Invoiced")] = displayAmount(prj.InvoicedAmount(s), s.Currency)
+	tbl.AddRow(invoices...)
+	return tbl
+}
+
+func budgetRow(s schema.Schema, usage schema.BudgetUsage, name string, budget bool) []string {
+	if !budget {
+		return []string{
+			name,
+			displayAmount(usage.Spent, s.Currency),
+			displayAmount(usage.Invoiced, s.Currency),
+		}
+	}
+	remaining := displayAmount(usage.Remaining(), s.Currency)
+	if usage.Exceeded() {
+		remaining = fmt.Sprintf("%s (over)", remaining)
+	}
+	return []string{
+		name,
+		displayAmount(usage.Planned, s.Currency),
+		displayAmount(usage.Spent, s.Currency),
+		displayAmount(usage.Invoiced, s.Currency),
+		remaining,
+	}
+}
+
+func categoryName(category string) string {
+	if category == "" {
+		return "(no category)"
+	}
+	return category
+}
+
+func indexOf(values []string, value string) int {
+	for i := range values {
+		if values[i] == value {
+			return i
+		}
+	}
+	return -1
+}
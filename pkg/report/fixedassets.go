@@ -0,0 +1,77 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/sirupsen/logrus"
+)
+
+// FixedAssetSchedule returns the depreciation schedule of all fixed assets for the given
+// year. Assets acquired after the year are omitted, fully depreciated assets are listed
+// with their residual value.
+func FixedAssetSchedule(s schema.Schema, year int) Table {
+	tbl := Table{
+		Title:    "Fixed Asset Schedule",
+		Subtitle: fmt.Sprintf("depreciation for the year %d", year),
+		Header:   []string{"Asset", "Acquired", "Cost", "Method", "Opening value", "Depreciation", "Closing value"},
+	}
+	var totals [4]int64
+	for i := range s.FixedAssets {
+		fas := s.FixedAssets[i]
+		date, err := fas.AcquisitionDate(s)
+		if err != nil {
+			logrus.Warn(err)
+			continue
+		}
+		if date.Year() > year {
+			continue
+		}
+		cost, _ := fas.AcquisitionCost(s)
+		schedule, err := fas.Schedule(s)
+		if err != nil {
+			logrus.Warn(err)
+			continue
+		}
+		line := scheduleLine(schedule, year)
+		method := fmt.Sprintf("%s, %d years", fas.Method, fas.UsefulLife)
+		tbl.AddRow(
+			fas.Short(),
+			displayDate(date),
+			displayAmount(cost.Amount(), s.Currency),
+			method,
+			displayAmount(line.OpeningValue, s.Currency),
+			displayAmount(line.Depreciation, s.Currency),
+			displayAmount(line.ClosingValue, s.Currency))
+		totals[0] += cost.Amount()
+		totals[1] += line.OpeningValue
+		totals[2] += line.Depreciation
+		totals[3] += line.ClosingValue
+	}
+	tbl.AddRow(emptyRow(len(tbl.Header))...)
+	tbl.AddEmphasizedRow(
+		"Total",
+		"",
+		displayAmount(totals[0], s.Currency),
+		"",
+		displayAmount(totals[1], s.Currency),
+		displayAmount(totals[2], s.Currency),
+		displayAmount(totals[3], s.Currency))
+	return tbl
+}
+
+// scheduleLine returns the line of the given year. After the end of the useful life the
+// book value stays at the closing value of the last year.
+func scheduleLine(schedule []schema.DepreciationYear, year int) schema.DepreciationYear {
+	for i := range schedule {
+		if schedule[i].Year == year {
+			return schedule[i]
+		}
+	}
+	last := schedule[len(schedule)-1]
+	return schema.DepreciationYear{
+		Year:         year,
+		OpeningValue: last.ClosingValue,
+		ClosingValue: last.ClosingValue,
+	}
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"fmt"
+
+	"github.com/72nd/acc/pkg/schema"
+)
+
+// SalaryCertificates returns the yearly summary of the salaries per employee with the
+// figures needed for the Swiss salary certificate (Lohnausweis). The column titles refer
+// to the numbers of the official form.
+func SalaryCertificates(s schema.Schema, year int) Table {
+	tbl := Table{
+		Title:    "Salary Certificates (Lohnausweis)",
+		Subtitle: fmt.Sprintf("salaries paid for the year %d", year),
+		Header:   []string{"Employee", "Months", "8. Gross salary", "9. AHV/IV/EO/ALV/NBUV", "10.1 BVG", "11. Net salary"},
+	}
+	var totals [4]int64
+	salaries := s.FilterYear(year).Salaries
+	for i := range s.Parties.Employees {
+		emp := s.Parties.Employees[i]
+		sal := salaries.ByEmployee(emp)
+		if len(sal) == 0 {
+			continue
+		}
+		var line [4]int64
+		months := 0
+		for k := range sal {
+			// Salaries without gross amount are reported by the validation.
+			if sal[k].Gross.Money == nil {
+				continue
+			}
+			months++
+			line[0] += sal[k].Gross.Amount()
+			line[1] += sal[k].DeductionAmount(schema.AHVDeduction) + sal[k].DeductionAmount(schema.ALVDeduction) + sal[k].DeductionAmount(schema.UVGDeduction)
+			line[2] += sal[k].DeductionAmount(schema.BVGDeduction)
+			line[3] += sal[k].NetAmount()
+		}
+		tbl.AddRow(
+			fmt.Sprintf("%s (%s)", emp.Name, emp.Identifier),
+			fmt.Sprint(months),
+			displayAmount(line[0], s.Currency),
+			displayAmount(line[1], s.Currency),
+			displayAmount(line[2], s.Currency),
+			displayAmount(line[3], s.Currency))
+		for k := range totals {
+			totals[k] += line[k]
+		}
+	}
+	tbl.AddRow(emptyRow(len(tbl.Header))...)
+	tbl.AddEmphasizedRow(
+		"Total",
+		"",
+		displayAmount(totals[0], s.Currency),
+		displayAmount(totals[1], s.Currency),
+		displayAmount(totals[2], s.Currency),
+		displayAmount(totals[3], s.Currency))
+	return tbl
+}
@@ -0,0 +1,122 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/sirupsen/logrus"
+)
+
+// ProjectProfitability returns the revenue (invoices), the direct expenses, the billable
+// expenses (already re-invoiced or still to re-invoice) and the margin per project.
+// Expenses and invoices without a project are summed up in a separate line. Only records
+// between from and to (optional) are used, this includes the credit notes which reduce the
+// revenue. Records without an amount count as zero. The schema doesn't record working time (salaries
+// aren't assigned to projects), thus the report contains no time spent.
+func ProjectProfitability(s schema.Schema, from *time.Time, to *time.Time) Table {
+	tbl := Table{
+		Title:    "Project Profitability",
+		Subtitle: periodName(from, to),
+		Header:   []string{"Project", "Customer", "Revenue", "Expenses", "Re-invoiced", "To re-invoice", "Margin"},
+	}
+	var err error
+	if s.Expenses, err = s.Expenses.Filter(from, to, ""); err != nil {
+		logrus.Fatal("error while filtering expenses: ", err)
+	}
+	if s.Invoices, err = s.Invoices.Filter(from, to); err != nil {
+		logrus.Fatal("error while filtering invoices: ", err)
+	}
+	if from != nil || to != nil {
+		s.CreditNotes = s.CreditNotes.Filter(from, to)
+	}
+
+	var total projectResult
+	for i := range s.Projects {
+		prj := s.Projects[i]
+		rsl := projectResult{revenue: prj.InvoicedAmount(s)}
+		exp := s.Expenses.ByProject(prj)
+		for j := range exp {
+			rsl.addExpense(exp[j])
+		}
+		customer := ""
+		if cst, err := s.Parties.CustomerByRef(prj.Customer); err == nil {
+			customer = cst.Name
+		}
+		tbl.AddRow(rsl.row(s, prj.Short(), customer)...)
+		total.add(rsl)
+	}
+
+	var other projectResult
+	for i := range s.Invoices {
+		if !s.Invoices[i].Revoked && s.Invoices[i].Project.Empty() {
+			other.revenue += amountOrZero(s.Invoices[i].Amount) - s.Invoices[i].CreditedAmount(s)
+		}
+	}
+	for i := range s.Expenses {
+		if !s.Expenses[i].Project.Empty() {
+			continue
+		}
+		other.addExpense(s.Expenses[i])
+	}
+	if other != (projectResult{}) {
+		tbl.AddRow(other.row(s, "No project", "")...)
+		total.add(other)
+	}
+
+	tbl.AddRow(emptyRow(len(tbl.Header))...)
+	tbl.AddEmphasizedRow(total.row(s, "Total", "")...)
+	return tbl
+}
+
+// projectResult sums up the amounts of a line in the project profitability report.
+type projectResult struct {
+	revenue    int64
+	expenses   int64
+	reInvoiced int64
+	open       int64
+}
+
+func (p *projectResult) addExpense(exp schema.Expense) {
+	amount := amountOrZero(exp.Amount)
+	p.expenses += amount
+	switch {
+	case exp.IsReInvoiced():
+		p.reInvoiced += amount
+	case exp.Billable:
+		p.open += amount
+	}
+}
+
+func (p *projectResult) add(other projectResult) {
+	p.revenue += other.revenue
+	p.expenses += other.expenses
+	p.reInvoiced += other.reInvoiced
+	p.open += other.open
+}
+
+func (p projectResult) row(s schema.Schema, name, customer string) []string {
+	return []string{
+		name,
+		customer,
+		displayAmount(p.revenue, s.Currency),
+		displayAmount(p.expenses, s.Currency),
+		displayAmount(p.reInvoiced, s.Currency),
+		displayAmount(p.open, s.Currency),
+		displayAmount(p.revenue-p.expenses, s.Currency),
+	}
+}
+
+// periodName returns a human readable representation of an optional date range.
+func periodName(from *time.Time, to *time.Time) string {
+	switch {
+	case from != nil && to != nil:
+		return fmt.Sprintf("from %s to %s", displayDate(*from), displayDate(*to))
+	case from != nil:
+		return fmt.Sprintf("since %s", displayDate(*from))
+	case to != nil:
+		return fmt.Sprintf("until %s", displayDate(*to))
+	default:
+		return "all records"
+	}
+}
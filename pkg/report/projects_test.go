@@ -0,0 +1,55 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestProjectProfitability(t *testing.T) {
+	s := schema.Schema{
+		Currency: "CHF",
+		Parties:  schema.PartiesCollection{Customers: []schema.Party{{Id: "cst-1", Name: "Stadttheater"}}},
+		Projects: schema.Projects{{Id: "prj-1", Identifier: "p-1", Name: "Hamlet", Customer: schema.NewRef("cst-1")}},
+		Expenses: schema.Expenses{
+			{Id: "exp-1", DateOfAccrual: "2020-02-01", Amount: util.NewMoney(30000, "CHF"), Project: schema.NewRef("prj-1")},
+			{Id: "exp-2", DateOfAccrual: "2020-02-03", Amount: util.NewMoney(12000, "CHF"), Project: schema.NewRef("prj-1"), Billable: true, Invoice: schema.NewRef("inv-2")},
+			{Id: "exp-3", DateOfAccrual: "2020-02-05", Amount: util.NewMoney(5000, "CHF"), Project: schema.NewRef("prj-1"), Billable: true},
+			{Id: "exp-4", DateOfAccrual: "2020-02-07", Amount: util.NewMoney(2500, "CHF")},
+			{Id: "exp-5", DateOfAccrual: "2019-12-31", Amount: util.NewMoney(99900, "CHF"), Project: schema.NewRef("prj-1")},
+			{Id: "exp-6", DateOfAccrual: "2020-02-08", Project: schema.NewRef("prj-1")},
+		},
+		Invoices: schema.Invoices{
+			{Id: "inv-1", SendDate: "2020-02-10", Amount: util.NewMoney(100000, "CHF"), Project: schema.NewRef("prj-1")},
+			{Id: "inv-2", SendDate: "2020-02-11", Amount: util.NewMoney(12000, "CHF"), Project: schema.NewRef("prj-1")},
+			{Id: "inv-3", SendDate: "2020-02-12", Amount: util.NewMoney(50000, "CHF"), Project: schema.NewRef("prj-1"), Revoked: true},
+			{Id: "inv-4", SendDate: "2020-02-13", Amount: util.NewMoney(8000, "CHF")},
+			{Id: "inv-5", SendDate: "2020-02-14"},
+		},
+		CreditNotes: schema.CreditNotes{
+			{Id: "cn-1", Date: "2020-02-20", Invoice: schema.NewRef("inv-1"), Amount: util.NewMoney(10000, "CHF")},
+			{Id: "cn-2", Date: "2020-03-05", Invoice: schema.NewRef("inv-4"), Amount: util.NewMoney(8000, "CHF")},
+		},
+	}
+	from := time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC)
+	tbl := ProjectProfitability(s, &from, &to)
+	expected := [][]string{
+		{"Hamlet (p-1)", "Stadttheater", "1,020.00 CHF", "470.00 CHF", "120.00 CHF", "50.00 CHF", "550.00 CHF"},
+		{"No project", "", "80.00 CHF", "25.00 CHF", "0.00 CHF", "0.00 CHF", "55.00 CHF"},
+		emptyRow(7),
+		{"Total", "", "1,100.00 CHF", "495.00 CHF", "120.00 CHF", "50.00 CHF", "605.00 CHF"},
+	}
+	if len(tbl.Rows) != len(expected) {
+		t.Fatalf("expected %d rows, got %+v", len(expected), tbl.Rows)
+	}
+	for i := range expected {
+		for j := range expected[i] {
+			if tbl.Rows[i].Cells[j] != expected[i][j] {
+				t.Errorf("row %d, column %s: expected %s, got %s", i, tbl.Header[j], expected[i][j], tbl.Rows[i].Cells[j])
+			}
+		}
+	}
+}
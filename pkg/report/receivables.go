@@ -0,0 +1,76 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/72nd/acc/pkg/schema"
+)
+
+// agingBuckets are the upper bounds (in days since sending) of the aging columns, the
+// last column contains all older receivables.
+var agingBuckets = []int{30, 60, 90}
+
+// Receivables returns the aging list of all unpaid invoices as of the given date. The
+// open amount of an invoice is reduced by its credit notes, fully credited invoices are
+// omitted. The open amount is listed in the column of its age.
+func Receivables(s schema.Schema, date time.Time) Table {
+	tbl := Table{
+		Title:    "Receivables Aging",
+		Subtitle: fmt.Sprintf("as of %s", displayDate(date)),
+		Header:   []string{"Invoice", "Customer", "Sent", "Amount", "Credited", "0-30 days", "31-60 days", "61-90 days", "> 90 days"},
+	}
+	totals := make([]int64, len(agingBuckets)+1)
+	for i := range s.Invoices {
+		inv := s.Invoices[i]
+		if inv.Revoked || inv.IsDraft() || inv.IsSettled() || inv.SendDateTime().After(date) {
+			continue
+		}
+		open := inv.OpenAmount(s).Amount()
+		if open <= 0 {
+			continue
+		}
+		customer := ""
+		if cst, err := s.Parties.CustomerByRef(inv.Customer); err == nil {
+			customer = cst.Name
+		}
+		credited := ""
+		if amount := inv.CreditedAmount(s); amount > 0 {
+			credited = displayAmount(amount, s.Currency)
+		}
+		row := []string{
+			inv.Short(),
+			customer,
+			displayDate(inv.SendDateTime()),
+			displayAmount(inv.Amount.Amount(), s.Currency),
+			credited,
+		}
+		bucket := agingBucket(int(date.Sub(inv.SendDateTime()).Hours() / 24))
+		for j := range totals {
+			if j != bucket {
+				row = append(row, "")
+				continue
+			}
+			row = append(row, displayAmount(open, s.Currency))
+			totals[j] += open
+		}
+		tbl.AddRow(row...)
+	}
+	tbl.AddRow(emptyRow(len(tbl.Header))...)
+	row := []string{"Total", "", "", "", ""}
+	for i := range totals {
+		row = append(row, displayAmount(totals[i], s.Currency))
+	}
+	tbl.AddEmphasizedRow(row...)
+	return tbl
+}
+
+// agingBucket returns the index of the aging column for the given age in days.
+func agingBucket(days int) int {
+	for i := range agingBuckets {
+		if days <= agingBuckets[i] {
+			return i
+		}
+	}
+	return len(agingBuckets)
+}
@@ -0,0 +1,174 @@
+package report
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/72nd/acc/pkg/ledger"
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/72nd/acc/pkg/util"
+)
+
+// defaultCurrency is used when the project doesn't define a currency.
+const defaultCurrency = "CHF"
+
+// BalanceSheet returns the balance sheet of the project at the end of the given year
+// compared with the balance sheet at the end of the previous year. The balances are
+// computed by generating the journal of all years and applying the account aliases.
+// An error is returned if there are postings in another currency than the one of the
+// project.
+func BalanceSheet(s schema.Schema, year int) (Table, error) {
+	jrn := ledger.JournalFromAcc(s, 0)
+	_, currentTo := util.DateRangeFromYear(year)
+	_, previousTo := util.DateRangeFromYear(year - 1)
+	current, err := jrn.Balances(statementCurrency(s), nil, &currentTo)
+	if err != nil {
+		return Table{}, err
+	}
+	previous, err := jrn.Balances(statementCurrency(s), nil, &previousTo)
+	if err != nil {
+		return Table{}, err
+	}
+
+	tbl := Table{
+		Title:    "Balance Sheet",
+		Subtitle: fmt.Sprintf("as of %s compared with %s", displayDate(currentTo), displayDate(previousTo)),
+		Header:   []string{"Account", displayDate(currentTo), displayDate(previousTo)},
+	}
+	tree := ledger.NewAccountTree(current, previous)
+
+	assets := accountRows(&tbl, tree, ledger.AssetAccount, false, s.Currency, current, previous)
+	tbl.AddEmphasizedRow(amountRow("Total assets", s.Currency, assets...)...)
+	tbl.AddRow(emptyRow(len(tbl.Header))...)
+
+	liabilities := accountRows(&tbl, tree, ledger.LiabilityAccount, true, s.Currency, current, previous)
+	equity := accountRows(&tbl, tree, ledger.EquityAccount, true, s.Currency, current, previous)
+	result := []int64{profit(current), profit(previous)}
+	tbl.AddRow(amountRow("Profit/loss (retained and current year)", s.Currency, result...)...)
+	tbl.AddEmphasizedRow(amountRow(
+		"Total liabilities and equity",
+		s.Currency,
+		liabilities[0]+equity[0]+result[0],
+		liabilities[1]+equity[1]+result[1])...)
+
+	if current.TotalByType(ledger.UnknownAccount) != 0 || previous.TotalByType(ledger.UnknownAccount) != 0 {
+		tbl.AddRow(emptyRow(len(tbl.Header))...)
+		unknown := accountRows(&tbl, tree, ledger.UnknownAccount, false, s.Currency, current, previous)
+		tbl.AddEmphasizedRow(amountRow("Total unclassified accounts", s.Currency, unknown...)...)
+	}
+	return tbl, nil
+}
+
+// IncomeStatement returns the income statement (profit and loss) for the given year
+// compared with the previous year. As with the balance sheet all postings have to be in
+// the currency of the project.
+func IncomeStatement(s schema.Schema, year int) (Table, error) {
+	jrn := ledger.JournalFromAcc(s, 0)
+	currentFrom, currentTo := util.DateRangeFromYear(year)
+	previousFrom, previousTo := util.DateRangeFromYear(year - 1)
+	current, err := jrn.Balances(statementCurrency(s), &currentFrom, &currentTo)
+	if err != nil {
+		return Table{}, err
+	}
+	previous, err := jrn.Balances(statementCurrency(s), &previousFrom, &previousTo)
+	if err != nil {
+		return Table{}, err
+	}
+
+	tbl := Table{
+		Title:    "Income Statement",
+		Subtitle: fmt.Sprintf("for the year %d compared with %d", year, year-1),
+		Header:   []string{"Account", fmt.Sprint(year), fmt.Sprint(year - 1)},
+	}
+	tree := ledger.NewAccountTree(current, previous)
+
+	revenues := accountRows(&tbl, tree, ledger.RevenueAccount, true, s.Currency, current, previous)
+	tbl.AddEmphasizedRow(amountRow("Total revenues", s.Currency, revenues...)...)
+	tbl.AddRow(emptyRow(len(tbl.Header))...)
+	expenses := accountRows(&tbl, tree, ledger.ExpenseAccount, false, s.Currency, current, previous)
+	tbl.AddEmphasizedRow(amountRow("Total expenses", s.Currency, expenses...)...)
+	tbl.AddRow(emptyRow(len(tbl.Header))...)
+	tbl.AddEmphasizedRow(amountRow(
+		"Profit/loss",
+		s.Currency,
+		revenues[0]-expenses[0],
+		revenues[1]-expenses[1])...)
+	return tbl, nil
+}
+
+// accountRows adds a row for each account of the given type to the table. Top level
+// accounts are emphasized, sub-accounts are indented according to their depth. If invert
+// is true, the sign of all amounts is changed (used for credit-side accounts). Returns
+// the sum of all top level accounts for each of the given balances.
+func accountRows(tbl *Table, tree *ledger.Account, typ ledger.AccountType, invert bool, currency string, balances ...ledger.Balances) []int64 {
+	totals := make([]int64, len(balances))
+	for i := range tree.Children {
+		if ledger.AccountTypeOf(tree.Children[i].FullName) != typ {
+			continue
+		}
+		tree.Children[i].Walk(func(depth int, acc *ledger.Account) {
+			amounts := make([]int64, len(balances))
+			for j := range balances {
+				amounts[j] = balances[j].Total(acc.FullName)
+				if invert {
+					amounts[j] = -amounts[j]
+				}
+				if depth == 0 {
+					totals[j] += amounts[j]
+				}
+			}
+			row := amountRow(strings.Repeat("  ", depth)+acc.Name, currency, amounts...)
+			if depth == 0 {
+				tbl.AddEmphasizedRow(row...)
+				return
+			}
+			tbl.AddRow(row...)
+		})
+	}
+	return totals
+}
+
+// statementCurrency returns the currency of the project the statements are computed in.
+func statementCurrency(s schema.Schema) string {
+	if s.Currency == "" {
+		return defaultCurrency
+	}
+	return s.Currency
+}
+
+// profit returns the accumulated profit (positive) or loss (negative) of the given balances.
+func profit(b ledger.Balances) int64 {
+	return -(b.TotalByType(ledger.RevenueAccount) + b.TotalByType(ledger.ExpenseAccount))
+}
+
+func amountRow(label, currency string, amounts ...int64) []string {
+	rsl := []string{label}
+	for i := range amounts {
+		rsl = append(rsl, displayAmount(amounts[i], currency))
+	}
+	return rsl
+}
+
+func emptyRow(length int) []string {
+	return make([]string, length)
+}
+
+func displayAmount(amount int64, currency string) string {
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	return util.NewMoney(amount, currency).Display()
+}
+
+// amountOrZero returns the amount in cents, zero if the amount isn't set.
+func amountOrZero(m util.Money) int64 {
+	if m.Money == nil {
+		return 0
+	}
+	return m.Amount()
+}
+
+func displayDate(date time.Time) string {
+	return date.Format("02.01.2006")
+}
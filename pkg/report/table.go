@@ -0,0 +1,155 @@
+// Package report computes reports (financial statements etc.) out of an acc project and
+// renders them as terminal table, CSV or PDF.
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/72nd/acc/pkg/document/reports"
+	"github.com/72nd/acc/pkg/schema"
+	"github.com/olekukonko/tablewriter"
+	"github.com/sirupsen/logrus"
+)
+
+// Format states the output format of a report.
+type Format int
+
+const (
+	TableFormat Format = iota
+	CsvFormat
+	PdfFormat
+)
+
+// FormatFromUserInput parses the format given by the user.
+func FormatFromUserInput(input string) (Format, error) {
+	switch strings.ToLower(input) {
+	case "", "table":
+		return TableFormat, nil
+	case "csv":
+		return CsvFormat, nil
+	case "pdf":
+		return PdfFormat, nil
+	}
+	return TableFormat, fmt.Errorf("unknown report format «%s», use table, csv or pdf", input)
+}
+
+// Row is a single line of a report. Emphasized rows contain section titles or totals.
+type Row struct {
+	Cells    []string
+	Emphasis bool
+}
+
+// Table is the common representation of all reports.
+type Table struct {
+	Title    string
+	Subtitle string
+	Header   []string
+	Rows     []Row
+}
+
+// AddRow appends a normal row to the table.
+func (t *Table) AddRow(cells ...string) {
+	t.Rows = append(t.Rows, Row{Cells: cells})
+}
+
+// AddEmphasizedRow appends a emphasized (section title or total) row to the table.
+func (t *Table) AddEmphasizedRow(cells ...string) {
+	t.Rows = append(t.Rows, Row{Cells: cells, Emphasis: true})
+}
+
+// Output writes the table in the given format. For the table and the CSV format an empty
+// path means printing to the standard output. The company is used for the PDF letterhead.
+func (t Table) Output(format Format, company schema.Company, path string) {
+	switch format {
+	case TableFormat:
+		t.write(path, t.Render())
+	case CsvFormat:
+		t.write(path, t.CSV())
+	case PdfFormat:
+		if path == "" {
+			logrus.Fatal("an output path is needed for PDF reports")
+		}
+		t.SavePdf(company, path)
+	default:
+		logrus.Fatalf("illegal report format \"%d\"", format)
+	}
+}
+
+func (t Table) write(path, content string) {
+	if path == "" {
+		fmt.Print(content)
+		return
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		logrus.Fatalf("error writing report %s: %s", path, err)
+	}
+	logrus.Info("report saved as ", path)
+}
+
+// Render returns the table for the terminal.
+func (t Table) Render() string {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "%s\n", t.Title)
+	if t.Subtitle != "" {
+		fmt.Fprintf(buf, "%s\n", t.Subtitle)
+	}
+	tbl := tablewriter.NewWriter(buf)
+	tbl.SetHeader(t.Header)
+	tbl.SetAutoWrapText(false)
+	tbl.SetAutoFormatHeaders(false)
+	alignments := make([]int, len(t.Header))
+	for i := range alignments {
+		alignments[i] = tablewriter.ALIGN_RIGHT
+	}
+	if len(alignments) > 0 {
+		alignments[0] = tablewriter.ALIGN_LEFT
+	}
+	tbl.SetColumnAlignment(alignments)
+	for i := range t.Rows {
+		tbl.Append(t.Rows[i].Cells)
+	}
+	tbl.Render()
+	return buf.String()
+}
+
+// CSV returns the table as comma separated values. The first line contains the header.
+func (t Table) CSV() string {
+	buf := &bytes.Buffer{}
+	if err := t.WriteCSV(buf); err != nil {
+		logrus.Fatal("error while rendering csv: ", err)
+	}
+	return buf.String()
+}
+
+// WriteCSV writes the table as comma separated values to the given writer.
+func (t Table) WriteCSV(w io.Writer) error {
+	wrt := csv.NewWriter(w)
+	if err := wrt.Write(t.Header); err != nil {
+		return err
+	}
+	for i := range t.Rows {
+		if err := wrt.Write(t.Rows[i].Cells); err != nil {
+			return err
+		}
+	}
+	wrt.Flush()
+	return wrt.Error()
+}
+
+// SavePdf renders the table as a PDF and saves it at the given path.
+func (t Table) SavePdf(company schema.Company, path string) {
+	rows := make([]reports.Row, len(t.Rows))
+	for i := range t.Rows {
+		rows[i] = reports.Row{
+			Cells:    t.Rows[i].Cells,
+			Emphasis: t.Rows[i].Emphasis,
+		}
+	}
+	reports.GenerateTable(company, t.Title, t.Subtitle, t.Header, rows, path)
+	logrus.Info("report saved as ", path)
+}
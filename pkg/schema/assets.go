@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/72nd/acc/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// assetRecord is a record which refers to a file (expense, invoice or misc record).
+type assetRecord struct {
+	ele  util.Validatable
+	path string
+	hash *string
+}
+
+// assetRecords returns all records with a file whose content hash is stored.
+func (s Schema) assetRecords() []assetRecord {
+	var rsl []assetRecord
+	for i := range s.Expenses {
+		rsl = append(rsl, assetRecord{s.Expenses[i], s.Expenses[i].Path, &s.Expenses[i].AssetHash})
+	}
+	for i := range s.Invoices {
+		rsl = append(rsl, assetRecord{s.Invoices[i], s.Invoices[i].Path, &s.Invoices[i].AssetHash})
+	}
+	for i := range s.MiscRecords {
+		rsl = append(rsl, assetRecord{s.MiscRecords[i], s.MiscRecords[i].Path, &s.MiscRecords[i].AssetHash})
+	}
+	return rsl
+}
+
+// currentHash returns the hash of the content of the file. The stored hash is used if
+// the file doesn't exist (anymore).
+func (r assetRecord) currentHash() string {
+	if r.path == "" || !util.FileExist(r.path) {
+		return *r.hash
+	}
+	hash, err := util.FileHash(r.path)
+	if err != nil {
+		logrus.Warnf("couldn't hash file of %s: %s", r.ele.String(), err)
+		return *r.hash
+	}
+	return hash
+}
+
+// HashAssets stores the content hash of the file of each expense, invoice and misc record
+// which has none yet. Outdated hashes are updated by the fix mode of the validation.
+func (s Schema) HashAssets() {
+	for _, rec := range s.assetRecords() {
+		if *rec.hash != "" || rec.path == "" || !util.FileExist(rec.path) {
+			continue
+		}
+		if hash, err := util.FileHash(rec.path); err == nil {
+			*rec.hash = hash
+		}
+	}
+}
+
+// samePath returns the absolute path used to compare the paths of records, relative paths
+// are relative to the working directory.
+func samePath(path string) string {
+	return filepath.Clean(util.AbsolutePathWithWD(path))
+}
+
+// KnownAsset returns the record whose file is the given file or has the same content.
+func (s Schema) KnownAsset(path string) (util.Validatable, bool) {
+	hash, err := util.FileHash(path)
+	if err != nil {
+		return nil, false
+	}
+	abs := samePath(path)
+	for _, rec := range s.assetRecords() {
+		if rec.path == "" {
+			continue
+		}
+		if samePath(rec.path) == abs {
+			return rec.ele, true
+		}
+		known := *rec.hash
+		if known == "" {
+			known = rec.currentHash()
+		}
+		if known == hash {
+			return rec.ele, true
+		}
+	}
+	return nil, false
+}
+
+// similarExpenses states whether two expenses are likely the same receipt booked twice:
+// the amounts are in the same currency and differ by at most 1%, the dates of accrual are
+// at most three days apart and the name of one contains the name of the other (ignoring
+// case, spaces and punctuation).
+func similarExpenses(a, b Expense) bool {
+	if a.Amount.Money == nil || b.Amount.Money == nil || a.Amount.Amount() == 0 ||
+		a.Amount.Currency().Code != b.Amount.Currency().Code {
+		return false
+	}
+	diff := math.Abs(float64(a.Amount.Amount() - b.Amount.Amount()))
+	if diff > math.Max(math.Abs(float64(a.Amount.Amount())), math.Abs(float64(b.Amount.Amount())))*0.01 {
+		return false
+	}
+	dateA, errA := time.Parse(util.DateFormat, a.DateOfAccrual)
+	dateB, errB := time.Parse(util.DateFormat, b.DateOfAccrual)
+	if errA != nil || errB != nil || math.Abs(dateA.Sub(dateB).Hours()) > 3*24 {
+		return false
+	}
+	nameA, nameB := normalizeName(a.Name), normalizeName(b.Name)
+	if nameA == "" || nameB == "" {
+		return false
+	}
+	return strings.Contains(nameA, nameB) || strings.Contains(nameB, nameA)
+}
+
+// normalizeName returns the name in lower case without spaces and punctuation.
+func normalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
@@ -0,0 +1,130 @@
+package schema
+
+import (
+	"fmt"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+// BudgetLine is the planned amount of a Project for one expense category.
+type BudgetLine struct {
+	// ExpenseCategory the budget is planned for, has to match the ExpenseCategory of the expenses.
+	ExpenseCategory string `yaml:"expenseCategory" default:""`
+	// Amount is the planned amount for the category.
+	Amount util.Money `yaml:"amount" default:"-"`
+}
+
+// BudgetUsage states the planned, spent and invoiced amount of a Project for one
+// expense category. All amounts are in cents.
+type BudgetUsage struct {
+	ExpenseCategory string
+	// Planned is the amount of the budget line, zero if the category has no budget.
+	Planned int64
+	// Spent is the sum of all expenses of the project in the category.
+	Spent int64
+	// Invoiced is the part of the spent amount which was re-invoiced to the customer
+	// with a (not revoked) invoice.
+	Invoiced int64
+}
+
+// Remaining returns the amount which is still left in the budget. Negative values
+// indicate a budget overrun.
+func (b BudgetUsage) Remaining() int64 {
+	return b.Planned - b.Spent
+}
+
+// Exceeded returns true if more was spent than planned.
+func (b BudgetUsage) Exceeded() bool {
+	return b.Spent > b.Planned
+}
+
+// BudgetUsage returns the usage of the budget per expense category. The budgeted
+// categories come first (in the order of the budget), categories with expenses but
+// without a budget line are appended. Budget lines and expenses without an amount
+// count as zero. Billable expenses which weren't re-invoiced yet (or only with a revoked
+// invoice) don't count as invoiced.
+func (p Project) BudgetUsage(s Schema) []BudgetUsage {
+	var rsl []BudgetUsage
+	index := make(map[string]int)
+	for i := range p.Budget {
+		index[p.Budget[i].ExpenseCategory] = len(rsl)
+		rsl = append(rsl, BudgetUsage{
+			ExpenseCategory: p.Budget[i].ExpenseCategory,
+			Planned:         amountOrZero(p.Budget[i].Amount),
+		})
+	}
+	for i := range s.Expenses {
+		exp := s.Expenses[i]
+		if !exp.Project.Match(p) {
+			continue
+		}
+		j, ok := index[exp.ExpenseCategory]
+		if !ok {
+			j = len(rsl)
+			index[exp.ExpenseCategory] = j
+			rsl = append(rsl, BudgetUsage{ExpenseCategory: exp.ExpenseCategory})
+		}
+		rsl[j].Spent += amountOrZero(exp.Amount)
+		if !exp.IsReInvoiced() {
+			continue
+		}
+		if inv, err := s.Invoices.InvoiceByRef(exp.Invoice); err == nil && !inv.Revoked {
+			rsl[j].Invoiced += amountOrZero(exp.Amount)
+		}
+	}
+	return rsl
+}
+
+// InvoicedAmount returns the sum of all non revoked invoices of the Project reduced by
+// their credit notes.
+func (p Project) InvoicedAmount(s Schema) int64 {
+	var sum int64
+	for i := range s.Invoices {
+		if s.Invoices[i].Revoked || !s.Invoices[i].Project.Match(p) {
+			continue
+		}
+		sum += amountOrZero(s.Invoices[i].Amount) - s.Invoices[i].CreditedAmount(s)
+	}
+	return sum
+}
+
+// amountOrZero returns the amount in cents, zero if the amount isn't set.
+func amountOrZero(m util.Money) int64 {
+	if m.Money == nil {
+		return 0
+	}
+	return m.Amount()
+}
+
+// ValidateBudgets returns a validation result for each Project which exceeds the budget
+// of at least one expense category. Categories without budget line are ignored.
+func (p Projects) ValidateBudgets(s Schema) util.ValidateResults {
+	var rsl util.ValidateResults
+	for i := range p {
+		if len(p[i].Budget) == 0 {
+			continue
+		}
+		var conditions util.Conditions
+		usage := p[i].BudgetUsage(s)
+		for j := range usage[:len(p[i].Budget)] {
+			if !usage[j].Exceeded() {
+				continue
+			}
+			conditions = append(conditions, util.Conditions{{
+				Condition: true,
+				Message: fmt.Sprintf(
+					"over budget in category \"%s\" by %s",
+					usage[j].ExpenseCategory,
+					util.NewMoney(-usage[j].Remaining(), s.Currency).Value()),
+				Level: util.BeforeExportFlaw,
+			}}...)
+		}
+		if len(conditions) != 0 {
+			rsl = append(rsl, util.ValidateResult{
+				Element:    p[i],
+				Conditions: conditions,
+			})
+		}
+	}
+	return rsl
+}
@@ -0,0 +1,66 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestBudgetUsage(t *testing.T) {
+	prj := Project{
+		Id:         "prj-1",
+		Identifier: "p-1",
+		Name:       "Hamlet",
+		Customer:   NewRef("cst-1"),
+		Budget: []BudgetLine{
+			{ExpenseCategory: "Material", Amount: util.NewMoney(100000, "CHF")},
+			{ExpenseCategory: "Travel"},
+		},
+	}
+	s := Schema{
+		Expenses: Expenses{
+			{Id: "exp-1", ExpenseCategory: "Material", Amount: util.NewMoney(60000, "CHF"), Project: NewRef("prj-1")},
+			{Id: "exp-2", ExpenseCategory: "Material", Amount: util.NewMoney(50000, "CHF"), Project: NewRef("prj-1"), Billable: true, Invoice: NewRef("inv-1")},
+			{Id: "exp-6", ExpenseCategory: "Material", Amount: util.NewMoney(7000, "CHF"), Project: NewRef("prj-1"), Billable: true},
+			{Id: "exp-7", ExpenseCategory: "Material", Amount: util.NewMoney(2000, "CHF"), Project: NewRef("prj-1"), Billable: true, Invoice: NewRef("inv-3")},
+			{Id: "exp-3", ExpenseCategory: "Travel", Project: NewRef("prj-1"), Billable: true},
+			{Id: "exp-4", ExpenseCategory: "Catering", Amount: util.NewMoney(3000, "CHF"), Project: NewRef("prj-1")},
+			{Id: "exp-5", ExpenseCategory: "Material", Amount: util.NewMoney(9900, "CHF"), Project: NewRef("prj-2")},
+		},
+		Invoices: Invoices{
+			{Id: "inv-1", Amount: util.NewMoney(150000, "CHF"), Project: NewRef("prj-1")},
+			{Id: "inv-2", Project: NewRef("prj-1")},
+			{Id: "inv-3", Amount: util.NewMoney(20000, "CHF"), Project: NewRef("prj-1"), Revoked: true},
+		},
+	}
+	expected := []BudgetUsage{
+		{ExpenseCategory: "Material", Planned: 100000, Spent: 119000, Invoiced: 50000},
+		{ExpenseCategory: "Travel"},
+		{ExpenseCategory: "Catering", Spent: 3000},
+	}
+	usage := prj.BudgetUsage(s)
+	if len(usage) != len(expected) {
+		t.Fatalf("expected %d categories, got %+v", len(expected), usage)
+	}
+	for i := range expected {
+		if usage[i] != expected[i] {
+			t.Errorf("expected %+v, got %+v", expected[i], usage[i])
+		}
+	}
+	if !usage[0].Exceeded() || usage[0].Remaining() != -19000 || usage[1].Exceeded() {
+		t.Errorf("unexpected budget state %+v", usage)
+	}
+	if amount := prj.InvoicedAmount(s); amount != 150000 {
+		t.Errorf("expected invoiced amount of 150000, got %d", amount)
+	}
+
+	found := false
+	for _, cnd := range prj.Conditions() {
+		if cnd.Condition && cnd.Message == "budget line without amount (Budget.Amount is empty)" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a validation finding for the budget line without amount")
+	}
+}
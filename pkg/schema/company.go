@@ -14,10 +14,13 @@ type Company struct {
 	StreetNr   int    `yaml:"streetNr" default:"1"`
 	PostalCode int    `yaml:"postalCode" default:"8000"`
 	Place      string `yaml:"place" default:"Zurich"`
+	Country    string `yaml:"country,omitempty" default:""`
 	Phone      string `yaml:"phone" default:"+41 78 000 00 00"`
 	Mail       string `yaml:"mail" default:"info@fortuna.com"`
 	Url        string `yaml:"url" default:"https://fortuna.com"`
 	Logo       string `yaml:"logo" default:"/path/to/logo.png"`
+	Iban       string `yaml:"iban,omitempty" default:""`
+	Bank       string `yaml:"bank,omitempty" default:""`
 }
 
 func NewCompany(logo string) Company {
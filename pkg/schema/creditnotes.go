@@ -0,0 +1,319 @@
+package schema
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultCreditNotesFile = "credit-notes.yaml"
+const DefaultCreditNotePrefix = "cn-"
+
+// CreditNotes is a collection of CreditNote elements.
+type CreditNotes []CreditNote
+
+// OpenCreditNotes opens the CreditNotes saved in the YAML file given by the path. As
+// credit notes are optional, an empty collection is returned if the file doesn't exist.
+func OpenCreditNotes(path string) CreditNotes {
+	var crn CreditNotes
+	if !util.FileExist(path) {
+		return crn
+	}
+	util.OpenYaml(&crn, path, "credit notes")
+	return crn
+}
+
+// Save writes the element as YAML file to the given path. Nothing is written when there
+// are no credit notes and no existing file.
+func (c CreditNotes) Save(path string) {
+	if len(c) == 0 && !util.FileExist(path) {
+		return
+	}
+	util.SaveToYaml(c, path, "credit notes")
+}
+
+// CreditNoteByRef returns the CreditNote with the given id. If no record could be found
+// an error will be returned.
+func (c CreditNotes) CreditNoteByRef(ref Ref) (*CreditNote, error) {
+	for i := range c {
+		if ref.Match(c[i]) {
+			return &c[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no credit note for id \"%s\" found", ref.Id)
+}
+
+// GetIdentifiables returns the a slice of all identifiers. This is used for the
+// identifier suggestion while interactively adding a new CreditNote.
+func (c CreditNotes) GetIdentifiables() []Identifiable {
+	rsl := make([]Identifiable, len(c))
+	for i := range c {
+		rsl[i] = c[i]
+	}
+	return rsl
+}
+
+// Validate all CreditNotes.
+func (c CreditNotes) Validate() util.ValidateResults {
+	var rsl util.ValidateResults
+	for i := range c {
+		rsl = append(rsl, util.Check(c[i]))
+	}
+	return rsl
+}
+
+// ValidateAmounts returns a validation result for each invoice which was credited with
+// more than its amount.
+func (c CreditNotes) ValidateAmounts(s Schema) util.ValidateResults {
+	var rsl util.ValidateResults
+	for i := range s.Invoices {
+		inv := s.Invoices[i]
+		if inv.CreditedAmount(s) <= inv.Amount.Amount() {
+			continue
+		}
+		rsl = append(rsl, util.ValidateResult{
+			Element: inv,
+			Conditions: util.Conditions{{
+				Condition: true,
+				Message: fmt.Sprintf(
+					"credit notes exceed the invoice amount (credited %s)",
+					util.NewMoney(inv.CreditedAmount(s), inv.Amount.Currency().Code).Value()),
+				Level: util.BeforeExportFlaw,
+			}},
+		})
+	}
+	return rsl
+}
+
+// SetReferenceDestinations sets the destinations of the Reference fields.
+func (c CreditNotes) SetReferenceDestinations(inv []Identifiable) {
+	for i := range c {
+		c[i].Invoice.SetDestination(inv)
+	}
+}
+
+// Filter returns all credit notes issued between from and to (both optional).
+func (c CreditNotes) Filter(from, to *time.Time) CreditNotes {
+	var rsl CreditNotes
+	for i := range c {
+		date, err := time.Parse(util.DateFormat, c[i].Date)
+		if err != nil {
+			logrus.Errorf("date of %s: %s", c[i].String(), err)
+			continue
+		}
+		if (from != nil && date.Before(*from)) || (to != nil && date.After(*to)) {
+			continue
+		}
+		rsl = append(rsl, c[i])
+	}
+	return rsl
+}
+
+// CreditNote cancels an invoice completely or partially. Instead of revoking an already
+// booked invoice, the credit note reverses the booking at its own date and thus keeps the
+// audit trail intact.
+type CreditNote struct {
+	// Id is the internal unique identifier of the CreditNote.
+	Id string `yaml:"id" default:""`
+	// Identifier is a unique user-chosen identifier, credit notes have their own numbering.
+	Identifier string `yaml:"identifier" default:"cn-1"`
+	// Name describes meaningful the CreditNote.
+	Name string `yaml:"name" default:"Credit note"`
+	// Amount which is credited to the customer, at most the amount of the invoice.
+	Amount util.Money `yaml:"amount" default:"" query:"amount"`
+	// Path is the full path to the credit note document.
+	Path string `yaml:"path" default:"" query:"path"`
+	// Invoice refers to the credited invoice.
+	Invoice Ref `yaml:"invoiceId" default:""`
+	// Date states the date the credit note was issued.
+	Date string `yaml:"date" default:""`
+	// Reason for the credit (ex: cancellation, discount).
+	Reason string `yaml:"reason" default:""`
+	// JournalOverride contains manual corrections of the generated journal entry.
+	JournalOverride *JournalOverride `yaml:"journalOverride,omitempty"`
+}
+
+// NewCreditNote returns a new CreditNote with the default values and a zero amount in the
+// given currency.
+func NewCreditNote(currency string) CreditNote {
+	crn := CreditNote{}
+	if err := defaults.Set(&crn); err != nil {
+		logrus.Fatal("error setting defaults for credit note: ", err)
+	}
+	crn.Id = GetUuid()
+	crn.Date = time.Now().Format(util.DateFormat)
+	crn.Amount = util.NewMoney(0, currency)
+	return crn
+}
+
+// InteractiveNewCreditNote returns a new CreditNote based on the user input.
+func InteractiveNewCreditNote(s Schema, asset string) CreditNote {
+	crn := NewCreditNote(s.Currency)
+	crn.Identifier = util.AskString(
+		"Identifier",
+		"Unique human readable identifier",
+		SuggestNextIdentifier(s.CreditNotes.GetIdentifiables(), DefaultCreditNotePrefix))
+	crn.Invoice = NewRef(util.AskStringFromSearch(
+		"Invoice",
+		"Invoice which is credited",
+		s.Invoices.SearchItems(s)))
+	open := util.NewMoney(0, s.Currency)
+	if inv, err := s.Invoices.InvoiceByRef(crn.Invoice); err == nil {
+		crn.Name = fmt.Sprintf("Credit note for %s", inv.Name)
+		open = inv.OpenAmount(s)
+	}
+	crn.Name = util.AskString(
+		"Name",
+		"Name of the credit note",
+		crn.Name)
+	crn.Amount = util.AskMoney(
+		"Amount",
+		"Credited amount (defaults to the not yet credited amount of the invoice)",
+		open,
+		s.Currency)
+	crn.Date = util.AskDate(
+		"Date",
+		"Date of the credit note",
+		time.Now())
+	crn.Reason = util.AskString(
+		"Reason",
+		"Reason for the credit",
+		"Cancellation")
+	crn.Path = util.AskString(
+		"Path",
+		"Path to the credit note document",
+		asset)
+	return crn
+}
+
+// SetId generates a unique id for the element if there isn't already one defined.
+func (c *CreditNote) SetId() {
+	if c.Id != "" {
+		return
+	}
+	c.Id = GetUuid()
+}
+
+// GetId returns the id of the CreditNote.
+func (c CreditNote) GetId() string {
+	return c.Id
+}
+
+// GetIdentifier returns the identifier of the CreditNote.
+func (c CreditNote) GetIdentifier() string {
+	return c.Identifier
+}
+
+// String returns a human readable representation of the element.
+func (c CreditNote) String() string {
+	return fmt.Sprintf("credit note %s (%s): %s", c.Name, c.Identifier, c.Amount.Display())
+}
+
+// Short returns a short representation of the element.
+func (c CreditNote) Short() string {
+	return fmt.Sprintf("%s (%s)", c.Name, c.Identifier)
+}
+
+// Type returns a string with the type name of the element.
+func (c CreditNote) Type() string {
+	return "CreditNote"
+}
+
+// FileString returns the file name for exporting the credit note as a document.
+func (c CreditNote) FileString() string {
+	return c.Identifier
+}
+
+// DateTime returns the parsed date of the credit note.
+func (c CreditNote) DateTime() time.Time {
+	result, err := time.Parse(util.DateFormat, c.Date)
+	if err != nil {
+		logrus.Fatalf("could not parse «%s» as date with YYYY-MM-DD: %s", c.Date, err)
+	}
+	return result
+}
+
+// Conditions returns the validation conditions.
+func (c CreditNote) Conditions() util.Conditions {
+	return util.Conditions{
+		{
+			Condition: c.Id == "",
+			Message:   "unique identifier not set (Id is empty)",
+		},
+		{
+			Condition: c.Identifier == "",
+			Message:   "human readable identifier not set (Identifier is empty)",
+		},
+		{
+			Condition: c.Name == "",
+			Message:   "name not set (Name is empty)",
+		},
+		{
+			Condition: c.Amount.Money == nil || c.Amount.Amount() <= 0,
+			Message:   "amount has to be positive",
+		},
+		{
+			Condition: c.Invoice.Empty(),
+			Message:   "credited invoice not set (InvoiceId is empty)",
+		},
+		{
+			Condition: !util.ValidDate(util.DateFormat, c.Date),
+			Message:   fmt.Sprintf("date «%s» could not be parsed with format YYYY-MM-DD", c.Date),
+		},
+	}
+}
+
+// CreditedAmount returns the sum of all credit notes for the Invoice.
+func (i Invoice) CreditedAmount(s Schema) int64 {
+	var sum int64
+	for j := range s.CreditNotes {
+		if s.CreditNotes[j].Invoice.Match(i) && s.CreditNotes[j].Amount.Money != nil {
+			sum += s.CreditNotes[j].Amount.Amount()
+		}
+	}
+	return sum
+}
+
+// OpenAmount returns the amount of the Invoice the customer still has to pay, that is the
+// amount reduced by all credit notes.
+func (i Invoice) OpenAmount(s Schema) util.Money {
+	return util.NewMoney(i.Amount.Amount()-i.CreditedAmount(s), i.Amount.Currency().Code)
+}
+
+// IsSettled returns whether the customer paid the Invoice.
+func (i Invoice) IsSettled() bool {
+	return i.DateOfSettlement != "" || !i.SettlementTransaction.Empty()
+}
+
+// CreditInvoice issues a credit note for the given invoice and adds it to the schema. If
+// the amount is zero the not yet credited amount is credited (cancellation).
+func (s *Schema) CreditInvoice(inv Invoice, amount int64, reason, dstFolder string) (CreditNote, error) {
+	if inv.Revoked {
+		return CreditNote{}, fmt.Errorf("%s is revoked, it can't be credited", inv.String())
+	}
+	open := inv.OpenAmount(*s)
+	if amount == 0 {
+		amount = open.Amount()
+	}
+	if amount <= 0 || amount > open.Amount() {
+		return CreditNote{}, fmt.Errorf(
+			"credit of %s is not between 0 and the open amount %s of %s",
+			util.NewMoney(amount, open.Currency().Code).Value(),
+			open.Value(),
+			inv.String())
+	}
+	crn := NewCreditNote(open.Currency().Code)
+	crn.Identifier = SuggestNextIdentifier(s.CreditNotes.GetIdentifiables(), DefaultCreditNotePrefix)
+	crn.Name = fmt.Sprintf("Credit note for %s", inv.Name)
+	crn.Amount = util.NewMoney(amount, open.Currency().Code)
+	crn.Path = path.Join(dstFolder, fmt.Sprintf("%s.pdf", crn.FileString()))
+	crn.Invoice = NewRef(inv.Id)
+	crn.Reason = reason
+	s.CreditNotes = append(s.CreditNotes, crn)
+	logrus.Infof("issued %s for %s", crn.String(), inv.String())
+	return crn, nil
+}
@@ -2,10 +2,12 @@ package schema
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
 
+	"github.com/72nd/acc/pkg/receipt"
 	"github.com/72nd/acc/pkg/util"
 	"github.com/creasty/defaults"
 	"github.com/logrusorgru/aurora"
@@ -107,6 +109,27 @@ func (e Expenses) Filter(from *time.Time, to *time.Time, identifier string) (Exp
 	return result, nil
 }
 
+// AdvancedBy returns all expenses advanced by the given employee.
+func (e Expenses) AdvancedBy(emp Party) Expenses {
+	var rsl Expenses
+	for i := range e {
+		if e[i].AdvancedByThirdParty && e[i].AdvancedThirdParty.Match(emp) {
+			rsl = append(rsl, e[i])
+		}
+	}
+	return rsl
+}
+
+// contains returns true if there is an expense with the given name and accrual date.
+func (e Expenses) contains(name, date string) bool {
+	for i := range e {
+		if e[i].Name == name && e[i].DateOfAccrual == date {
+			return true
+		}
+	}
+	return false
+}
+
 func (e Expenses) AssistedCompletion(s *Schema, doAll, autoSave, openAttachment, retainFocus bool) {
 	first := true
 	for i := range e {
@@ -157,10 +180,11 @@ func (e Expenses) SortByYear() map[int]Expenses {
 }
 
 // SetReferenceDestinations sets the destinations of the Reference fields.
-func (e Expenses) SetReferenceDestinations(cst, emp, trn, prj []Identifiable) {
+func (e Expenses) SetReferenceDestinations(cst, emp, trn, prj, inv []Identifiable) {
 	for i := range e {
 		if e[i].Billable {
 			e[i].ObligedCustomer.SetDestination(cst)
+			e[i].Invoice.SetDestination(inv)
 		}
 		if e[i].AdvancedByThirdParty {
 			e[i].AdvancedThirdParty.SetDestination(emp)
@@ -194,12 +218,16 @@ type Expense struct {
 	Amount util.Money `yaml:"amount" default:"" query:"amount"`
 	// Path is the full path to the business record document.
 	Path string `yaml:"path" default:"/path/to/expense.pdf" query:"path"`
+	// AssetHash is the SHA-256 hash of the content of the file at Path, used to detect receipts booked twice.
+	AssetHash string `yaml:"assetHash,omitempty" default:""`
 	// DateOfAccrual represents the day the obligation emerged.
 	DateOfAccrual string `yaml:"dateOfAccrual" default:"2019-12-20"`
 	// Billable states if the costs for the Expense will be forwarded to the customer.
 	Billable bool `yaml:"billable" default:"false"`
 	// ObligedCustomer refers to the customer which have to pay the Expense.
 	ObligedCustomer Ref `yaml:"obligedCustomerId" default:"" query:"customer"`
+	// Invoice refers to the invoice which forwarded a billable Expense to the obliged customer.
+	Invoice Ref `yaml:"invoiceId" default:""`
 	// AdvancedByThirdParty states if a third party (employee, etc.) advanced the payment of this expense for the company.
 	AdvancedByThirdParty bool `yaml:"advancedByThirdParty" default:"false"`
 	// AdvancePartyId refers to the third party which advanced the payment.
@@ -216,6 +244,14 @@ type Expense struct {
 	Internal bool `yaml:"internal" default:"true"`
 	// Project refers to the associated project.
 	Project Ref `yaml:"projectId" default:""`
+	// JournalOverride contains manual corrections of the generated journal entry.
+	JournalOverride *JournalOverride `yaml:"journalOverride,omitempty"`
+	// Draft states that the expense was created from a receipt in the inbox and wasn't completed yet.
+	Draft bool `yaml:"draft,omitempty" default:"false"`
+	// SupplierIban is the IBAN the expense has to be paid to, taken from the receipt.
+	SupplierIban string `yaml:"supplierIban,omitempty" default:""`
+	// PaymentReference has to be stated when paying the expense (ex: reference of a QR-bill).
+	PaymentReference string `yaml:"paymentReference,omitempty" default:""`
 }
 
 // NewExpense returns a new Expense element with the default values.
@@ -235,6 +271,72 @@ func NewExpenseWithUuid() Expense {
 	return exp
 }
 
+// NewDraftExpense returns a draft Expense for a receipt in the inbox. The name is derived
+// from the file name, the amount has to be entered when completing the draft.
+func NewDraftExpense(s Schema, path, date string) Expense {
+	exp := NewExpenseWithUuid()
+	exp.Identifier = SuggestNextIdentifier(s.Expenses.GetIdentifiables(), DefaultExpensePrefix)
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	exp.Name = strings.TrimSpace(strings.NewReplacer("_", " ", "-", " ").Replace(name))
+	exp.Amount = util.NewMoney(0, s.Currency)
+	exp.Path = path
+	exp.DateOfAccrual = date
+	exp.DateOfSettlement = ""
+	exp.Draft = true
+	if rcp := readReceipt(s, path); rcp != nil {
+		if rcp.Supplier != "" {
+			exp.Name = rcp.Supplier
+		}
+		if rcp.Amount.Money != nil {
+			exp.Amount = rcp.Amount
+		}
+		if rcp.Date != "" {
+			exp.DateOfAccrual = rcp.Date
+		}
+		exp.SupplierIban, exp.PaymentReference = rcp.IBAN, rcp.Reference()
+	}
+	return exp
+}
+
+// readReceipt extracts the values of a PDF receipt. Nil is returned for other files and
+// if no text could be extracted. The IBAN of the company itself is ignored.
+func readReceipt(s Schema, path string) *receipt.Receipt {
+	if strings.ToLower(filepath.Ext(path)) != ".pdf" || !util.FileExist(path) {
+		return nil
+	}
+	rcp, err := receipt.ReadReceipt(path, s.Currency)
+	if err != nil {
+		logrus.Warn("no values read from receipt: ", err)
+		return nil
+	}
+	if rcp.IBAN != "" && strings.EqualFold(rcp.IBAN, strings.Replace(s.Company.Iban, " ", "", -1)) {
+		rcp.IBAN = ""
+	}
+	return &rcp
+}
+
+// askPaymentDetails asks for the IBAN of the supplier and the payment reference. Only the
+// values found in the receipt are asked, they are offered as defaults.
+func (e *Expense) askPaymentDetails(iban, reference string) {
+	if iban != "" {
+		e.SupplierIban = util.AskString(
+			"Supplier IBAN",
+			"IBAN the expense has to be paid to",
+			iban)
+	}
+	if reference != "" {
+		e.PaymentReference = util.AskString(
+			"Payment Reference",
+			"Reference to state when paying the expense",
+			reference)
+	}
+}
+
+// printReceipt shows the values found in a receipt.
+func printReceipt(rcp *receipt.Receipt) {
+	fmt.Printf("%s %s\n", aurora.BrightMagenta(aurora.Bold("Found in receipt:")), aurora.BrightMagenta(rcp.Summary()))
+}
+
 // InteractiveNewExpense returns a new Expense based on the user input.
 func InteractiveNewExpense(s *Schema, asset string) Expense {
 	exp := NewExpenseWithUuid()
@@ -242,15 +344,6 @@ func InteractiveNewExpense(s *Schema, asset string) Expense {
 		"Identifier",
 		"Unique human readable identifier",
 		SuggestNextIdentifier(s.Expenses.GetIdentifiables(), DefaultExpensePrefix))
-	exp.Name = util.AskString(
-		"Name",
-		"Name of the expense",
-		"HAL 9000")
-	exp.Amount = util.AskMoney(
-		"Amount",
-		"How much did you spend?",
-		util.NewMoney(2342, s.Currency),
-		s.Currency)
 	if asset == "" {
 		exp.Path = util.AskString(
 			"Asset",
@@ -259,11 +352,37 @@ func InteractiveNewExpense(s *Schema, asset string) Expense {
 	} else {
 		exp.Path = asset
 	}
-	exp.DateOfAccrual = util.AskDate(
+	if other, ok := s.KnownAsset(exp.Path); ok {
+		logrus.Warnf("%s is already booked as %s", exp.Path, other.String())
+		if !util.AskBool(
+			"Add anyway?",
+			"The file (or one with the same content) belongs to another record",
+			false) {
+			logrus.Fatal("expense not added, receipt is already known")
+		}
+	}
+	name, amount, date, iban, reference := "", util.NewMoney(0, s.Currency), "", "", ""
+	if rcp := readReceipt(*s, exp.Path); rcp != nil {
+		printReceipt(rcp)
+		name, date, iban, reference = rcp.Supplier, rcp.Date, rcp.IBAN, rcp.Reference()
+		if rcp.Amount.Money != nil {
+			amount = rcp.Amount
+		}
+	}
+	exp.Name = util.AskString(
+		"Name",
+		"Name of the expense",
+		name)
+	exp.Amount = util.AskMoney(
+		"Amount",
+		"How much did you spend?",
+		amount,
+		amount.Currency().Code)
+	exp.DateOfAccrual = util.AskDateWithDefault(
 		"Date of Accrual",
 		"Date when the obligation accrued",
-		time.Now(),
-	)
+		date)
+	exp.askPaymentDetails(iban, reference)
 	exp.Billable = util.AskBool(
 		"Billable?",
 		"Is expense billable to customer?",
@@ -334,6 +453,44 @@ func (e Expense) AssistedCompletion(s *Schema, doAll, autoSave, openAttachment,
 		ext.Open()
 	}
 	fmt.Printf("%s %s\n", aurora.BrightMagenta(aurora.Bold("Optimize expense:")), aurora.BrightMagenta(e.String()))
+	missingAmount := e.Amount.Money == nil || e.Amount.Amount() == 0
+	missingDate := !util.ValidDate(util.DateFormat, e.DateOfAccrual)
+	if e.Name == "" || missingAmount || missingDate {
+		name, amount, date, iban, reference := e.Name, util.NewMoney(0, s.Currency), "", "", ""
+		if rcp := readReceipt(*s, e.Path); rcp != nil {
+			printReceipt(rcp)
+			if name == "" {
+				name = rcp.Supplier
+			}
+			if rcp.Amount.Money != nil {
+				amount = rcp.Amount
+			}
+			date = rcp.Date
+			if e.SupplierIban == "" && e.PaymentReference == "" {
+				iban, reference = rcp.IBAN, rcp.Reference()
+			}
+		}
+		if e.Name == "" {
+			e.Name = util.AskString(
+				"Name",
+				"Name of the expense",
+				name)
+		}
+		if missingAmount {
+			e.Amount = util.AskMoney(
+				"Amount",
+				"How much did you spend?",
+				amount,
+				amount.Currency().Code)
+		}
+		if missingDate {
+			e.DateOfAccrual = util.AskDateWithDefault(
+				"Date of Accrual",
+				"Date when the obligation accrued",
+				date)
+		}
+		e.askPaymentDetails(iban, reference)
+	}
 	if e.AdvancedByThirdParty && e.AdvancedThirdParty.Empty() {
 		e.AdvancedThirdParty = NewRef(util.AskStringFromListSearch(
 			"Advanced party",
@@ -384,6 +541,101 @@ func (e Expense) AssistedCompletion(s *Schema, doAll, autoSave, openAttachment,
 	return e
 }
 
+// DraftCompletion asks for all information of a draft Expense created from a receipt in
+// the inbox, the values of the draft are used as defaults. Returns false if the user
+// skipped the draft.
+func (e Expense) DraftCompletion(s *Schema, openAttachment, retainFocus bool) (Expense, bool) {
+	tmp := e
+	var ext util.External
+	if e.Path != "" && openAttachment {
+		ext = util.NewExternal(e.Path, retainFocus)
+		ext.Open()
+	}
+	fmt.Printf("%s %s\n", aurora.BrightMagenta(aurora.Bold("Complete draft:")), aurora.BrightMagenta(fmt.Sprintf("%s (%s)", e.Short(), e.Path)))
+	if rcp := readReceipt(*s, e.Path); rcp != nil {
+		printReceipt(rcp)
+	}
+	e.Identifier = util.AskString(
+		"Identifier",
+		"Unique human readable identifier",
+		e.Identifier)
+	e.Name = util.AskString(
+		"Name",
+		"Name of the expense",
+		e.Name)
+	e.Amount = util.AskMoney(
+		"Amount",
+		"How much did you spend?",
+		e.Amount,
+		s.Currency)
+	e.DateOfAccrual = util.AskDateWithDefault(
+		"Date of Accrual",
+		"Date when the obligation accrued",
+		e.DateOfAccrual)
+	e.askPaymentDetails(e.SupplierIban, e.PaymentReference)
+	e.Billable = util.AskBool(
+		"Billable?",
+		"Is expense billable to customer?",
+		e.Billable)
+	if e.Billable {
+		e.ObligedCustomer = NewRef(util.AskStringFromSearch(
+			"Obliged Customer",
+			"Customer which has to pay this expense",
+			s.Parties.CustomersSearchItems()))
+	}
+	e.AdvancedByThirdParty = util.AskBool(
+		"Advanced?",
+		"Was this expense advanced by some third party (ex: employee)?",
+		e.AdvancedByThirdParty)
+	if e.AdvancedByThirdParty {
+		e.AdvancedThirdParty = NewRef(util.AskStringFromSearch(
+			"Advanced party",
+			"Employee which advanced the expense",
+			s.Parties.EmployeesSearchItems()))
+	}
+	var cat interface{}
+	e.ExpenseCategory, cat = util.AskStringFromSearchWithNew(
+		"Expense Category",
+		"Used for journal generation",
+		s.JournalConfig.ExpenseCategories.SearchItems(),
+		InteractiveNewGenericExpenseCategory,
+		nil)
+	if cat != nil {
+		value, ok := cat.(ExpenseCategory)
+		if !ok {
+			logrus.Fatal("returned new expense category has different type")
+		}
+		s.JournalConfig.ExpenseCategories = append(s.JournalConfig.ExpenseCategories, value)
+		e.ExpenseCategory = value.Name
+	}
+	e.PaidWithDebit = util.AskBool(
+		"Paid with Debit",
+		"Was this expense directly paid via the main account debit card?",
+		e.PaidWithDebit)
+	e.Internal = util.AskBool(
+		"Internal",
+		"Has this expense an internal purpose?",
+		e.Internal)
+	if !e.Internal {
+		e.Project = NewRef(util.AskStringFromSearch(
+			"Project",
+			"Associated Project",
+			s.Projects.SearchItems()))
+	}
+
+	strategy := util.AskForStategy()
+	switch strategy {
+	case util.RedoStrategy:
+		ext.Close()
+		return tmp.DraftCompletion(s, openAttachment, retainFocus)
+	case util.SkipStrategy:
+		ext.Close()
+		return tmp, false
+	}
+	ext.Close()
+	return e, true
+}
+
 func (e *Expense) Repopulate(s Schema) {
 	trn, err := s.Statement.TransactionForDocument(e.Id)
 	if err != nil {
@@ -470,18 +722,18 @@ func (e Expense) Conditions() util.Conditions {
 			Condition: e.Billable && e.ObligedCustomer.Empty(),
 			Message:   "although billable, no obliged customer is set (ObligedCustomerId is empty)",
 		},
+		{
+			Condition: !e.Billable && !e.Invoice.Empty(),
+			Message:   "forwarded to a customer although not billable (InvoiceId is set)",
+		},
 		{
 			Condition: e.AdvancedByThirdParty && e.AdvancedThirdParty.Empty(),
 			Message:   "although advanced by third party, no third party id is set (AdvancedThirdPartyId is empty)",
 		},
 		{
-			Condition: e.DateOfSettlement != "" && util.ValidDate(util.DateFormat, e.DateOfSettlement),
+			Condition: e.DateOfSettlement != "" && !util.ValidDate(util.DateFormat, e.DateOfSettlement),
 			Message:   fmt.Sprintf("string «%s» could not be parsed with format YYYY-MM-DD", e.DateOfSettlement),
 		},
-		{
-			Condition: e.DateOfSettlement != "" && e.SettlementTransaction.Empty(),
-			Message:   "although date of settlement is set, the corresponding transaction is empty (SettlementTransactionId is empty",
-		},
 		{
 			Condition: e.ExpenseCategory == "",
 			Message:   "expense category is not set (ExpenseCategory is empty)",
@@ -490,6 +742,10 @@ func (e Expense) Conditions() util.Conditions {
 			Condition: !e.Internal && e.Project.Empty(),
 			Message:   "altrough not an internal expense, project id is not set (ProjectId is empty)",
 		},
+		{
+			Condition: e.Draft,
+			Message:   "draft created from the inbox isn't completed yet (use acc inbox)",
+		},
 	}
 }
 
@@ -501,6 +757,11 @@ func (e Expense) AccrualDateTime() time.Time {
 	return result
 }
 
+// IsSettled returns true if the expense was paid (or reimbursed to the employee).
+func (e Expense) IsSettled() bool {
+	return e.DateOfSettlement != "" || !e.SettlementTransaction.Empty()
+}
+
 func (e Expense) Match(from *time.Time, to *time.Time, identifier string) (bool, error) {
 	date, err := time.Parse(util.DateFormat, e.DateOfAccrual)
 	if err != nil {
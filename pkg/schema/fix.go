@@ -0,0 +1,292 @@
+package schema
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+// Fix is a single automatic correction of a field of a record.
+type Fix struct {
+	// Type of the corrected record.
+	Type string
+	// Element is the human readable representation of the record.
+	Element string
+	// Field is the name of the corrected field as used in the YAML files.
+	Field string
+	// Old is the value before the correction.
+	Old string
+	// New is the value after the correction.
+	New string
+	// Reason states why the value was corrected.
+	Reason string
+}
+
+// Fixes is a list of corrections applied by Schema.Fix.
+type Fixes []Fix
+
+// Diff returns the corrections grouped by record as a unified diff like text.
+func (f Fixes) Diff() string {
+	var headers []string
+	groups := make(map[string][]Fix)
+	for i := range f {
+		header := fmt.Sprintf("%s: %s", f[i].Type, f[i].Element)
+		if _, ok := groups[header]; !ok {
+			headers = append(headers, header)
+		}
+		groups[header] = append(groups[header], f[i])
+	}
+	var rsl strings.Builder
+	for i, header := range headers {
+		if i != 0 {
+			rsl.WriteString("\n")
+		}
+		rsl.WriteString(header + "\n")
+		for _, fix := range groups[header] {
+			fmt.Fprintf(&rsl, "  - %s: %s\n", fix.Field, fix.Old)
+			fmt.Fprintf(&rsl, "  + %s: %s  (%s)\n", fix.Field, fix.New, fix.Reason)
+		}
+	}
+	return rsl.String()
+}
+
+// fixable is implemented by all records which get an Id by the fix mode.
+type fixable interface {
+	Completable
+	util.Validatable
+	GetId() string
+}
+
+// fixer applies the corrections and records them.
+type fixer struct {
+	fixes Fixes
+}
+
+// set changes the value of a field and records the change.
+func (f *fixer) set(ele util.Validatable, field string, value *string, fixed, reason string) {
+	if *value == fixed {
+		return
+	}
+	f.fixes = append(f.fixes, Fix{
+		Type:    ele.Type(),
+		Element: ele.String(),
+		Field:   field,
+		Old:     *value,
+		New:     fixed,
+		Reason:  reason,
+	})
+	*value = fixed
+}
+
+// id sets a new Id if the record has none.
+func (f *fixer) id(ele fixable) {
+	if ele.GetId() != "" {
+		return
+	}
+	ele.SetId()
+	f.fixes = append(f.fixes, Fix{
+		Type:    ele.Type(),
+		Element: ele.String(),
+		Field:   "id",
+		New:     ele.GetId(),
+		Reason:  "missing id",
+	})
+}
+
+// date converts a date in another format to YYYY-MM-DD.
+func (f *fixer) date(ele util.Validatable, field string, value *string) {
+	if *value == "" || util.ValidDate(util.DateFormat, *value) {
+		return
+	}
+	if date, ok := util.NormalizeDate(*value); ok {
+		f.set(ele, field, value, date, "date not in YYYY-MM-DD")
+	}
+}
+
+// path makes an absolute path to a file within the base folder relative. This is only
+// done if the relative path points to the same file from the working directory.
+func (f *fixer) path(ele util.Validatable, base string, value *string) {
+	if base == "" || !filepath.IsAbs(*value) || !util.FileExist(*value) {
+		return
+	}
+	rel, err := filepath.Rel(base, *value)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return
+	}
+	if util.FileExist(rel) && filepath.Clean(util.AbsolutePathWithWD(rel)) == filepath.Clean(*value) {
+		f.set(ele, "path", value, rel, "absolute path within the project folder")
+	}
+}
+
+// hash updates the stored content hash of the file of a record if the file has changed.
+// Missing hashes are added when saving the schema.
+func (f *fixer) hash(ele util.Validatable, path string, value *string) {
+	if *value == "" || path == "" || !util.FileExist(path) {
+		return
+	}
+	if hash, err := util.FileHash(path); err == nil {
+		f.set(ele, "assetHash", value, hash, "content of the file has changed")
+	}
+}
+
+// settlement completes the settlement transaction and date of a document based on the
+// transactions associated with it. The transaction is only linked if exactly one
+// transaction refers to the document.
+func (f *fixer) settlement(s Schema, ele fixable, trnField string, trn *Ref, dateField string, date *string) {
+	if trn.Empty() {
+		var matches []Transaction
+		for i := range s.Statement.Transactions {
+			if s.Statement.Transactions[i].AssociatedDocument.Id == ele.GetId() {
+				matches = append(matches, s.Statement.Transactions[i])
+			}
+		}
+		if len(matches) != 1 {
+			return
+		}
+		f.set(ele, trnField, &trn.Id, matches[0].Id, "transaction is associated with the document")
+	}
+	if date == nil || *date != "" {
+		return
+	}
+	for i := range s.Statement.Transactions {
+		if s.Statement.Transactions[i].Id == trn.Id && util.ValidDate(util.DateFormat, s.Statement.Transactions[i].Date) {
+			f.set(ele, dateField, date, s.Statement.Transactions[i].Date, "date of the settlement transaction")
+		}
+	}
+}
+
+// associatedDocument links a transaction with its document if exactly one document
+// refers to the transaction as settlement.
+func (f *fixer) associatedDocument(s Schema, trn *Transaction) {
+	if !trn.AssociatedDocument.Empty() || trn.Id == "" {
+		return
+	}
+	var docs []string
+	for _, exp := range s.Expenses {
+		if exp.SettlementTransaction.Id == trn.Id {
+			docs = append(docs, exp.Id)
+		}
+	}
+	for _, inv := range s.Invoices {
+		if inv.SettlementTransaction.Id == trn.Id {
+			docs = append(docs, inv.Id)
+		}
+	}
+	for _, mrc := range s.MiscRecords {
+		if mrc.Transaction.Id == trn.Id {
+			docs = append(docs, mrc.Id)
+		}
+	}
+	for _, sal := range s.Salaries {
+		if sal.SettlementTransaction.Id == trn.Id {
+			docs = append(docs, sal.Id)
+		}
+	}
+	if len(docs) == 1 && docs[0] != "" {
+		f.set(trn, "associatedDocumentId", &trn.AssociatedDocument.Id, docs[0], "document is settled by the transaction")
+	}
+}
+
+// Fix applies all corrections which can be done without human judgement and returns
+// them. These are:
+//
+// - missing Ids
+// - dates in another unambiguous format than YYYY-MM-DD
+// - absolute paths to files within the base folder
+// - outdated content hashes of files
+// - missing settlement transactions and dates which can be derived from the statement
+// - missing associated documents of transactions which can be derived from the documents
+//
+// The schema isn't saved.
+func (s *Schema) Fix() Fixes {
+	f := fixer{}
+	for i := range s.CreditNotes {
+		ele := &s.CreditNotes[i]
+		f.id(ele)
+		f.date(ele, "date", &ele.Date)
+		f.path(ele, s.BaseFolder, &ele.Path)
+	}
+	for i := range s.Expenses {
+		ele := &s.Expenses[i]
+		f.id(ele)
+		f.date(ele, "dateOfAccrual", &ele.DateOfAccrual)
+		f.date(ele, "dateOfSettlement", &ele.DateOfSettlement)
+		f.path(ele, s.BaseFolder, &ele.Path)
+		f.hash(ele, ele.Path, &ele.AssetHash)
+	}
+	for i := range s.FixedAssets {
+		f.id(&s.FixedAssets[i])
+	}
+	for i := range s.Invoices {
+		ele := &s.Invoices[i]
+		f.id(ele)
+		f.date(ele, "sendDate", &ele.SendDate)
+		f.date(ele, "mailDate", &ele.MailDate)
+		f.date(ele, "dateOfSettlement", &ele.DateOfSettlement)
+		f.path(ele, s.BaseFolder, &ele.Path)
+		f.hash(ele, ele.Path, &ele.AssetHash)
+	}
+	for i := range s.MiscRecords {
+		ele := &s.MiscRecords[i]
+		f.id(ele)
+		f.date(ele, "date", &ele.Date)
+		f.path(ele, s.BaseFolder, &ele.Path)
+		f.hash(ele, ele.Path, &ele.AssetHash)
+	}
+	for i := range s.Offers {
+		ele := &s.Offers[i]
+		f.id(ele)
+		f.date(ele, "date", &ele.Date)
+		f.date(ele, "validUntil", &ele.ValidUntil)
+		f.path(ele, s.BaseFolder, &ele.Path)
+	}
+	for i := range s.Parties.Employees {
+		f.id(&s.Parties.Employees[i])
+	}
+	for i := range s.Parties.Customers {
+		f.id(&s.Parties.Customers[i])
+	}
+	for i := range s.Projects {
+		f.id(&s.Projects[i])
+	}
+	for i := range s.RecurringTemplates {
+		ele := &s.RecurringTemplates[i]
+		f.id(ele)
+		f.date(ele, "start", &ele.Start)
+		f.date(ele, "end", &ele.End)
+		f.date(ele, "lastOccurrence", &ele.LastOccurrence)
+	}
+	for i := range s.Salaries {
+		ele := &s.Salaries[i]
+		f.id(ele)
+		f.date(ele, "dateOfSettlement", &ele.DateOfSettlement)
+	}
+	for i := range s.Statement.Transactions {
+		ele := &s.Statement.Transactions[i]
+		f.id(ele)
+		f.date(ele, "date", &ele.Date)
+	}
+
+	for i := range s.Expenses {
+		ele := &s.Expenses[i]
+		f.settlement(*s, ele, "settlementTransactionId", &ele.SettlementTransaction, "dateOfSettlement", &ele.DateOfSettlement)
+	}
+	for i := range s.Invoices {
+		ele := &s.Invoices[i]
+		f.settlement(*s, ele, "settlementTransactionId", &ele.SettlementTransaction, "dateOfSettlement", &ele.DateOfSettlement)
+	}
+	for i := range s.MiscRecords {
+		ele := &s.MiscRecords[i]
+		f.settlement(*s, ele, "settlementTransactionId", &ele.Transaction, "", nil)
+	}
+	for i := range s.Salaries {
+		ele := &s.Salaries[i]
+		f.settlement(*s, ele, "settlementTransactionId", &ele.SettlementTransaction, "dateOfSettlement", &ele.DateOfSettlement)
+	}
+	for i := range s.Statement.Transactions {
+		f.associatedDocument(*s, &s.Statement.Transactions[i])
+	}
+	return f.fixes
+}
@@ -0,0 +1,69 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestFix(t *testing.T) {
+	s := Schema{
+		Expenses: Expenses{
+			{Id: "", Identifier: "e-1", Name: "Wood", Amount: util.NewMoney(50000, "CHF"), DateOfAccrual: "2019.12.24"},
+			{Id: "exp-2", Identifier: "e-2", Name: "Paint", Amount: util.NewMoney(8000, "CHF"), DateOfAccrual: "12/24/2019"},
+		},
+		Invoices: Invoices{
+			{Id: "inv-1", Identifier: "i-1", Name: "Hamlet", Amount: util.NewMoney(300000, "CHF"), SendDate: "2019/12/1"},
+		},
+		Statement: Statement{Transactions: []Transaction{
+			{Id: "trn-1", Identifier: "t-1", Amount: util.NewMoney(300000, "CHF"), Date: "2020-01-10", AssociatedDocument: NewRef("inv-1")},
+			{Id: "trn-2", Identifier: "t-2", Amount: util.NewMoney(8000, "CHF"), Date: "2020-01-12"},
+		}},
+	}
+	s.Expenses[1].SettlementTransaction = NewRef("trn-2")
+
+	fixes := s.Fix()
+	if s.Expenses[0].Id == "" || s.Expenses[0].DateOfAccrual != "2019-12-24" {
+		t.Errorf("id and date of the first expense not fixed: %+v", s.Expenses[0])
+	}
+	if s.Expenses[1].DateOfAccrual != "12/24/2019" {
+		t.Error("ambiguous date was changed")
+	}
+	if s.Expenses[1].DateOfSettlement != "2020-01-12" {
+		t.Errorf("settlement date of the second expense is «%s»", s.Expenses[1].DateOfSettlement)
+	}
+	inv := s.Invoices[0]
+	if inv.SendDate != "2019-12-01" || inv.SettlementTransaction.Id != "trn-1" || inv.DateOfSettlement != "2020-01-10" {
+		t.Errorf("invoice not fixed: %+v", inv)
+	}
+	if s.Statement.Transactions[1].AssociatedDocument.Id != "exp-2" {
+		t.Error("associated document of the second transaction not set")
+	}
+	if len(fixes) != 7 {
+		t.Errorf("expected 7 fixes, got %d:\n%s", len(fixes), fixes.Diff())
+	}
+	if diff := fixes.Diff(); !strings.Contains(diff, "  - sendDate: 2019/12/1\n  + sendDate: 2019-12-01") {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
+
+func TestDateConditions(t *testing.T) {
+	flawed := func(cnd util.Conditions, date string) bool {
+		for i := range cnd {
+			if cnd[i].Condition && strings.Contains(cnd[i].Message, date) {
+				return true
+			}
+		}
+		return false
+	}
+	for date, expected := range map[string]bool{"2020-01-12": false, "12.01.2020": true} {
+		exp := Expense{Amount: util.NewMoney(8000, "CHF"), DateOfSettlement: date}
+		if flawed(exp.Conditions(), date) != expected {
+			t.Errorf("settlement date «%s» of expense reported: %t", date, !expected)
+		}
+		if flawed(MiscRecord{Date: date}.Conditions(), date) != expected {
+			t.Errorf("date «%s» of misc record reported: %t", date, !expected)
+		}
+	}
+}
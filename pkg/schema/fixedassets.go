@@ -0,0 +1,328 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultFixedAssetsFile = "fixed-assets.yaml"
+const DefaultFixedAssetPrefix = "fa-"
+
+// DepreciationMethod states how the value of a FixedAsset is reduced over the years.
+type DepreciationMethod int
+
+const (
+	// StraightLineDepreciation reduces the value by the same amount every year.
+	StraightLineDepreciation DepreciationMethod = iota
+	// DecliningBalanceDepreciation reduces the value by a fixed rate of the remaining book value.
+	DecliningBalanceDepreciation
+)
+
+// String returns the name of the method.
+func (d DepreciationMethod) String() string {
+	switch d {
+	case StraightLineDepreciation:
+		return "straight-line"
+	case DecliningBalanceDepreciation:
+		return "declining-balance"
+	}
+	return "unknown"
+}
+
+// FixedAssets is the register of all fixed assets of the company.
+type FixedAssets []FixedAsset
+
+// OpenFixedAssets opens the FixedAssets saved in the YAML file given by the path. As
+// the asset register is optional, an empty collection is returned if the file doesn't exist.
+func OpenFixedAssets(path string) FixedAssets {
+	var fas FixedAssets
+	if !util.FileExist(path) {
+		return fas
+	}
+	util.OpenYaml(&fas, path, "fixed-assets")
+	return fas
+}
+
+// Save writes the element as YAML file to the given path. Nothing is written when the
+// register is empty and there is no existing file.
+func (f FixedAssets) Save(path string) {
+	if len(f) == 0 && !util.FileExist(path) {
+		return
+	}
+	util.SaveToYaml(f, path, "fixed-assets")
+}
+
+// GetIdentifiables returns the a slice of all identifiers. This is used for the
+// identifier suggestion while interactively adding a new FixedAsset.
+func (f FixedAssets) GetIdentifiables() []Identifiable {
+	rsl := make([]Identifiable, len(f))
+	for i := range f {
+		rsl[i] = f[i]
+	}
+	return rsl
+}
+
+// Validate all FixedAssets.
+func (f FixedAssets) Validate() util.ValidateResults {
+	var rsl util.ValidateResults
+	for i := range f {
+		rsl = append(rsl, util.Check(f[i]))
+	}
+	return rsl
+}
+
+// SetReferenceDestinations sets the destination of the expense references.
+func (f FixedAssets) SetReferenceDestinations(exp []Identifiable) {
+	for i := range f {
+		f[i].Expense.SetDestination(exp)
+	}
+}
+
+// FixedAsset is a good (laptop, stage equipment etc.) which is used over several years
+// and therefore is capitalised and depreciated over its useful life. The acquisition
+// cost and date are taken from the linked Expense.
+type FixedAsset struct {
+	// Id is the internal unique identifier of the FixedAsset.
+	Id string `yaml:"id" default:"1"`
+	// Identifier is a unique user-chosen identifier, should be human readable.
+	Identifier string `yaml:"identifier" default:"fa-1"`
+	// Name of the FixedAsset.
+	Name string `yaml:"name" default:"Laptop"`
+	// Expense refers to the expense of the acquisition.
+	Expense Ref `yaml:"expenseId" default:"" query:"expense"`
+	// UsefulLife states the number of years the asset is depreciated.
+	UsefulLife int `yaml:"usefulLife" default:"3"`
+	// Method of the depreciation.
+	Method DepreciationMethod `yaml:"method" default:"0"`
+	// DecliningRate is the yearly depreciation rate in percent for the declining-balance
+	// method. If zero, the double of the straight-line rate is used.
+	DecliningRate int `yaml:"decliningRate" default:"0"`
+	// ResidualValue is the book value at the end of the useful life.
+	ResidualValue util.Money `yaml:"residualValue" default:""`
+	// Account is the balance sheet account of the asset. If empty, the fixed assets
+	// account of the journal config is used.
+	Account string `yaml:"account" default:""`
+	// JournalOverride contains manual corrections of the generated journal entries.
+	JournalOverride *JournalOverride `yaml:"journalOverride,omitempty"`
+}
+
+// NewFixedAsset returns a new FixedAsset element with the default values. The residual
+// value is in the given currency.
+func NewFixedAsset(currency string) FixedAsset {
+	fas := FixedAsset{}
+	if err := defaults.Set(&fas); err != nil {
+		logrus.Fatal("error setting defaults for fixed asset: ", err)
+	}
+	fas.Id = GetUuid()
+	fas.ResidualValue = util.NewMoney(0, currency)
+	return fas
+}
+
+// InteractiveNewFixedAsset returns a new FixedAsset based on the user input.
+func InteractiveNewFixedAsset(s Schema) FixedAsset {
+	fas := NewFixedAsset(s.Currency)
+	fas.Identifier = util.AskString(
+		"Identifier",
+		"Unique human readable identifier",
+		SuggestNextIdentifier(s.FixedAssets.GetIdentifiables(), DefaultFixedAssetPrefix))
+	fas.Name = util.AskString(
+		"Name",
+		"Name of the asset",
+		"Laptop")
+	fas.Expense = NewRef(util.AskStringFromSearch(
+		"Expense",
+		"Expense of the acquisition",
+		s.Expenses.SearchItems()))
+	fas.UsefulLife = util.AskInt(
+		"Useful life",
+		"Number of years the asset will be depreciated",
+		3)
+	fas.Method = DepreciationMethod(util.AskIntFromList(
+		"Method",
+		"Method of the depreciation",
+		util.SearchItems{
+			util.SearchItem{
+				Name:        "Straight-line",
+				Value:       int(StraightLineDepreciation),
+				SearchValue: "1 straight-line linear",
+			},
+			util.SearchItem{
+				Name:        "Declining-balance",
+				Value:       int(DecliningBalanceDepreciation),
+				SearchValue: "2 declining-balance degressive",
+			},
+		}))
+	if fas.Method == DecliningBalanceDepreciation {
+		fas.DecliningRate = util.AskInt(
+			"Rate",
+			"Yearly depreciation rate in percent",
+			200/fas.UsefulLife)
+	}
+	fas.ResidualValue = util.AskMoney(
+		"Residual value",
+		"Book value at the end of the useful life",
+		util.NewMoney(0, s.Currency),
+		s.Currency)
+	return fas
+}
+
+// SetId generates a unique id for the element if there isn't already one defined.
+func (f *FixedAsset) SetId() {
+	if f.Id != "" {
+		return
+	}
+	f.Id = GetUuid()
+}
+
+// GetId returns the id of the FixedAsset.
+func (f FixedAsset) GetId() string {
+	return f.Id
+}
+
+// GetIdentifier returns the identifier of the FixedAsset.
+func (f FixedAsset) GetIdentifier() string {
+	return f.Identifier
+}
+
+// String returns a human readable representation of the element.
+func (f FixedAsset) String() string {
+	return fmt.Sprintf("fixed asset %s (%s)", f.Name, f.Identifier)
+}
+
+// Short returns a short representation of the element.
+func (f FixedAsset) Short() string {
+	return fmt.Sprintf("%s (%s)", f.Name, f.Identifier)
+}
+
+// Type returns a string with the type name of the element.
+func (f FixedAsset) Type() string {
+	return "FixedAsset"
+}
+
+// Conditions returns the validation conditions.
+func (f FixedAsset) Conditions() util.Conditions {
+	return util.Conditions{
+		{
+			Condition: f.Id == "",
+			Message:   "unique identifier not set (Id is empty)",
+		},
+		{
+			Condition: f.Identifier == "",
+			Message:   "human readable identifier not set (Identifier is empty)",
+		},
+		{
+			Condition: f.Name == "",
+			Message:   "name not set (Name is empty)",
+		},
+		{
+			Condition: f.Expense.Empty(),
+			Message:   "acquisition expense not set (Expense is empty)",
+		},
+		{
+			Condition: f.UsefulLife < 1,
+			Message:   "useful life has to be at least one year",
+		},
+		{
+			Condition: f.Method != StraightLineDepreciation && f.Method != DecliningBalanceDepreciation,
+			Message:   fmt.Sprintf("unknown depreciation method %d", f.Method),
+		},
+		{
+			Condition: f.DecliningRate < 0 || f.DecliningRate > 100,
+			Message:   "declining rate has to be between 0 and 100 percent",
+		},
+		{
+			Condition: f.ResidualValue.Money == nil,
+			Message:   "residual value not set (ResidualValue is empty)",
+		},
+	}
+}
+
+// DepreciationYear is one line of the depreciation schedule of a FixedAsset. All
+// amounts are in cents.
+type DepreciationYear struct {
+	Year         int
+	OpeningValue int64
+	Depreciation int64
+	ClosingValue int64
+}
+
+// AcquisitionCost returns the cost of the asset as stated in the acquisition expense.
+func (f FixedAsset) AcquisitionCost(s Schema) (util.Money, error) {
+	exp, err := s.Expenses.ExpenseByRef(f.Expense)
+	if err != nil {
+		return util.Money{}, fmt.Errorf("acquisition expense of %s: %s", f.String(), err)
+	}
+	if exp.Amount.Money == nil {
+		return util.Money{}, fmt.Errorf("acquisition expense %s has no amount", exp.String())
+	}
+	return exp.Amount, nil
+}
+
+// AcquisitionDate returns the accrual date of the acquisition expense.
+func (f FixedAsset) AcquisitionDate(s Schema) (time.Time, error) {
+	exp, err := s.Expenses.ExpenseByRef(f.Expense)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("acquisition expense of %s: %s", f.String(), err)
+	}
+	return exp.AccrualDateTime(), nil
+}
+
+// Schedule returns the yearly depreciation over the useful life of the asset starting
+// with the year of the acquisition. Each year is depreciated in full. The straight-line
+// method books the difference between the acquisition cost and the residual value in
+// equal parts (the remainder of the division goes into the last year). The declining-
+// balance method applies the rate to the book value and depreciates the remaining
+// amount down to the residual value in the last year.
+func (f FixedAsset) Schedule(s Schema) ([]DepreciationYear, error) {
+	if f.UsefulLife < 1 {
+		return nil, fmt.Errorf("useful life of %s has to be at least one year", f.String())
+	}
+	cost, err := f.AcquisitionCost(s)
+	if err != nil {
+		return nil, err
+	}
+	date, err := f.AcquisitionDate(s)
+	if err != nil {
+		return nil, err
+	}
+	var residual int64
+	if f.ResidualValue.Money != nil {
+		residual = f.ResidualValue.Amount()
+	}
+	if residual > cost.Amount() {
+		return nil, fmt.Errorf("residual value of %s is higher than the acquisition cost", f.String())
+	}
+
+	rate := int64(f.DecliningRate)
+	if rate == 0 {
+		rate = int64(200 / f.UsefulLife)
+	}
+	rsl := make([]DepreciationYear, f.UsefulLife)
+	value := cost.Amount()
+	for i := range rsl {
+		var dep int64
+		switch {
+		case i == f.UsefulLife-1:
+			dep = value - residual
+		case f.Method == DecliningBalanceDepreciation:
+			dep = value * rate / 100
+		default:
+			dep = (cost.Amount() - residual) / int64(f.UsefulLife)
+		}
+		if value-dep < residual {
+			dep = value - residual
+		}
+		rsl[i] = DepreciationYear{
+			Year:         date.Year() + i,
+			OpeningValue: value,
+			Depreciation: dep,
+			ClosingValue: value - dep,
+		}
+		value -= dep
+	}
+	return rsl, nil
+}
@@ -0,0 +1,220 @@
+package schema
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/72nd/acc/pkg/util"
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+// InboxConfig states where new receipts are collected and where they are stored after
+// an expense was created for them (see acc inbox).
+type InboxConfig struct {
+	// Folder where new receipts are dropped (ex: a shared folder), relative paths are
+	// relative to the project folder.
+	Folder string `yaml:"folder" default:"inbox"`
+	// AssetFolder is the folder the receipts are moved to in flat mode, relative paths are
+	// relative to the project folder. In distributed mode the receipts are moved to the
+	// folder of the project of the expense or to the internal folder.
+	AssetFolder string `yaml:"assetFolder" default:"receipts"`
+}
+
+// NewInboxConfig returns a new InboxConfig with the default values.
+func NewInboxConfig() InboxConfig {
+	ibx := InboxConfig{}
+	if err := defaults.Set(&ibx); err != nil {
+		logrus.Fatal("error setting defaults for inbox config: ", err)
+	}
+	return ibx
+}
+
+// inboxPath returns the absolute path of a folder of the inbox config.
+func (s Schema) inboxPath(folder string) string {
+	if filepath.IsAbs(folder) {
+		return folder
+	}
+	return filepath.Join(s.BaseFolder, folder)
+}
+
+// InboxFiles returns the files in the inbox folder which aren't referenced by an expense
+// yet. Hidden files and sub folders are ignored. If folder is empty, the folder of the
+// InboxConfig is used.
+func (s Schema) InboxFiles(folder string) ([]string, error) {
+	if folder == "" {
+		folder = s.inboxPath(s.InboxConfig.Folder)
+	}
+	entries, err := ioutil.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("error reading inbox folder: %s", err)
+	}
+	known := make(map[string]bool)
+	for i := range s.Expenses {
+		if s.Expenses[i].Path != "" {
+			known[util.AbsolutePathWithWD(s.Expenses[i].Path)] = true
+		}
+	}
+	var rsl []string
+	for i := range entries {
+		pth := util.AbsolutePathWithWD(filepath.Join(folder, entries[i].Name()))
+		if entries[i].IsDir() || strings.HasPrefix(entries[i].Name(), ".") || known[pth] {
+			continue
+		}
+		rsl = append(rsl, pth)
+	}
+	return rsl, nil
+}
+
+// ScanInbox creates a draft expense for each new file in the inbox folder and moves the
+// file to the asset folder (see InboxConfig). The drafts are appended to the expenses of
+// the schema and returned. If folder is empty, the folder of the InboxConfig is used. On
+// an error the drafts created so far are returned as well, their files were already moved
+// and the schema has to be saved nevertheless.
+func (s *Schema) ScanInbox(folder string) (Expenses, error) {
+	files, err := s.InboxFiles(folder)
+	if err != nil {
+		return nil, err
+	}
+	var rsl Expenses
+	for i := range files {
+		stat, err := os.Stat(files[i])
+		if err != nil {
+			return rsl, err
+		}
+		if other, ok := s.KnownAsset(files[i]); ok {
+			logrus.Warnf("%s has the same content as the file of %s, the draft may be a duplicate", files[i], other.String())
+		}
+		exp := NewDraftExpense(*s, files[i], stat.ModTime().Format(util.DateFormat))
+		if exp.Path, err = moveAsset(files[i], s.expenseFolder(exp)); err != nil {
+			return rsl, err
+		}
+		logrus.Infof("draft expense %s created for %s", exp.Identifier, exp.Path)
+		s.Expenses = append(s.Expenses, exp)
+		rsl = append(rsl, exp)
+	}
+	return rsl, nil
+}
+
+// Drafts returns all draft expenses.
+func (e Expenses) Drafts() Expenses {
+	var rsl Expenses
+	for i := range e {
+		if e[i].Draft {
+			rsl = append(rsl, e[i])
+		}
+	}
+	return rsl
+}
+
+// CompleteDrafts walks through the completion of the given draft expenses. The receipt of
+// each completed expense is moved to the folder of its project (in distributed mode) and
+// the schema is saved. Returns the number of completed drafts.
+func (s *Schema) CompleteDrafts(drafts Expenses, openAttachment, retainFocus bool) int {
+	done := 0
+	for i := range s.Expenses {
+		if !s.Expenses[i].Draft || !drafts.hasId(s.Expenses[i].Id) {
+			continue
+		}
+		if done != 0 {
+			fmt.Println()
+		}
+		exp, ok := s.Expenses[i].DraftCompletion(s, openAttachment, retainFocus)
+		if !ok {
+			continue
+		}
+		exp.Draft = false
+		pth, err := moveAsset(exp.Path, s.expenseFolder(exp))
+		if err != nil {
+			logrus.Errorf("receipt of %s couldn't be moved: %s", exp.Short(), err)
+		} else {
+			exp.Path = pth
+		}
+		s.Expenses[i] = exp
+		s.Save()
+		done++
+	}
+	if len(drafts) != done {
+		logrus.Infof("%d of %d drafts completed, the others remain in the project as drafts", done, len(drafts))
+	}
+	return done
+}
+
+// hasId returns true if there is an expense with the given id.
+func (e Expenses) hasId(id string) bool {
+	for i := range e {
+		if e[i].Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// expenseFolder returns the folder the receipt of an expense is stored in.
+func (s Schema) expenseFolder(exp Expense) string {
+	if s.ExpenseFolder != nil {
+		return s.ExpenseFolder(s, exp)
+	}
+	return s.inboxPath(s.InboxConfig.AssetFolder)
+}
+
+// moveAsset moves the file into the folder and returns the new path. If there is already
+// a file with the same name, a number is appended. The returned path is relative to the
+// working directory if the file is within it.
+func moveAsset(src, folder string) (string, error) {
+	src = util.AbsolutePathWithWD(src)
+	folder = util.AbsolutePathWithWD(folder)
+	dst := src
+	if filepath.Dir(src) != filepath.Clean(folder) {
+		if err := os.MkdirAll(folder, 0777); err != nil {
+			return "", fmt.Errorf("error while creating folder: %s", err)
+		}
+		ext := filepath.Ext(src)
+		name := strings.TrimSuffix(filepath.Base(src), ext)
+		dst = filepath.Join(folder, name+ext)
+		for i := 1; util.FileExist(dst); i++ {
+			dst = filepath.Join(folder, fmt.Sprintf("%s-%d%s", name, i, ext))
+		}
+		if err := moveFile(src, dst); err != nil {
+			return "", err
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return dst, nil
+	}
+	if rel, err := filepath.Rel(wd, dst); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel, nil
+	}
+	return dst, nil
+}
+
+// moveFile renames the file. If this isn't possible (ex: different devices) the file is
+// copied and the source removed.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	in.Close()
+	return os.Remove(src)
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestScanInbox(t *testing.T) {
+	base, err := ioutil.TempDir("", "acc-inbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	for _, name := range []string{"inbox/coop_receipt.pdf", "inbox/.hidden", "receipts/coop_receipt.pdf"} {
+		if err := os.MkdirAll(filepath.Join(base, filepath.Dir(name)), 0777); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(base, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := Schema{
+		BaseFolder:  base,
+		Currency:    "CHF",
+		Expenses:    Expenses{{Id: "exp-1", Identifier: "e-1", Path: filepath.Join(base, "receipts/coop_receipt.pdf")}},
+		InboxConfig: InboxConfig{Folder: "inbox", AssetFolder: "receipts"},
+	}
+
+	drafts, err := s.ScanInbox("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(drafts) != 1 || len(s.Expenses) != 2 {
+		t.Fatalf("expected one draft, got %d (%d expenses)", len(drafts), len(s.Expenses))
+	}
+	exp := s.Expenses[1]
+	if !exp.Draft || exp.Identifier != "e-2" || exp.Name != "coop receipt" || exp.DateOfSettlement != "" {
+		t.Errorf("unexpected draft %+v", exp)
+	}
+	if filepath.Base(exp.Path) != "coop_receipt-1.pdf" || !util.FileExist(exp.Path) {
+		t.Errorf("receipt wasn't moved to the asset folder: %s", exp.Path)
+	}
+	if util.FileExist(filepath.Join(base, "inbox/coop_receipt.pdf")) || !util.FileExist(filepath.Join(base, "inbox/.hidden")) {
+		t.Error("inbox contains the wrong files")
+	}
+	if d := s.Expenses.Drafts(); len(d) != 1 || d[0].Id != exp.Id {
+		t.Errorf("unexpected drafts %+v", d)
+	}
+	if len(util.Check(exp).Conditions) == 0 {
+		t.Error("draft is valid")
+	}
+}
+
+func TestDraftPaymentDetails(t *testing.T) {
+	base, err := ioutil.TempDir("", "acc-inbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	pdf := `%PDF-1.4
+1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj
+2 0 obj << /Type /Pages /Kids [3 0 R] /Count 1 /Resources << /Font << /F1 4 0 R >> >> >> endobj
+3 0 obj << /Type /Page /Parent 2 0 R /MediaBox [0 0 595 842] /Contents 5 0 R >> endobj
+4 0 obj << /Type /Font /Subtype /Type1 /BaseFont /Helvetica >> endobj
+5 0 obj << /Length 0 >>
+stream
+BT /F1 12 Tf 14 TL 50 780 Td (Brocki Bern AG) Tj T* (Total CHF 42.00) Tj T* (IBAN CH93 0076 2011 6238 5295 7) Tj ET
+endstream
+endobj
+trailer << /Root 1 0 R >>
+%%EOF`
+	path := filepath.Join(base, "receipt.pdf")
+	if err := ioutil.WriteFile(path, []byte(pdf), 0644); err != nil {
+		t.Fatal(err)
+	}
+	exp := NewDraftExpense(Schema{Currency: "CHF"}, path, "2020-03-01")
+	if exp.Name != "Brocki Bern AG" || exp.SupplierIban != "CH9300762011623852957" {
+		t.Errorf("payment details not taken from receipt: %+v", exp)
+	}
+	exp = NewDraftExpense(Schema{Currency: "CHF", Company: Company{Iban: "CH93 0076 2011 6238 5295 7"}}, path, "2020-03-01")
+	if exp.SupplierIban != "" {
+		t.Error("IBAN of the company was taken as supplier IBAN")
+	}
+}
@@ -0,0 +1,402 @@
+package schema
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+// Names of the record kinds used by the referential integrity checks.
+const (
+	creditNoteKind  = "credit note"
+	customerKind    = "customer"
+	employeeKind    = "employee"
+	expenseKind     = "expense"
+	invoiceKind     = "invoice"
+	miscRecordKind  = "misc record"
+	offerKind       = "offer"
+	projectKind     = "project"
+	salaryKind      = "salary"
+	transactionKind = "transaction"
+)
+
+// ValidateIntegrity checks the relations between the records of the schema. In contrast to
+// the Conditions of the single elements these checks need the whole schema:
+//
+// - references which point to no existing record
+// - Ids or Identifiers used more than once within a type
+// - transactions whose amount differs from their associated document
+// - settled expenses and invoices without transaction or whose transaction isn't linked back
+// - documents linked from several transactions
+// - files referenced by more than one record
+// - different files with identical content (ex: the same receipt submitted twice)
+// - expenses with nearly identical amount, date and name
+func (s Schema) ValidateIntegrity() util.ValidateResults {
+	var rsl util.ValidateResults
+	rsl = append(rsl, s.validateReferences()...)
+	rsl = append(rsl, s.validateDuplicates()...)
+	rsl = append(rsl, s.validateTransactionAmounts()...)
+	rsl = append(rsl, s.validateSettlementLinks()...)
+	rsl = append(rsl, s.validateDocumentLinks()...)
+	rsl = append(rsl, s.validateFilePaths()...)
+	rsl = append(rsl, s.validateAssetContents()...)
+	rsl = append(rsl, s.validateSimilarExpenses()...)
+	return rsl
+}
+
+// idIndex contains the ids of all records per kind.
+type idIndex map[string]map[string]bool
+
+func newIdIndex(s Schema) idIndex {
+	idx := make(idIndex)
+	add := func(kind string, idt []Identifiable) {
+		idx[kind] = make(map[string]bool)
+		for i := range idt {
+			idx[kind][idt[i].GetId()] = true
+		}
+	}
+	add(creditNoteKind, s.CreditNotes.GetIdentifiables())
+	add(customerKind, s.Parties.GetCustomerIdentifiables())
+	add(employeeKind, s.Parties.GetEmployeeIdentifiables())
+	add(expenseKind, s.Expenses.GetIdentifiables())
+	add(invoiceKind, s.Invoices.GetIdentifiables())
+	add(miscRecordKind, s.MiscRecords.GetIdentifiables())
+	add(offerKind, s.Offers.GetIdentifiables())
+	add(projectKind, s.Projects.GetIdentifiables())
+	add(salaryKind, s.Salaries.GetIdentifiables())
+	add(transactionKind, s.Statement.GetIdentifiables())
+	return idx
+}
+
+// check returns a condition if the reference is set but points to no record of the
+// given kinds.
+func (x idIndex) check(ref Ref, field string, kinds ...string) util.Conditions {
+	if ref.Empty() {
+		return nil
+	}
+	for i := range kinds {
+		if x[kinds[i]][ref.Id] {
+			return nil
+		}
+	}
+	return util.Conditions{{
+		Condition: true,
+		Message:   fmt.Sprintf("%s «%s» points to no existing %s", field, ref.Id, strings.Join(kinds, "/")),
+		Level:     util.FundamentalFlaw,
+	}}
+}
+
+// appendResult adds a result for the element if there are any conditions.
+func appendResult(rsl util.ValidateResults, ele util.Validatable, conditions util.Conditions) util.ValidateResults {
+	if len(conditions) == 0 {
+		return rsl
+	}
+	return append(rsl, util.ValidateResult{Element: ele, Conditions: conditions})
+}
+
+func (s Schema) validateReferences() util.ValidateResults {
+	var rsl util.ValidateResults
+	x := newIdIndex(s)
+	for _, crn := range s.CreditNotes {
+		rsl = appendResult(rsl, crn, x.check(crn.Invoice, "InvoiceId", invoiceKind))
+	}
+	for _, exp := range s.Expenses {
+		var cnd util.Conditions
+		cnd = append(cnd, x.check(exp.ObligedCustomer, "ObligedCustomerId", customerKind)...)
+		cnd = append(cnd, x.check(exp.Invoice, "InvoiceId", invoiceKind)...)
+		cnd = append(cnd, x.check(exp.AdvancedThirdParty, "AdvancedThirdPartyId", employeeKind, customerKind)...)
+		cnd = append(cnd, x.check(exp.SettlementTransaction, "SettlementTransactionId", transactionKind)...)
+		cnd = append(cnd, x.check(exp.Project, "ProjectId", projectKind)...)
+		rsl = appendResult(rsl, exp, cnd)
+	}
+	for _, ast := range s.FixedAssets {
+		rsl = appendResult(rsl, ast, x.check(ast.Expense, "ExpenseId", expenseKind))
+	}
+	for _, inv := range s.Invoices {
+		var cnd util.Conditions
+		cnd = append(cnd, x.check(inv.Customer, "CustomerId", customerKind)...)
+		cnd = append(cnd, x.check(inv.SettlementTransaction, "SettlementTransactionId", transactionKind)...)
+		cnd = append(cnd, x.check(inv.Project, "ProjectId", projectKind)...)
+		cnd = append(cnd, x.check(inv.Offer, "OfferId", offerKind)...)
+		rsl = appendResult(rsl, inv, cnd)
+	}
+	for _, mrc := range s.MiscRecords {
+		rsl = appendResult(rsl, mrc, x.check(mrc.Transaction, "SettlementTransactionId", transactionKind))
+	}
+	for _, off := range s.Offers {
+		var cnd util.Conditions
+		cnd = append(cnd, x.check(off.Customer, "CustomerId", customerKind)...)
+		cnd = append(cnd, x.check(off.Project, "ProjectId", projectKind)...)
+		rsl = appendResult(rsl, off, cnd)
+	}
+	for _, prj := range s.Projects {
+		rsl = appendResult(rsl, prj, x.check(prj.Customer, "CustomerId", customerKind))
+	}
+	for _, rec := range s.RecurringTemplates {
+		var cnd util.Conditions
+		cnd = append(cnd, x.check(rec.Customer, "CustomerId", customerKind)...)
+		cnd = append(cnd, x.check(rec.Project, "ProjectId", projectKind)...)
+		rsl = appendResult(rsl, rec, cnd)
+	}
+	for _, sal := range s.Salaries {
+		var cnd util.Conditions
+		cnd = append(cnd, x.check(sal.Employee, "EmployeeId", employeeKind)...)
+		cnd = append(cnd, x.check(sal.SettlementTransaction, "SettlementTransactionId", transactionKind)...)
+		rsl = appendResult(rsl, sal, cnd)
+	}
+	for _, trn := range s.Statement.Transactions {
+		var cnd util.Conditions
+		cnd = append(cnd, x.check(trn.AssociatedParty, "AssociatedPartyId", customerKind, employeeKind)...)
+		cnd = append(cnd, x.check(trn.AssociatedDocument, "AssociatedDocumentId", expenseKind, invoiceKind, salaryKind, creditNoteKind, miscRecordKind)...)
+		rsl = appendResult(rsl, trn, cnd)
+	}
+	return rsl
+}
+
+func (s Schema) validateDuplicates() util.ValidateResults {
+	var rsl util.ValidateResults
+	for _, idt := range [][]Identifiable{
+		s.CreditNotes.GetIdentifiables(),
+		s.Parties.GetCustomerIdentifiables(),
+		s.Parties.GetEmployeeIdentifiables(),
+		s.Expenses.GetIdentifiables(),
+		s.FixedAssets.GetIdentifiables(),
+		s.Invoices.GetIdentifiables(),
+		s.MiscRecords.GetIdentifiables(),
+		s.Offers.GetIdentifiables(),
+		s.Projects.GetIdentifiables(),
+		s.RecurringTemplates.GetIdentifiables(),
+		s.Salaries.GetIdentifiables(),
+		s.Statement.GetIdentifiables(),
+	} {
+		rsl = append(rsl, duplicateResults(idt)...)
+	}
+	return rsl
+}
+
+// duplicateResults returns a result for each element whose Id or Identifier is also used
+// by another element of the same type. Empty values are reported by the Conditions.
+func duplicateResults(idt []Identifiable) util.ValidateResults {
+	ids := make(map[string]int)
+	identifiers := make(map[string]int)
+	for i := range idt {
+		ids[idt[i].GetId()]++
+		identifiers[idt[i].GetIdentifier()]++
+	}
+	var rsl util.ValidateResults
+	for i := range idt {
+		ele, ok := idt[i].(util.Validatable)
+		if !ok {
+			continue
+		}
+		var cnd util.Conditions
+		if id := idt[i].GetId(); id != "" && ids[id] > 1 {
+			cnd = append(cnd, util.Conditions{{
+				Condition: true,
+				Message:   fmt.Sprintf("id «%s» is used by %d elements", id, ids[id]),
+				Level:     util.FundamentalFlaw,
+			}}...)
+		}
+		if ident := idt[i].GetIdentifier(); ident != "" && identifiers[ident] > 1 {
+			cnd = append(cnd, util.Conditions{{
+				Condition: true,
+				Message:   fmt.Sprintf("identifier «%s» is used by %d elements", ident, identifiers[ident]),
+				Level:     util.BeforeExportFlaw,
+			}}...)
+		}
+		rsl = appendResult(rsl, ele, cnd)
+	}
+	return rsl
+}
+
+// documentAmount returns the amount a transaction associated with the document should
+// have. Invoices are expected to be paid reduced by their credit notes.
+func (s Schema) documentAmount(ref Ref) (util.Money, string, bool) {
+	if exp, err := s.Expenses.ExpenseByRef(ref); err == nil {
+		return exp.Amount, exp.String(), exp.Amount.Money != nil
+	}
+	if inv, err := s.Invoices.InvoiceByRef(ref); err == nil && inv.Amount.Money != nil {
+		return inv.OpenAmount(s), inv.String(), true
+	}
+	if sal, err := s.Salaries.SalaryByRef(ref); err == nil && sal.Gross.Money != nil {
+		return sal.Net(), sal.String(), true
+	}
+	if crn, err := s.CreditNotes.CreditNoteByRef(ref); err == nil {
+		return crn.Amount, crn.String(), crn.Amount.Money != nil
+	}
+	return util.Money{}, "", false
+}
+
+func (s Schema) validateTransactionAmounts() util.ValidateResults {
+	var rsl util.ValidateResults
+	for _, trn := range s.Statement.Transactions {
+		if trn.AssociatedDocument.Empty() || trn.Amount.Money == nil {
+			continue
+		}
+		amount, doc, ok := s.documentAmount(trn.AssociatedDocument)
+		if !ok {
+			continue
+		}
+		if amount.Amount() == trn.Amount.Amount() && amount.Currency().Code == trn.Amount.Currency().Code {
+			continue
+		}
+		rsl = appendResult(rsl, trn, util.Conditions{{
+			Condition: true,
+			Message:   fmt.Sprintf("amount %s differs from %s expected by the associated %s", trn.Amount.Display(), amount.Display(), doc),
+			Level:     util.BeforeExportFlaw,
+		}})
+	}
+	return rsl
+}
+
+func (s Schema) validateSettlementLinks() util.ValidateResults {
+	var rsl util.ValidateResults
+	linkedBack := func(ref Ref, id, settled string) util.Conditions {
+		if ref.Empty() && settled == "" {
+			return nil
+		}
+		if ref.Empty() {
+			return util.Conditions{{
+				Condition: true,
+				Message:   "although date of settlement is set, the corresponding transaction is empty (SettlementTransactionId is empty)",
+				Level:     util.BeforeExportFlaw,
+			}}
+		}
+		trn, err := s.Statement.TransactionByRef(ref)
+		if err != nil || trn.AssociatedDocument.Id == id {
+			return nil
+		}
+		return util.Conditions{{
+			Condition: true,
+			Message:   fmt.Sprintf("settlement transaction %s isn't linked back (AssociatedDocumentId is «%s»)", trn.Identifier, trn.AssociatedDocument.Id),
+			Level:     util.BeforeExportFlaw,
+		}}
+	}
+	for _, exp := range s.Expenses {
+		rsl = appendResult(rsl, exp, linkedBack(exp.SettlementTransaction, exp.Id, exp.DateOfSettlement))
+	}
+	for _, inv := range s.Invoices {
+		rsl = appendResult(rsl, inv, linkedBack(inv.SettlementTransaction, inv.Id, inv.DateOfSettlement))
+	}
+	return rsl
+}
+
+func (s Schema) validateDocumentLinks() util.ValidateResults {
+	links := make(map[string][]string)
+	for _, trn := range s.Statement.Transactions {
+		if !trn.AssociatedDocument.Empty() {
+			links[trn.AssociatedDocument.Id] = append(links[trn.AssociatedDocument.Id], trn.Identifier)
+		}
+	}
+	var rsl util.ValidateResults
+	for _, trn := range s.Statement.Transactions {
+		if trn.AssociatedDocument.Empty() || len(links[trn.AssociatedDocument.Id]) < 2 {
+			continue
+		}
+		rsl = appendResult(rsl, trn, util.Conditions{{
+			Condition: true,
+			Message: fmt.Sprintf("associated document «%s» is linked from several transactions (%s)",
+				trn.AssociatedDocument.Id, strings.Join(links[trn.AssociatedDocument.Id], ", ")),
+			Level: util.BeforeExportFlaw,
+		}})
+	}
+	return rsl
+}
+
+func (s Schema) validateFilePaths() util.ValidateResults {
+	type record struct {
+		ele  util.Validatable
+		path string
+	}
+	var records []record
+	for _, crn := range s.CreditNotes {
+		records = append(records, record{crn, crn.Path})
+	}
+	for _, exp := range s.Expenses {
+		records = append(records, record{exp, exp.Path})
+	}
+	for _, inv := range s.Invoices {
+		records = append(records, record{inv, inv.Path})
+	}
+	for _, mrc := range s.MiscRecords {
+		records = append(records, record{mrc, mrc.Path})
+	}
+	usage := make(map[string][]string)
+	for i := range records {
+		if records[i].path == "" {
+			continue
+		}
+		records[i].path = filepath.Clean(records[i].path)
+		usage[samePath(records[i].path)] = append(usage[samePath(records[i].path)], records[i].ele.String())
+	}
+	var rsl util.ValidateResults
+	for i := range records {
+		users := usage[samePath(records[i].path)]
+		if records[i].path == "" || len(users) < 2 {
+			continue
+		}
+		rsl = appendResult(rsl, records[i].ele, util.Conditions{{
+			Condition: true,
+			Message:   fmt.Sprintf("file %s is referenced by %d records", records[i].path, len(users)),
+			Level:     util.BeforeExportFlaw,
+		}})
+	}
+	return rsl
+}
+
+func (s Schema) validateAssetContents() util.ValidateResults {
+	records := s.assetRecords()
+	hashes := make([]string, len(records))
+	usage := make(map[string][]int)
+	for i := range records {
+		if records[i].path == "" {
+			continue
+		}
+		hashes[i] = records[i].currentHash()
+		if hashes[i] != "" {
+			usage[hashes[i]] = append(usage[hashes[i]], i)
+		}
+	}
+	var rsl util.ValidateResults
+	for i := range records {
+		var others []string
+		for _, j := range usage[hashes[i]] {
+			// The same path is already reported by validateFilePaths.
+			if samePath(records[j].path) != samePath(records[i].path) {
+				others = append(others, records[j].ele.String())
+			}
+		}
+		if hashes[i] == "" || len(others) == 0 {
+			continue
+		}
+		rsl = appendResult(rsl, records[i].ele, util.Conditions{{
+			Condition: true,
+			Message:   fmt.Sprintf("file %s has the same content as the file of %s", records[i].path, strings.Join(others, ", ")),
+			Level:     util.BeforeExportFlaw,
+		}})
+	}
+	return rsl
+}
+
+func (s Schema) validateSimilarExpenses() util.ValidateResults {
+	var rsl util.ValidateResults
+	for i := range s.Expenses {
+		var others []string
+		for j := range s.Expenses {
+			if i != j && similarExpenses(s.Expenses[i], s.Expenses[j]) {
+				others = append(others, s.Expenses[j].Identifier)
+			}
+		}
+		if len(others) == 0 {
+			continue
+		}
+		rsl = appendResult(rsl, s.Expenses[i], util.Conditions{{
+			Condition: true,
+			Message:   fmt.Sprintf("possibly booked twice, amount, date and name are nearly identical to %s", strings.Join(others, ", ")),
+			Level:     util.BeforeExportFlaw,
+		}})
+	}
+	return rsl
+}
@@ -0,0 +1,131 @@
+package schema
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestValidateIntegrity(t *testing.T) {
+	s := Schema{
+		Expenses: Expenses{
+			{Id: "exp-1", Identifier: "e-1", Name: "Wood", Amount: util.NewMoney(50000, "CHF"), Path: "receipts/r1.pdf", SettlementTransaction: NewRef("trn-1")},
+			{Id: "exp-2", Identifier: "e-1", Name: "Paint", Amount: util.NewMoney(8000, "CHF"), Path: "receipts/./r1.pdf", Project: NewRef("prj-9")},
+		},
+		Invoices: Invoices{
+			{Id: "inv-1", Identifier: "i-1", Name: "Chairs", Amount: util.NewMoney(20000, "CHF"), DateOfSettlement: "2020-05-01"},
+		},
+		Statement: Statement{Transactions: []Transaction{
+			{Id: "trn-1", Identifier: "t-1", Amount: util.NewMoney(50000, "CHF"), AssociatedDocument: NewRef("exp-2")},
+			{Id: "trn-2", Identifier: "t-2", Amount: util.NewMoney(8000, "CHF"), AssociatedDocument: NewRef("exp-2")},
+		}},
+	}
+	var messages []string
+	for _, rsl := range s.ValidateIntegrity() {
+		for _, cnd := range rsl.Conditions {
+			messages = append(messages, rsl.Element.String()+": "+cnd.Message)
+		}
+	}
+	all := strings.Join(messages, "\n")
+	for _, expected := range []string{
+		"ProjectId «prj-9» points to no existing project",
+		"identifier «e-1» is used by 2 elements",
+		"amount 500.00 CHF differs from 80.00 CHF",
+		"settlement transaction t-1 isn't linked back",
+		"although date of settlement is set, the corresponding transaction is empty",
+		"associated document «exp-2» is linked from several transactions (t-1, t-2)",
+		"file receipts/r1.pdf is referenced by 2 records",
+	} {
+		if !strings.Contains(all, expected) {
+			t.Errorf("expected «%s» in the results:\n%s", expected, all)
+		}
+	}
+	if len(messages) != 10 {
+		t.Errorf("expected 10 findings, got %d:\n%s", len(messages), all)
+	}
+}
+
+func TestValidateAssetDuplicates(t *testing.T) {
+	base, err := ioutil.TempDir("", "acc-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	for name, content := range map[string]string{"a.pdf": "receipt", "b.pdf": "receipt", "c.pdf": "other receipt"} {
+		if err := ioutil.WriteFile(filepath.Join(base, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := Schema{
+		Expenses: Expenses{
+			{Id: "exp-1", Identifier: "e-1", Name: "Coop Bern", Amount: util.NewMoney(4250, "CHF"), DateOfAccrual: "2020-03-02", Path: filepath.Join(base, "a.pdf")},
+			{Id: "exp-2", Identifier: "e-2", Name: "Material", Amount: util.NewMoney(1000, "CHF"), DateOfAccrual: "2020-03-02", Path: filepath.Join(base, "b.pdf")},
+			{Id: "exp-3", Identifier: "e-3", Name: "coop", Amount: util.NewMoney(4260, "CHF"), DateOfAccrual: "2020-03-04", Path: filepath.Join(base, "c.pdf")},
+			{Id: "exp-4", Identifier: "e-4", Name: "Coop", Amount: util.NewMoney(4250, "CHF"), DateOfAccrual: "2020-04-02"},
+		},
+	}
+	s.HashAssets()
+	if len(s.Expenses[0].AssetHash) != 64 || s.Expenses[0].AssetHash != s.Expenses[1].AssetHash || s.Expenses[3].AssetHash != "" {
+		t.Errorf("unexpected hashes %q, %q, %q", s.Expenses[0].AssetHash, s.Expenses[1].AssetHash, s.Expenses[3].AssetHash)
+	}
+	if other, ok := s.KnownAsset(filepath.Join(base, "c.pdf")); !ok || other.String() != s.Expenses[2].String() {
+		t.Error("known file not found")
+	}
+
+	var messages []string
+	for _, rsl := range append(s.validateAssetContents(), s.validateSimilarExpenses()...) {
+		for _, cnd := range rsl.Conditions {
+			messages = append(messages, rsl.Element.(Expense).Identifier+": "+cnd.Message)
+		}
+	}
+	all := strings.Join(messages, "\n")
+	for _, expected := range []string{
+		"e-1: file " + filepath.Join(base, "a.pdf") + " has the same content as the file of " + s.Expenses[1].String(),
+		"e-2: file " + filepath.Join(base, "b.pdf") + " has the same content",
+		"e-1: possibly booked twice, amount, date and name are nearly identical to e-3",
+		"e-3: possibly booked twice, amount, date and name are nearly identical to e-1",
+	} {
+		if !strings.Contains(all, expected) {
+			t.Errorf("expected «%s» in the results:\n%s", expected, all)
+		}
+	}
+	if len(messages) != 4 {
+		t.Errorf("expected 4 findings, got %d:\n%s", len(messages), all)
+	}
+}
+
+func TestValidateSamePath(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := ioutil.TempDir(wd, "acc-assets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(base)
+	abs := filepath.Join(base, "a.pdf")
+	if err := ioutil.WriteFile(abs, []byte("receipt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	rel, err := filepath.Rel(wd, abs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := Schema{
+		Expenses: Expenses{
+			{Id: "exp-1", Identifier: "e-1", Name: "Wood", Amount: util.NewMoney(50000, "CHF"), Path: abs},
+			{Id: "exp-2", Identifier: "e-2", Name: "Paint", Amount: util.NewMoney(8000, "CHF"), Path: rel},
+		},
+	}
+	if rsl := s.validateAssetContents(); len(rsl) != 0 {
+		t.Errorf("same file reported as duplicate content: %s", rsl)
+	}
+	if rsl := s.validateFilePaths(); len(rsl) != 2 {
+		t.Errorf("expected the file to be reported for both records, got: %s", rsl)
+	}
+}
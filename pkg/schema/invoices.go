@@ -78,13 +78,27 @@ func (i Invoices) Filter(from *time.Time, to *time.Time) (Invoices, error) {
 		if err != nil {
 			return nil, fmt.Errorf("invoice \"%s\": %s", i[j].String(), err)
 		}
-		if from != nil && to != nil && (date.After(*from) || date.Equal(*from)) && (date.Before(*to) || date.Equal(*to)) {
-			result = append(result, i[j])
+		if from != nil && date.Before(*from) {
+			continue
 		}
+		if to != nil && date.After(*to) {
+			continue
+		}
+		result = append(result, i[j])
 	}
 	return result, nil
 }
 
+// contains returns true if there is an invoice with the given name and send date.
+func (i Invoices) contains(name, date string) bool {
+	for j := range i {
+		if i[j].Name == name && i[j].SendDate == date {
+			return true
+		}
+	}
+	return false
+}
+
 func (i Invoices) AssistedCompletion(s Schema, doAll, autoSave, openAttachment, retainFocus bool) {
 	first := true
 	for j := range i {
@@ -120,13 +134,14 @@ func (i Invoices) Less(j, k int) bool {
 	return dj.Before(dk)
 }
 
-func (i Invoices) SetReferenceDestinations(cst, trn, prj []Identifiable) {
+func (i Invoices) SetReferenceDestinations(cst, trn, prj, off []Identifiable) {
 	for j := range i {
 		i[j].Customer.SetDestination(cst)
 		if i[j].DateOfSettlement != "" {
 			i[j].SettlementTransaction.SetDestination(trn)
 		}
 		i[j].Project.SetDestination(prj)
+		i[j].Offer.SetDestination(off)
 	}
 }
 
@@ -142,18 +157,30 @@ type Invoice struct {
 	Amount util.Money `yaml:"amount" default:"-" query:"amount"`
 	// Path is the full path to the voucher utils.
 	Path string `yaml:"path" default:"/path/to/file.utils" query:"path"`
-	// Revoked invoices are disabled an no longer taken into account.
+	// AssetHash is the SHA-256 hash of the content of the file at Path.
+	AssetHash string `yaml:"assetHash,omitempty" default:""`
+	// Revoked invoices are disabled an no longer taken into account. Already booked invoices
+	// should be cancelled with a CreditNote instead.
 	Revoked bool `yaml:"revoked" default:"false"`
 	// Customer refers to the customer the invoice was sent to.
 	Customer Ref `yaml:"customerId" default:"" query:"customer"`
 	// SendDate states the date, the invoice was sent to the customer.
 	SendDate string `yaml:"sendDate" default:"2019-12-20"`
+	// MailDate states the date the invoice was emailed to the customer (see acc invoices send).
+	MailDate string `yaml:"mailDate,omitempty" default:""`
+	// Draft states that the invoice was generated (see acc invoices reinvoice) and wasn't
+	// checked and sent yet. Drafts aren't booked.
+	Draft bool `yaml:"draft,omitempty" default:"false"`
 	// DateOfSettlement states the date the customer paid the outstanding amount.
 	DateOfSettlement string `yaml:"dateOfSettlement" default:"2019-12-25"`
 	// SettlementTransaction refers to a possible bank transaction which settled the Invoice for the company.
 	SettlementTransaction Ref `yaml:"settlementTransactionId" default:"" query:"transaction"`
 	// Project refers to the associated project.
 	Project Ref `yaml:"projectId" default:""`
+	// Offer refers to the offer the invoice was created from, optional.
+	Offer Ref `yaml:"offerId,omitempty" default:""`
+	// JournalOverride contains manual corrections of the generated journal entry.
+	JournalOverride *JournalOverride `yaml:"journalOverride,omitempty"`
 }
 
 // NewInvoice returns a new Acc element with the default values.
@@ -360,6 +387,10 @@ func (i Invoice) Conditions() util.Conditions {
 			Condition: i.Customer.Empty(),
 			Message:   "customer id is not set (CustomerId empty)",
 		},
+		{
+			Condition: i.Draft,
+			Message:   "invoice is a draft and wasn't sent yet (Draft is true)",
+		},
 		{
 			Condition: !util.ValidDate(util.DateFormat, i.SendDate),
 			Message:   fmt.Sprintf("string «%s» could not be parsed with format YYYY-MM-DD", i.SendDate),
@@ -368,10 +399,6 @@ func (i Invoice) Conditions() util.Conditions {
 			Condition: i.DateOfSettlement != "" && !util.ValidDate(util.DateFormat, i.DateOfSettlement),
 			Message:   fmt.Sprintf("string «%s» could not be parsed with format YYYY-MM-DD", i.DateOfSettlement),
 		},
-		{
-			Condition: i.DateOfSettlement != "" && i.SettlementTransaction.Empty(),
-			Message:   "although date of settlement is set, the corresponding transaction is empty (SettlementTransactionId is empty",
-		},
 		/*
 			{
 				Condition: i.ProjectName == "",
@@ -381,6 +408,11 @@ func (i Invoice) Conditions() util.Conditions {
 	}
 }
 
+// IsDraft returns true if the invoice was generated but wasn't sent yet.
+func (i Invoice) IsDraft() bool {
+	return i.Draft
+}
+
 func (i Invoice) SendDateTime() time.Time {
 	result, err := time.Parse(util.DateFormat, i.SendDate)
 	if err != nil {
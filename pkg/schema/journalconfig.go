@@ -15,14 +15,20 @@ type JournalConfig struct {
 	RevenueAccount                          string            `yaml:"revenueAccount" default:"revenues:Betrieblicher Ertrag:Dienstleistungserlös"`
 	PayableAccount                          string            `yaml:"payableAccount" default:"liabilities:Kurzfristiges Fremdkapital:Kreditoren"`
 	EmployeeLiabilitiesAccount              string            `yaml:"employeeLiabilitiesAccount" default:"liabilities:Kurzfristiges Fremdkapital:Verbindlichkeiten gegenüber Genossenschaftler"`
+	FixedAssetsAccount                      string            `yaml:"fixedAssetsAccount" default:"assets:Anlagevermögen:Mobile Sachanlagen"`
+	DepreciationAccount                     string            `yaml:"depreciationAccount" default:"expenses:Abschreibungen"`
 	InvoicingTransactionDescription         string            `yaml:"invoicingTransactionDescription" default:"Rechnungsstellung {{ .Identifier }} an {{ .Party }}"`
 	InvoiceSettlementTransactionDescription string            `yaml:"invoiceSettlementTransactionDescription" default:"Erhalt Zahlung für die Rechnung {{ .Identifier }} von {{ .Party }}"`
+	CreditNoteDescription                   string            `yaml:"creditNoteDescription" default:"Gutschrift {{ .Identifier }} zur Rechnung {{ .Invoice }} an {{ .Party }}"`
+	CreditNoteSettlementDescription         string            `yaml:"creditNoteSettlementDescription" default:"Rückerstattung der Gutschrift {{ .Identifier }} an {{ .Party }}"`
 	ExpenseAdvancedByEmployeeDescription    string            `yaml:"expenseAdvancedByEmployeeDescription" default:"Bezahlung des Aufwands {{ .Identifier }} durch {{ .Party }} mit Privatvermögen"`
 	InternalExpenseOccurenceDescription     string            `yaml:"internalExpenseOccurenceDescription" default:"Aufwand für {{.Name}} ({{.Identifier}})"`
 	ProductionExpenseOccurenceDescription   string            `yaml:"productionExpenseOccurenceDescription" default:"Einkauf von {{.Name}} ({{.Identifier}}) für Projekt {{.Project}}"`
 	InternalExpenseTransactionDescription   string            `yaml:"internalExpenseTransactionDescription" default:"Bezahlung der Rechnung {{.Identifier}}"`
 	AdvancedExpenseSettlementDescription    string            `yaml:"advancedExpenseSettlementDescription" default:"Rückerstattung der Zahlung von {{.Party}} für {{.Identifier}}"`
 	CompanyPaidExpenseSettlementDescription string            `yaml:"companyPaidExpenseSettlementDescription" default:"Bezahlen des Aufwands {{.Identifier}}"`
+	CapitalisationDescription               string            `yaml:"capitalisationDescription" default:"Aktivierung von {{.Name}} ({{.Identifier}})"`
+	DepreciationDescription                 string            `yaml:"depreciationDescription" default:"Abschreibung {{.Year}} auf {{.Name}} ({{.Identifier}})"`
 	AccountAliases                          []string          `yaml:"accountAliases" default:"[]"`
 	ExpenseCategories                       ExpenseCategories `yaml:"expenseCategories" default:"[]"`
 }
@@ -0,0 +1,95 @@
+package schema
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+
+	"github.com/72nd/acc/pkg/util"
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+// MailPasswordEnv is the environment variable which can be used instead of storing the
+// SMTP password in the config file.
+const MailPasswordEnv = "ACC_SMTP_PASSWORD"
+
+// MailConfig contains the SMTP server and the templates used to email invoices to the
+// customers. The subject and the body are templates with the fields Company, Customer,
+// Invoice and Amount.
+type MailConfig struct {
+	// Host of the SMTP server, sending is disabled if empty.
+	Host string `yaml:"host" default:""`
+	// Port of the SMTP server. Port 465 uses implicit TLS, otherwise STARTTLS is used if
+	// the server supports it.
+	Port int `yaml:"port" default:"587"`
+	// Username for the SMTP authentication, no authentication if empty.
+	Username string `yaml:"username" default:""`
+	// Password for the SMTP authentication, the environment variable ACC_SMTP_PASSWORD
+	// takes precedence.
+	Password string `yaml:"password,omitempty" default:""`
+	// From is the sender address (ex: Fortuna Inc. <info@fortuna.com>), defaults to the
+	// name and mail address of the company.
+	From string `yaml:"from" default:""`
+	// Bcc is an optional address which receives a blind copy of all mails (ex: archive).
+	Bcc            string `yaml:"bcc,omitempty" default:""`
+	InvoiceSubject string `yaml:"invoiceSubject" default:"Rechnung {{ .Invoice.Identifier }}: {{ .Invoice.Name }}"`
+	InvoiceBody    string `yaml:"invoiceBody" default:"Guten Tag\n\nIm Anhang finden Sie unsere Rechnung {{ .Invoice.Identifier }} über {{ .Amount }}.\n\nFreundliche Grüsse\n{{ .Company.Name }}"`
+}
+
+// NewMailConfig returns a new MailConfig with the default values.
+func NewMailConfig() MailConfig {
+	mfg := MailConfig{}
+	if err := defaults.Set(&mfg); err != nil {
+		logrus.Fatal("error setting defaults for mail config: ", err)
+	}
+	return mfg
+}
+
+// Sender returns the sender address of the mails.
+func (m MailConfig) Sender(company Company) string {
+	if m.From != "" {
+		return m.From
+	}
+	return (&mail.Address{Name: company.Name, Address: company.Mail}).String()
+}
+
+// SmtpPassword returns the password for the SMTP server.
+func (m MailConfig) SmtpPassword() string {
+	if pw := os.Getenv(MailPasswordEnv); pw != "" {
+		return pw
+	}
+	return m.Password
+}
+
+// Type returns a string with the type name of the element.
+func (m MailConfig) Type() string {
+	return "Mail-Config"
+}
+
+// String returns a human readable representation of the element.
+func (m MailConfig) String() string {
+	return fmt.Sprintf("smtp server %s:%d", m.Host, m.Port)
+}
+
+// Conditions returns the validation conditions.
+func (m MailConfig) Conditions() util.Conditions {
+	return util.Conditions{
+		{
+			Condition: m.Host == "",
+			Message:   "SMTP server not set (host is empty)",
+		},
+		{
+			Condition: m.Port <= 0,
+			Message:   fmt.Sprintf("invalid SMTP port %d", m.Port),
+		},
+		{
+			Condition: m.From != "" && !validMail(m.From),
+			Message:   fmt.Sprintf("sender «%s» is not a valid email address", m.From),
+		},
+		{
+			Condition: m.Bcc != "" && !validMail(m.Bcc),
+			Message:   fmt.Sprintf("bcc «%s» is not a valid email address", m.Bcc),
+		},
+	}
+}
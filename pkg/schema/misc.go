@@ -108,6 +108,8 @@ type MiscRecord struct {
 	Name        string `yaml:"name" default:""`
 	// Path is the full file path to the associated business record.
 	Path        string `yaml:"path" default:"/path/to/record.pdf" query:"path"`
+	// AssetHash is the SHA-256 hash of the content of the file at Path.
+	AssetHash   string `yaml:"assetHash,omitempty" default:""`
 	// Date represents the date the document arrived.
 	Date        string `yaml:"date" default:"2019-12-20"`
 	// Transaction refers to an optional transaction which was issued upon the arrival of the Miscellaneous Record.
@@ -230,7 +232,7 @@ func (m MiscRecord) Conditions() util.Conditions {
 			Message:   fmt.Sprintf("business record document at \"%s\" not found", m.Path),
 		},
 		{
-			Condition: m.Date != "" && util.ValidDate(util.DateFormat, m.Date),
+			Condition: m.Date != "" && !util.ValidDate(util.DateFormat, m.Date),
 			Message:   fmt.Sprintf("string \"%s\" could not be parsed with format YYYY-MM-DD", m.Date),
 		},
 	}
@@ -0,0 +1,355 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultOffersFile = "offers.yaml"
+const DefaultOfferPrefix = "o-"
+
+// Status of an Offer.
+const (
+	OfferDraft    = "draft"
+	OfferSent     = "sent"
+	OfferAccepted = "accepted"
+	OfferRejected = "rejected"
+)
+
+// Offers is a collection of Offer elements.
+type Offers []Offer
+
+// OpenOffers opens the Offers saved in the YAML file given by the path. As offers are
+// optional, an empty collection is returned if the file doesn't exist.
+func OpenOffers(path string) Offers {
+	var off Offers
+	if !util.FileExist(path) {
+		return off
+	}
+	util.OpenYaml(&off, path, "offers")
+	return off
+}
+
+// Save writes the element as YAML file to the given path. Nothing is written when there
+// are no offers and no existing file.
+func (o Offers) Save(path string) {
+	if len(o) == 0 && !util.FileExist(path) {
+		return
+	}
+	util.SaveToYaml(o, path, "offers")
+}
+
+// OfferByRef returns the Offer with the given id. If no record could be found an error
+// will be returned.
+func (o Offers) OfferByRef(ref Ref) (*Offer, error) {
+	for i := range o {
+		if ref.Match(o[i]) {
+			return &o[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no offer for id \"%s\" found", ref.Id)
+}
+
+// OfferByIdent returns the Offer with the given identifier. If no record could be found
+// an error will be returned.
+func (o Offers) OfferByIdent(ident string) (*Offer, error) {
+	for i := range o {
+		if o[i].Identifier == ident {
+			return &o[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no offer for ident \"%s\" found", ident)
+}
+
+// GetIdentifiables returns the a slice of all identifiers. This is used for the
+// identifier suggestion while interactively adding a new Offer.
+func (o Offers) GetIdentifiables() []Identifiable {
+	rsl := make([]Identifiable, len(o))
+	for i := range o {
+		rsl[i] = o[i]
+	}
+	return rsl
+}
+
+// Validate all Offers.
+func (o Offers) Validate() util.ValidateResults {
+	var rsl util.ValidateResults
+	for i := range o {
+		rsl = append(rsl, util.Check(o[i]))
+	}
+	return rsl
+}
+
+// SetReferenceDestinations sets the destinations of the Reference fields.
+func (o Offers) SetReferenceDestinations(cst, prj []Identifiable) {
+	for i := range o {
+		o[i].Customer.SetDestination(cst)
+		o[i].Project.SetDestination(prj)
+	}
+}
+
+// OfferItem is a line of an Offer.
+type OfferItem struct {
+	// Description of the offered good or service.
+	Description string `yaml:"description" default:"Set construction"`
+	// Quantity of the item (ex: number of hours).
+	Quantity float64 `yaml:"quantity" default:"1"`
+	// UnitPrice is the price for one unit of the item.
+	UnitPrice util.Money `yaml:"unitPrice" default:""`
+}
+
+// Amount returns the total of the item in cents (quantity times unit price).
+func (i OfferItem) Amount() int64 {
+	if i.UnitPrice.Money == nil {
+		return 0
+	}
+	return int64(math.Round(i.Quantity * float64(i.UnitPrice.Amount())))
+}
+
+// Offer represents a quote sent to a customer before a project starts. Accepted offers
+// can be converted into one or several (partial and final) invoices.
+type Offer struct {
+	// Id is the internal unique identifier of the Offer.
+	Id string `yaml:"id" default:""`
+	// Identifier is a unique user-chosen identifier, should be human readable.
+	Identifier string `yaml:"identifier" default:"o-1"`
+	// Name describes meaningful the Offer.
+	Name string `yaml:"name" default:"Stage design"`
+	// Path is the full path to the offer document.
+	Path string `yaml:"path" default:"" query:"path"`
+	// Customer refers to the customer the offer is addressed to.
+	Customer Ref `yaml:"customerId" default:"" query:"customer"`
+	// Project refers to the associated project.
+	Project Ref `yaml:"projectId" default:""`
+	// Date states the date the offer was written.
+	Date string `yaml:"date" default:""`
+	// ValidUntil is the last day the customer can accept the offer.
+	ValidUntil string `yaml:"validUntil" default:""`
+	// Status is either draft, sent, accepted or rejected.
+	Status string `yaml:"status" default:"draft"`
+	// Items are the offered goods and services.
+	Items []OfferItem `yaml:"items" default:"[]"`
+}
+
+// NewOffer returns a new Offer with the default values.
+func NewOffer() Offer {
+	off := Offer{}
+	if err := defaults.Set(&off); err != nil {
+		logrus.Fatal("error setting defaults for offer: ", err)
+	}
+	off.Id = GetUuid()
+	off.Date = time.Now().Format(util.DateFormat)
+	off.ValidUntil = time.Now().AddDate(0, 1, 0).Format(util.DateFormat)
+	off.Items = []OfferItem{{
+		Description: "Set construction",
+		Quantity:    1,
+		UnitPrice:   util.NewMoney(100000, "CHF"),
+	}}
+	return off
+}
+
+// InteractiveNewOffer returns a new Offer based on the user input.
+func InteractiveNewOffer(s Schema) Offer {
+	off := NewOffer()
+	off.Identifier = util.AskString(
+		"Identifier",
+		"Unique human readable identifier",
+		SuggestNextIdentifier(s.Offers.GetIdentifiables(), DefaultOfferPrefix))
+	off.Name = util.AskString(
+		"Name",
+		"Name of the offer",
+		"Stage design")
+	off.Customer = NewRef(util.AskStringFromSearch(
+		"Customer",
+		"Customer the offer is addressed to",
+		s.Parties.CustomersSearchItems()))
+	if util.AskBool("Project", "Is the offer associated with a project?", false) {
+		off.Project = NewRef(util.AskStringFromSearch(
+			"Project",
+			"Associated Project",
+			s.Projects.SearchItems()))
+	}
+	off.Date = util.AskDate(
+		"Date",
+		"Date of the offer",
+		time.Now())
+	off.ValidUntil = util.AskDate(
+		"Valid until",
+		"Last day the customer can accept the offer",
+		time.Now().AddDate(0, 1, 0))
+	off.Items = nil
+	for {
+		off.Items = append(off.Items, OfferItem{
+			Description: util.AskString("Description", "Description of the item", "Set construction"),
+			Quantity:    util.AskFloat("Quantity", "Quantity of the item (ex: hours)", 1),
+			UnitPrice:   util.AskMoney("Unit price", "Price of one unit", util.NewMoney(0, s.Currency), s.Currency),
+		})
+		if !util.AskBool("Another item", "Add another item to the offer?", false) {
+			break
+		}
+	}
+	return off
+}
+
+// SetId generates a unique id for the element if there isn't already one defined.
+func (o *Offer) SetId() {
+	if o.Id != "" {
+		return
+	}
+	o.Id = GetUuid()
+}
+
+// GetId returns the id of the Offer.
+func (o Offer) GetId() string {
+	return o.Id
+}
+
+// GetIdentifier returns the identifier of the Offer.
+func (o Offer) GetIdentifier() string {
+	return o.Identifier
+}
+
+// String returns a human readable representation of the element.
+func (o Offer) String() string {
+	return fmt.Sprintf("offer %s (%s): %s", o.Name, o.Identifier, o.Status)
+}
+
+// Short returns a short representation of the element.
+func (o Offer) Short() string {
+	return fmt.Sprintf("%s (%s)", o.Name, o.Identifier)
+}
+
+// Type returns a string with the type name of the element.
+func (o Offer) Type() string {
+	return "Offer"
+}
+
+// FileString returns the file name for exporting the offer as a document.
+func (o Offer) FileString() string {
+	return o.Identifier
+}
+
+// Amount returns the total of all items.
+func (o Offer) Amount(currency string) util.Money {
+	var sum int64
+	for i := range o.Items {
+		sum += o.Items[i].Amount()
+		if o.Items[i].UnitPrice.Money != nil {
+			currency = o.Items[i].UnitPrice.Currency().Code
+		}
+	}
+	return util.NewMoney(sum, currency)
+}
+
+// Conditions returns the validation conditions.
+func (o Offer) Conditions() util.Conditions {
+	return util.Conditions{
+		{
+			Condition: o.Id == "",
+			Message:   "unique identifier not set (Id is empty)",
+		},
+		{
+			Condition: o.Identifier == "",
+			Message:   "human readable identifier not set (Identifier is empty)",
+		},
+		{
+			Condition: o.Name == "",
+			Message:   "name not set (Name is empty)",
+		},
+		{
+			Condition: o.Customer.Empty(),
+			Message:   "customer id not set (CustomerId is empty)",
+		},
+		{
+			Condition: !util.ValidDate(util.DateFormat, o.Date),
+			Message:   fmt.Sprintf("date «%s» could not be parsed with format YYYY-MM-DD", o.Date),
+		},
+		{
+			Condition: !util.ValidDate(util.DateFormat, o.ValidUntil),
+			Message:   fmt.Sprintf("validity date «%s» could not be parsed with format YYYY-MM-DD", o.ValidUntil),
+		},
+		{
+			Condition: o.Status != OfferDraft && o.Status != OfferSent && o.Status != OfferAccepted && o.Status != OfferRejected,
+			Message:   fmt.Sprintf("status «%s» is neither %s, %s, %s nor %s", o.Status, OfferDraft, OfferSent, OfferAccepted, OfferRejected),
+		},
+		{
+			Condition: len(o.Items) == 0,
+			Message:   "offer has no items",
+		},
+		{
+			Condition: !o.validItems(),
+			Message:   "every item needs a description and a unit price",
+		},
+	}
+}
+
+func (o Offer) validItems() bool {
+	for i := range o.Items {
+		if o.Items[i].Description == "" || o.Items[i].UnitPrice.Money == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// InvoicedAmount returns the sum of all non revoked invoices created from the Offer.
+// Invoices without an amount count as zero.
+func (o Offer) InvoicedAmount(s Schema) int64 {
+	var sum int64
+	for i := range s.Invoices {
+		if !s.Invoices[i].Revoked && s.Invoices[i].Offer.Match(o) {
+			sum += amountOrZero(s.Invoices[i].Amount)
+		}
+	}
+	return sum
+}
+
+// InvoiceOffer converts an accepted offer into an invoice and adds it to the schema. A
+// partial invoice is created for the given percentage of the offer amount. If the
+// percentage is zero the final invoice over the not yet invoiced amount is created. Like
+// re-invoices the invoice is a draft dated today.
+func (s *Schema) InvoiceOffer(off Offer, percentage float64) (Invoice, error) {
+	if off.Status != OfferAccepted {
+		return Invoice{}, fmt.Errorf("%s is not accepted", off.String())
+	}
+	if percentage < 0 || percentage > 100 {
+		return Invoice{}, fmt.Errorf("percentage %.2f is not between 0 and 100", percentage)
+	}
+	total := off.Amount(s.Currency)
+	remaining := total.Amount() - off.InvoicedAmount(*s)
+	amount := remaining
+	name := fmt.Sprintf("%s final invoice", off.Name)
+	if percentage > 0 {
+		amount = int64(math.Round(float64(total.Amount()) * percentage / 100))
+		name = fmt.Sprintf("%s partial invoice %.0f%%", off.Name, percentage)
+	}
+	if remaining <= 0 {
+		return Invoice{}, fmt.Errorf("%s is already fully invoiced", off.String())
+	}
+	if amount > remaining {
+		return Invoice{}, fmt.Errorf(
+			"amount %s exceeds the not yet invoiced amount %s of %s",
+			util.NewMoney(amount, total.Currency().Code).Value(),
+			util.NewMoney(remaining, total.Currency().Code).Value(),
+			off.String())
+	}
+	inv := NewInvoiceWithUuid()
+	inv.Identifier = SuggestNextIdentifier(s.Invoices.GetIdentifiables(), DefaultInvoicesPrefix)
+	inv.Name = name
+	inv.Amount = util.NewMoney(amount, total.Currency().Code)
+	inv.Path = ""
+	inv.Customer = off.Customer
+	inv.SendDate = time.Now().Format(util.DateFormat)
+	inv.Draft = true
+	inv.DateOfSettlement = ""
+	inv.Project = off.Project
+	inv.Offer = NewRef(off.Id)
+	s.Invoices = append(s.Invoices, inv)
+	logrus.Infof("generated invoice %s from %s", inv.String(), off.String())
+	return inv, nil
+}
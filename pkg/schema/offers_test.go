@@ -0,0 +1,38 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestInvoiceOffer(t *testing.T) {
+	off := Offer{
+		Id:     "off-1",
+		Name:   "Hamlet",
+		Status: OfferAccepted,
+		Items:  []OfferItem{{Description: "Set construction", Quantity: 2, UnitPrice: util.NewMoney(50000, "CHF")}},
+	}
+	s := Schema{
+		Currency: "CHF",
+		Offers:   Offers{off},
+		Invoices: Invoices{{Id: "inv-1", Identifier: "i-1", Offer: NewRef("off-1")}},
+	}
+	inv, err := s.InvoiceOffer(off, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.Amount.Value() != "300.00 CHF" || !inv.IsDraft() || !util.ValidDate(util.DateFormat, inv.SendDate) {
+		t.Errorf("expected dated draft over 300.00 CHF, got %s (%s, draft %t)", inv.Amount.Value(), inv.SendDate, inv.Draft)
+	}
+	inv, err = s.InvoiceOffer(off, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if inv.Amount.Value() != "700.00 CHF" {
+		t.Errorf("expected final invoice over 700.00 CHF, got %s", inv.Amount.Value())
+	}
+	if _, err := s.InvoiceOffer(off, 0); err == nil {
+		t.Error("expected error for fully invoiced offer")
+	}
+}
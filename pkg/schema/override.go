@@ -0,0 +1,44 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+// JournalOverride contains the manual corrections of the journal entries generated for
+// a record (Expense, Invoice, Transaction, Salary, CreditNote or FixedAsset). Usually
+// it's populated by importing a manually edited journal (acc ledger import). Empty fields
+// are not overridden, the amount is only stored if it differs from the amount of the
+// record. The fields apply to all entries of the record, corrections of a single entry
+// of a record with several entries are stored in Entries.
+type JournalOverride struct {
+	Account1 string      `yaml:"account1,omitempty"`
+	Account2 string      `yaml:"account2,omitempty"`
+	Amount   *util.Money `yaml:"amount,omitempty"`
+	// Entries contains the corrections per entry. The key is the suffix of the id of the
+	// entry (ex: 2 for the second entry, capitalisation or the year of a depreciation).
+	Entries map[string]JournalOverride `yaml:"entries,omitempty"`
+}
+
+// IsEmpty returns true if the override doesn't alter the accounts or the amount.
+func (o JournalOverride) IsEmpty() bool {
+	return o.Account1 == "" && o.Account2 == "" && o.Amount == nil
+}
+
+// String returns a short human readable representation of the override.
+func (o JournalOverride) String() string {
+	var parts []string
+	if o.Account1 != "" {
+		parts = append(parts, o.Account1)
+	}
+	if o.Account2 != "" {
+		parts = append(parts, o.Account2)
+	}
+	rsl := strings.Join(parts, " / ")
+	if o.Amount != nil && o.Amount.Money != nil {
+		rsl = fmt.Sprintf("%s (%s)", rsl, o.Amount.Display())
+	}
+	return rsl
+}
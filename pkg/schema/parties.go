@@ -2,6 +2,7 @@ package schema
 
 import (
 	"fmt"
+	"net/mail"
 
 	"github.com/72nd/acc/pkg/util"
 	"github.com/creasty/defaults"
@@ -191,8 +192,14 @@ type Party struct {
 	PostalCode int `yaml:"postalCode" default:"8000"`
 	// Name of person's/company's place.
 	Place string `yaml:"place" default:"Zurich"`
+	// Country is the ISO 3166 country code of the address (e.g. CH), used for payment orders.
+	Country string `yaml:"country,omitempty" default:""`
 	// States whether a party is a customer or a employee.
 	PartyType PartyType `yaml:"partyType" default:"0"`
+	// Iban of the bank account of the party, used for payment orders.
+	Iban string `yaml:"iban,omitempty" default:""`
+	// Mail is the email address, invoices are sent to this address.
+	Mail string `yaml:"mail,omitempty" default:""`
 }
 
 // NewParty returns a new Party with the default values.
@@ -248,6 +255,10 @@ func InteractiveNewCustomer(s Schema) Party {
 		"Unique human readable identifier",
 		SuggestNextIdentifier(s.Parties.GetCustomerIdentifiables(), DefaultCustomerPrefix))
 	pty.PartyType = CustomerType
+	pty.Mail = util.AskString(
+		"Mail",
+		"Email address for sending invoices",
+		"")
 	return pty
 }
 
@@ -258,6 +269,10 @@ func InteractiveNewEmployee(s Schema) Party {
 		"Unique human readable identifier",
 		SuggestNextIdentifier(s.Parties.GetEmployeeIdentifiables(), DefaultEmployeePrefix))
 	pty.PartyType = EmployeeType
+	pty.Iban = util.AskString(
+		"IBAN",
+		"Bank account for the reimbursement of advances and the salary",
+		"")
 	return pty
 }
 
@@ -374,5 +389,15 @@ func (p Party) Conditions() util.Conditions {
 			Condition: p.PostalCode == 0,
 			Message:   "postal code is not set (PostalCode is 0)",
 		},
+		{
+			Condition: p.Mail != "" && !validMail(p.Mail),
+			Message:   fmt.Sprintf("«%s» is not a valid email address", p.Mail),
+		},
 	}
 }
+
+// validMail states whether the given string is a valid email address.
+func validMail(address string) bool {
+	_, err := mail.ParseAddress(address)
+	return err == nil
+}
@@ -0,0 +1,367 @@
+package schema
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultPayrollFile = "payroll.yaml"
+const DefaultSalaryPrefix = "s-"
+
+// SalaryMonthFormat is the format of the month a Salary is paid for.
+const SalaryMonthFormat = "2006-01"
+
+// Names of the Swiss social security deductions.
+const (
+	AHVDeduction = "AHV/IV/EO"
+	ALVDeduction = "ALV"
+	BVGDeduction = "BVG"
+	UVGDeduction = "UVG"
+)
+
+// PayrollConfig contains the employee contribution rates of the Swiss social security
+// and the accounts used for the salary journal entries. Rates are in percent of the
+// gross salary. As the rates change over the years, the deductions are calculated when
+// a Salary is created and then saved with it.
+type PayrollConfig struct {
+	// AHVRate is the rate for the old-age, disability and income compensation insurance (AHV/IV/EO).
+	AHVRate float64 `yaml:"ahvRate" default:"5.3"`
+	// ALVRate is the rate for the unemployment insurance (ALV).
+	ALVRate float64 `yaml:"alvRate" default:"1.1"`
+	// BVGRate is the rate for the occupational pension fund (BVG).
+	BVGRate float64 `yaml:"bvgRate" default:"0"`
+	// UVGRate is the rate for the non-occupational accident insurance (UVG/NBU).
+	UVGRate                  float64 `yaml:"uvgRate" default:"0"`
+	SalaryAccount            string  `yaml:"salaryAccount" default:"expenses:Personalaufwand:Lohnaufwand"`
+	SalaryLiabilitiesAccount string  `yaml:"salaryLiabilitiesAccount" default:"liabilities:Kurzfristiges Fremdkapital:Lohnverbindlichkeiten"`
+	SocialSecurityAccount    string  `yaml:"socialSecurityAccount" default:"liabilities:Kurzfristiges Fremdkapital:Verbindlichkeiten Sozialversicherungen"`
+	SalaryDescription        string  `yaml:"salaryDescription" default:"Lohn {{.Month}} für {{.Party}}"`
+	DeductionsDescription    string  `yaml:"deductionsDescription" default:"Sozialversicherungsabzüge Lohn {{.Month}} für {{.Party}}"`
+	SalaryPaymentDescription string  `yaml:"salaryPaymentDescription" default:"Auszahlung Lohn {{.Month}} an {{.Party}}"`
+}
+
+// NewPayrollConfig returns a new PayrollConfig with the default values.
+func NewPayrollConfig() PayrollConfig {
+	pfg := PayrollConfig{}
+	if err := defaults.Set(&pfg); err != nil {
+		logrus.Fatal("error setting defaults for payroll config: ", err)
+	}
+	return pfg
+}
+
+// Deductions returns the social security deductions for the given gross salary. Deductions
+// with a rate of zero are omitted.
+func (p PayrollConfig) Deductions(gross util.Money) []Deduction {
+	rates := []struct {
+		name string
+		rate float64
+	}{
+		{AHVDeduction, p.AHVRate},
+		{ALVDeduction, p.ALVRate},
+		{BVGDeduction, p.BVGRate},
+		{UVGDeduction, p.UVGRate},
+	}
+	var rsl []Deduction
+	for i := range rates {
+		if rates[i].rate == 0 {
+			continue
+		}
+		rsl = append(rsl, Deduction{
+			Name:   rates[i].name,
+			Rate:   rates[i].rate,
+			Amount: util.NewMoney(int64(math.Round(float64(gross.Amount())*rates[i].rate/100)), gross.Currency().Code),
+		})
+	}
+	return rsl
+}
+
+// Salaries is a collection of Salary elements.
+type Salaries []Salary
+
+// OpenSalaries opens the Salaries saved in the YAML file given by the path. As the
+// payroll is optional, an empty collection is returned if the file doesn't exist.
+func OpenSalaries(path string) Salaries {
+	var sal Salaries
+	if !util.FileExist(path) {
+		return sal
+	}
+	util.OpenYaml(&sal, path, "payroll")
+	return sal
+}
+
+// Save writes the element as YAML file to the given path. Nothing is written when there
+// are no salaries and no existing file.
+func (s Salaries) Save(path string) {
+	if len(s) == 0 && !util.FileExist(path) {
+		return
+	}
+	util.SaveToYaml(s, path, "payroll")
+}
+
+// SalaryByRef returns the Salary with the given id. If no salary could be found an error
+// will be returned.
+func (s Salaries) SalaryByRef(ref Ref) (*Salary, error) {
+	for i := range s {
+		if ref.Match(s[i]) {
+			return &s[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no salary for id \"%s\" found", ref.Id)
+}
+
+// SalaryByIdent returns the Salary with the given identifier. If no salary could be found
+// an error will be returned.
+func (s Salaries) SalaryByIdent(ident string) (*Salary, error) {
+	for i := range s {
+		if s[i].Identifier == ident {
+			return &s[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no salary for ident \"%s\" found", ident)
+}
+
+// GetIdentifiables returns the a slice of all identifiers. This is used for the
+// identifier suggestion while interactively adding a new Salary.
+func (s Salaries) GetIdentifiables() []Identifiable {
+	rsl := make([]Identifiable, len(s))
+	for i := range s {
+		rsl[i] = s[i]
+	}
+	return rsl
+}
+
+// Validate all Salaries.
+func (s Salaries) Validate() util.ValidateResults {
+	var rsl util.ValidateResults
+	for i := range s {
+		rsl = append(rsl, util.Check(s[i]))
+	}
+	return rsl
+}
+
+// SetReferenceDestinations sets the destinations of the Reference fields.
+func (s Salaries) SetReferenceDestinations(emp, trn []Identifiable) {
+	for i := range s {
+		s[i].Employee.SetDestination(emp)
+		s[i].SettlementTransaction.SetDestination(trn)
+	}
+}
+
+// Filter returns all salaries with a accrual date between from and to (both can be nil).
+func (s Salaries) Filter(from, to *time.Time) Salaries {
+	var rsl Salaries
+	for i := range s {
+		date, err := s[i].AccrualDateTime()
+		if err != nil {
+			logrus.Error(err)
+			continue
+		}
+		if (from != nil && date.Before(*from)) || (to != nil && date.After(*to)) {
+			continue
+		}
+		rsl = append(rsl, s[i])
+	}
+	return rsl
+}
+
+// ByEmployee returns all salaries of the given employee.
+func (s Salaries) ByEmployee(emp Party) Salaries {
+	var rsl Salaries
+	for i := range s {
+		if s[i].Employee.Match(emp) {
+			rsl = append(rsl, s[i])
+		}
+	}
+	return rsl
+}
+
+// Deduction is a single social security deduction of a Salary.
+type Deduction struct {
+	// Name of the insurance (AHV/IV/EO, ALV, BVG or UVG).
+	Name string `yaml:"name"`
+	// Rate in percent of the gross salary.
+	Rate float64 `yaml:"rate"`
+	// Amount deducted from the gross salary.
+	Amount util.Money `yaml:"amount"`
+}
+
+// Salary is the monthly salary of an employee.
+type Salary struct {
+	// Id is the internal unique identifier of the Salary.
+	Id string `yaml:"id" default:""`
+	// Identifier is a unique user-chosen identifier, should be human readable.
+	Identifier string `yaml:"identifier" default:"s-1"`
+	// Employee refers to the employee receiving the salary.
+	Employee Ref `yaml:"employeeId" default:"" query:"employee"`
+	// Month the salary is paid for (YYYY-MM).
+	Month string `yaml:"month" default:""`
+	// Gross is the salary before the deductions.
+	Gross util.Money `yaml:"gross" default:""`
+	// Deductions are the social security contributions of the employee.
+	Deductions []Deduction `yaml:"deductions" default:"[]"`
+	// DateOfSettlement is the date the net salary was paid out.
+	DateOfSettlement string `yaml:"dateOfSettlement" default:""`
+	// SettlementTransaction refers to the bank transaction of the payment.
+	SettlementTransaction Ref `yaml:"settlementTransactionId" default:"" query:"transaction"`
+	// JournalOverride contains manual corrections of the generated journal entries.
+	JournalOverride *JournalOverride `yaml:"journalOverride,omitempty"`
+}
+
+// NewSalary returns a new Salary for the given gross amount with the deductions according
+// to the PayrollConfig.
+func NewSalary(pfg PayrollConfig, gross util.Money) Salary {
+	sal := Salary{}
+	if err := defaults.Set(&sal); err != nil {
+		logrus.Fatal("error setting defaults for salary: ", err)
+	}
+	sal.Id = GetUuid()
+	sal.Month = time.Now().Format(SalaryMonthFormat)
+	sal.Gross = gross
+	sal.Deductions = pfg.Deductions(gross)
+	return sal
+}
+
+// InteractiveNewSalary returns a new Salary based on the user input.
+func InteractiveNewSalary(s Schema) Salary {
+	ident := util.AskString(
+		"Identifier",
+		"Unique human readable identifier",
+		SuggestNextIdentifier(s.Salaries.GetIdentifiables(), DefaultSalaryPrefix))
+	emp := util.AskStringFromSearch(
+		"Employee",
+		"Employee receiving the salary",
+		s.Parties.EmployeesSearchItems())
+	month := util.AskString(
+		"Month",
+		"Month the salary is paid for (YYYY-MM)",
+		time.Now().Format(SalaryMonthFormat))
+	gross := util.AskMoney(
+		"Gross",
+		"Gross salary before the deductions",
+		util.NewMoney(0, s.Currency),
+		s.Currency)
+	sal := NewSalary(s.PayrollConfig, gross)
+	sal.Identifier = ident
+	sal.Employee = NewRef(emp)
+	sal.Month = month
+	return sal
+}
+
+// SetId generates a unique id for the element if there isn't already one defined.
+func (s *Salary) SetId() {
+	if s.Id != "" {
+		return
+	}
+	s.Id = GetUuid()
+}
+
+// GetId returns the id of the Salary.
+func (s Salary) GetId() string {
+	return s.Id
+}
+
+// GetIdentifier returns the identifier of the Salary.
+func (s Salary) GetIdentifier() string {
+	return s.Identifier
+}
+
+// String returns a human readable representation of the element.
+func (s Salary) String() string {
+	return fmt.Sprintf("salary %s for %s (%s)", s.Month, s.Employee.Id, s.Identifier)
+}
+
+// Short returns a short representation of the element.
+func (s Salary) Short() string {
+	return fmt.Sprintf("%s (%s)", s.Month, s.Identifier)
+}
+
+// Type returns a string with the type name of the element.
+func (s Salary) Type() string {
+	return "Salary"
+}
+
+// Conditions returns the validation conditions.
+func (s Salary) Conditions() util.Conditions {
+	_, monthErr := time.Parse(SalaryMonthFormat, s.Month)
+	return util.Conditions{
+		{
+			Condition: s.Id == "",
+			Message:   "unique identifier not set (Id is empty)",
+		},
+		{
+			Condition: s.Identifier == "",
+			Message:   "human readable identifier not set (Identifier is empty)",
+		},
+		{
+			Condition: s.Employee.Empty(),
+			Message:   "employee not set (Employee is empty)",
+		},
+		{
+			Condition: monthErr != nil,
+			Message:   fmt.Sprintf("month «%s» is not in the format YYYY-MM", s.Month),
+		},
+		{
+			Condition: s.Gross.Money == nil,
+			Message:   "gross salary not set (Gross is empty)",
+		},
+		{
+			Condition: s.Gross.Money != nil && s.NetAmount() < 0,
+			Message:   "deductions are higher than the gross salary",
+		},
+		{
+			Condition: s.DateOfSettlement != "" && s.SettlementTransaction.Empty(),
+			Message:   "although settlement date set, no corresponding transaction is set",
+		},
+	}
+}
+
+// AccrualDateTime returns the last day of the month of the salary.
+func (s Salary) AccrualDateTime() (time.Time, error) {
+	month, err := time.Parse(SalaryMonthFormat, s.Month)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse «%s» of %s as month with YYYY-MM: %s", s.Month, s.String(), err)
+	}
+	return month.AddDate(0, 1, -1), nil
+}
+
+// SettlementDateTime returns the date of the payment.
+func (s Salary) SettlementDateTime() (time.Time, error) {
+	return time.Parse(util.DateFormat, s.DateOfSettlement)
+}
+
+// TotalDeductions returns the sum of all deductions in cents.
+func (s Salary) TotalDeductions() int64 {
+	var rsl int64
+	for i := range s.Deductions {
+		if s.Deductions[i].Amount.Money != nil {
+			rsl += s.Deductions[i].Amount.Amount()
+		}
+	}
+	return rsl
+}
+
+// DeductionAmount returns the sum of the deductions with the given name in cents.
+func (s Salary) DeductionAmount(name string) int64 {
+	var rsl int64
+	for i := range s.Deductions {
+		if s.Deductions[i].Name == name && s.Deductions[i].Amount.Money != nil {
+			rsl += s.Deductions[i].Amount.Amount()
+		}
+	}
+	return rsl
+}
+
+// NetAmount returns the salary paid out to the employee in cents.
+func (s Salary) NetAmount() int64 {
+	return s.Gross.Amount() - s.TotalDeductions()
+}
+
+// Net returns the salary paid out to the employee.
+func (s Salary) Net() util.Money {
+	return util.NewMoney(s.NetAmount(), s.Gross.Currency().Code)
+}
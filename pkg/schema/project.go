@@ -98,6 +98,8 @@ type Project struct {
 	Name       string `yaml:"name" default:"Building a space rocket"`
 	// Customer refers to the associated customer.
 	Customer   Ref    `yaml:"customerId" default:""`
+	// Budget contains the planned amounts per expense category, optional.
+	Budget []BudgetLine `yaml:"budget,omitempty" default:"[]"`
 }
 
 // NewProject returns a new Project element with the default values.
@@ -190,5 +192,36 @@ func (p Project) Conditions() util.Conditions {
 			Condition: p.Customer.Empty(),
 			Message:   "customer id not set (CustomerId is empty)",
 		},
+		{
+			Condition: !p.validBudget(),
+			Message:   "budget lines need a unique expense category",
+		},
+		{
+			Condition: !p.budgetAmountsSet(),
+			Message:   "budget line without amount (Budget.Amount is empty)",
+		},
+	}
+}
+
+// budgetAmountsSet returns false if a budget line has no amount.
+func (p Project) budgetAmountsSet() bool {
+	for i := range p.Budget {
+		if p.Budget[i].Amount.Money == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// validBudget returns false if a budget line has no expense category or a category
+// is used more than once.
+func (p Project) validBudget() bool {
+	categories := make(map[string]bool)
+	for i := range p.Budget {
+		if p.Budget[i].ExpenseCategory == "" || categories[p.Budget[i].ExpenseCategory] {
+			return false
+		}
+		categories[p.Budget[i].ExpenseCategory] = true
 	}
+	return true
 }
@@ -0,0 +1,403 @@
+package schema
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+	"github.com/creasty/defaults"
+	"github.com/sirupsen/logrus"
+)
+
+const DefaultRecurringFile = "recurring.yaml"
+const DefaultRecurringPrefix = "r-"
+
+// Intervals of RecurringTemplate.
+const (
+	MonthlyInterval   = "monthly"
+	QuarterlyInterval = "quarterly"
+	YearlyInterval    = "yearly"
+)
+
+// Record types a RecurringTemplate can generate.
+const (
+	RecurringExpense = "expense"
+	RecurringInvoice = "invoice"
+)
+
+// RecurringTemplates is a collection of RecurringTemplate elements.
+type RecurringTemplates []RecurringTemplate
+
+// OpenRecurringTemplates opens the RecurringTemplates saved in the YAML file given by the
+// path. As the templates are optional, an empty collection is returned if the file
+// doesn't exist.
+func OpenRecurringTemplates(path string) RecurringTemplates {
+	var rec RecurringTemplates
+	if !util.FileExist(path) {
+		return rec
+	}
+	util.OpenYaml(&rec, path, "recurring")
+	return rec
+}
+
+// Save writes the element as YAML file to the given path. Nothing is written when there
+// are no templates and no existing file.
+func (r RecurringTemplates) Save(path string) {
+	if len(r) == 0 && !util.FileExist(path) {
+		return
+	}
+	util.SaveToYaml(r, path, "recurring")
+}
+
+// GetIdentifiables returns the a slice of all identifiers. This is used for the
+// identifier suggestion while interactively adding a new RecurringTemplate.
+func (r RecurringTemplates) GetIdentifiables() []Identifiable {
+	rsl := make([]Identifiable, len(r))
+	for i := range r {
+		rsl[i] = r[i]
+	}
+	return rsl
+}
+
+// Validate all RecurringTemplates.
+func (r RecurringTemplates) Validate() util.ValidateResults {
+	var rsl util.ValidateResults
+	for i := range r {
+		rsl = append(rsl, util.Check(r[i]))
+	}
+	return rsl
+}
+
+// SetReferenceDestinations sets the destinations of the Reference fields.
+func (r RecurringTemplates) SetReferenceDestinations(cst, prj []Identifiable) {
+	for i := range r {
+		r[i].Customer.SetDestination(cst)
+		r[i].Project.SetDestination(prj)
+	}
+}
+
+// Run materialises the due expenses and invoices of all templates up to the given date
+// (inclusive) and adds them to the schema. Returns the number of generated records.
+func (r RecurringTemplates) Run(s *Schema, until time.Time) int {
+	count := 0
+	for i := range r {
+		count += r[i].run(s, until)
+	}
+	return count
+}
+
+// RecurringTemplate describes an expense (rent, insurance, subscriptions...) or an
+// invoice (retainer) which repeats in a fixed interval. The records are generated with
+// `acc recurring run`. The date of the last generated occurrence is saved in the template
+// to never generate a record twice.
+type RecurringTemplate struct {
+	// Id is the internal unique identifier of the RecurringTemplate.
+	Id string `yaml:"id" default:""`
+	// Identifier is a unique user-chosen identifier, should be human readable.
+	Identifier string `yaml:"identifier" default:"r-1"`
+	// Name of the generated records, the period (ex: «2022-03») is appended.
+	Name string `yaml:"name" default:"Rent"`
+	// RecordType is either expense or invoice.
+	RecordType string `yaml:"recordType" default:"expense"`
+	// Interval is either monthly, quarterly or yearly.
+	Interval string `yaml:"interval" default:"monthly"`
+	// Start is the date of the first occurrence, the following are on the same day of the period.
+	Start string `yaml:"start" default:""`
+	// End is the optional date after which no occurrences are generated.
+	End string `yaml:"end" default:""`
+	// Amount of the generated records.
+	Amount util.Money `yaml:"amount" default:""`
+	// ExpenseCategory of the generated expenses.
+	ExpenseCategory string `yaml:"expenseCategory" default:""`
+	// Customer is the recipient of the generated invoices or the obliged customer of billable expenses.
+	Customer Ref `yaml:"customerId" default:"" query:"customer"`
+	// Project refers to the associated project, expenses without project are internal.
+	Project Ref `yaml:"projectId" default:""`
+	// LastOccurrence is the date of the last generated record.
+	LastOccurrence string `yaml:"lastOccurrence" default:""`
+}
+
+// NewRecurringTemplate returns a new RecurringTemplate with the default values, the amount
+// is in the given currency.
+func NewRecurringTemplate(currency string) RecurringTemplate {
+	rec := RecurringTemplate{}
+	if err := defaults.Set(&rec); err != nil {
+		logrus.Fatal("error setting defaults for recurring template: ", err)
+	}
+	rec.Id = GetUuid()
+	rec.Start = time.Now().Format(util.DateFormat)
+	rec.Amount = util.NewMoney(0, currency)
+	return rec
+}
+
+// InteractiveNewRecurringTemplate returns a new RecurringTemplate based on the user input.
+func InteractiveNewRecurringTemplate(s Schema) RecurringTemplate {
+	rec := NewRecurringTemplate(s.Currency)
+	rec.Identifier = util.AskString(
+		"Identifier",
+		"Unique human readable identifier",
+		SuggestNextIdentifier(s.RecurringTemplates.GetIdentifiables(), DefaultRecurringPrefix))
+	rec.RecordType = util.AskStringFromListSearch(
+		"Type",
+		"Type of the generated records",
+		util.SearchItems{
+			{Name: "Expense", Value: RecurringExpense, SearchValue: RecurringExpense},
+			{Name: "Invoice", Value: RecurringInvoice, SearchValue: RecurringInvoice},
+		})
+	rec.Name = util.AskString(
+		"Name",
+		"Name of the generated records, the period is appended",
+		"Rent")
+	rec.Interval = util.AskStringFromListSearch(
+		"Interval",
+		"How often the record repeats",
+		util.SearchItems{
+			{Name: "Monthly", Value: MonthlyInterval, SearchValue: MonthlyInterval},
+			{Name: "Quarterly", Value: QuarterlyInterval, SearchValue: QuarterlyInterval},
+			{Name: "Yearly", Value: YearlyInterval, SearchValue: YearlyInterval},
+		})
+	rec.Start = util.AskDate(
+		"Start",
+		"Date of the first occurrence",
+		time.Now())
+	rec.End = util.AskDate(
+		"End",
+		"Date of the last possible occurrence, leave empty for no end",
+		time.Now())
+	rec.Amount = util.AskMoney(
+		"Amount",
+		"Amount of each occurrence",
+		util.NewMoney(0, s.Currency),
+		s.Currency)
+	if rec.RecordType == RecurringExpense {
+		rec.ExpenseCategory = util.AskStringFromSearch(
+			"Expense Category",
+			"Used for journal generation",
+			s.JournalConfig.ExpenseCategories.SearchItems())
+	} else {
+		rec.Customer = NewRef(util.AskStringFromSearch(
+			"Customer",
+			"Customer receiving the invoices",
+			s.Parties.CustomersSearchItems()))
+	}
+	if util.AskBool("Project", "Are the records associated with a project?", false) {
+		rec.Project = NewRef(util.AskStringFromSearch(
+			"Project",
+			"Associated Project",
+			s.Projects.SearchItems()))
+	}
+	return rec
+}
+
+// SetId generates a unique id for the element if there isn't already one defined.
+func (r *RecurringTemplate) SetId() {
+	if r.Id != "" {
+		return
+	}
+	r.Id = GetUuid()
+}
+
+// GetId returns the id of the RecurringTemplate.
+func (r RecurringTemplate) GetId() string {
+	return r.Id
+}
+
+// GetIdentifier returns the identifier of the RecurringTemplate.
+func (r RecurringTemplate) GetIdentifier() string {
+	return r.Identifier
+}
+
+// String returns a human readable representation of the element.
+func (r RecurringTemplate) String() string {
+	return fmt.Sprintf("recurring %s %s %s (%s)", r.Interval, r.RecordType, r.Name, r.Identifier)
+}
+
+// Short returns a short representation of the element.
+func (r RecurringTemplate) Short() string {
+	return fmt.Sprintf("%s (%s)", r.Name, r.Identifier)
+}
+
+// Type returns a string with the type name of the element.
+func (r RecurringTemplate) Type() string {
+	return "RecurringTemplate"
+}
+
+// Conditions returns the validation conditions.
+func (r RecurringTemplate) Conditions() util.Conditions {
+	_, startErr := time.Parse(util.DateFormat, r.Start)
+	_, endErr := time.Parse(util.DateFormat, r.End)
+	return util.Conditions{
+		{
+			Condition: r.Id == "",
+			Message:   "unique identifier not set (Id is empty)",
+		},
+		{
+			Condition: r.Identifier == "",
+			Message:   "human readable identifier not set (Identifier is empty)",
+		},
+		{
+			Condition: r.Name == "",
+			Message:   "name not set (Name is empty)",
+		},
+		{
+			Condition: r.RecordType != RecurringExpense && r.RecordType != RecurringInvoice,
+			Message:   fmt.Sprintf("record type «%s» is neither %s nor %s", r.RecordType, RecurringExpense, RecurringInvoice),
+		},
+		{
+			Condition: r.Interval != MonthlyInterval && r.Interval != QuarterlyInterval && r.Interval != YearlyInterval,
+			Message:   fmt.Sprintf("interval «%s» is neither %s, %s nor %s", r.Interval, MonthlyInterval, QuarterlyInterval, YearlyInterval),
+		},
+		{
+			Condition: startErr != nil,
+			Message:   "start date is not a valid date (YYYY-MM-DD)",
+		},
+		{
+			Condition: r.End != "" && endErr != nil,
+			Message:   "end date is not a valid date (YYYY-MM-DD)",
+		},
+		{
+			Condition: r.Amount.Money == nil,
+			Message:   "amount not set (Amount is empty)",
+		},
+		{
+			Condition: r.RecordType == RecurringInvoice && r.Customer.Empty(),
+			Message:   "recurring invoices need a customer (Customer is empty)",
+		},
+	}
+}
+
+// Occurrences returns the dates of all occurrences up to the given date (inclusive)
+// which weren't generated yet.
+func (r RecurringTemplate) Occurrences(until time.Time) ([]time.Time, error) {
+	start, err := time.Parse(util.DateFormat, r.Start)
+	if err != nil {
+		return nil, fmt.Errorf("start of %s: %s", r.String(), err)
+	}
+	if r.End != "" {
+		end, err := time.Parse(util.DateFormat, r.End)
+		if err != nil {
+			return nil, fmt.Errorf("end of %s: %s", r.String(), err)
+		}
+		if end.Before(until) {
+			until = end
+		}
+	}
+	var last time.Time
+	if r.LastOccurrence != "" {
+		if last, err = time.Parse(util.DateFormat, r.LastOccurrence); err != nil {
+			return nil, fmt.Errorf("last occurrence of %s: %s", r.String(), err)
+		}
+	}
+	months := map[string]int{MonthlyInterval: 1, QuarterlyInterval: 3, YearlyInterval: 12}[r.Interval]
+	if months == 0 {
+		return nil, fmt.Errorf("unknown interval «%s» of %s", r.Interval, r.String())
+	}
+
+	var rsl []time.Time
+	for i := 0; ; i++ {
+		date := addMonths(start, i*months)
+		if date.After(until) {
+			break
+		}
+		if !date.After(last) {
+			continue
+		}
+		rsl = append(rsl, date)
+	}
+	return rsl, nil
+}
+
+// Period returns the human readable period of an occurrence (ex: «2022-03», «2022-Q1»,
+// «2022»).
+func (r RecurringTemplate) Period(date time.Time) string {
+	switch r.Interval {
+	case QuarterlyInterval:
+		return fmt.Sprintf("%d-Q%d", date.Year(), (int(date.Month())-1)/3+1)
+	case YearlyInterval:
+		return fmt.Sprint(date.Year())
+	}
+	return date.Format("2006-01")
+}
+
+// run generates the due records of the template and updates the last occurrence.
+func (r *RecurringTemplate) run(s *Schema, until time.Time) int {
+	dates, err := r.Occurrences(until)
+	if err != nil {
+		logrus.Error(err)
+		return 0
+	}
+	count := 0
+	for i := range dates {
+		name := fmt.Sprintf("%s %s", r.Name, r.Period(dates[i]))
+		date := dates[i].Format(util.DateFormat)
+		switch r.RecordType {
+		case RecurringExpense:
+			if !s.Expenses.contains(name, date) {
+				s.Expenses = append(s.Expenses, r.expense(*s, name, date))
+				count++
+			}
+		case RecurringInvoice:
+			if !s.Invoices.contains(name, date) {
+				s.Invoices = append(s.Invoices, r.invoice(*s, name, date))
+				count++
+			}
+		default:
+			logrus.Errorf("unknown record type «%s» of %s", r.RecordType, r.String())
+			return count
+		}
+		r.LastOccurrence = date
+	}
+	return count
+}
+
+// amount returns a copy of the amount of the template, thus the generated records don't
+// share the money object with the template and each other.
+func (r RecurringTemplate) amount() util.Money {
+	if r.Amount.Money == nil {
+		return util.Money{}
+	}
+	return util.NewMoney(r.Amount.Amount(), r.Amount.Currency().Code)
+}
+
+func (r RecurringTemplate) expense(s Schema, name, date string) Expense {
+	exp := NewExpenseWithUuid()
+	exp.Identifier = SuggestNextIdentifier(s.Expenses.GetIdentifiables(), DefaultExpensePrefix)
+	exp.Name = name
+	exp.Amount = r.amount()
+	exp.Path = ""
+	exp.DateOfAccrual = date
+	exp.Billable = !r.Customer.Empty()
+	exp.ObligedCustomer = r.Customer
+	exp.DateOfSettlement = ""
+	exp.ExpenseCategory = r.ExpenseCategory
+	exp.Internal = r.Project.Empty()
+	exp.Project = r.Project
+	logrus.Infof("generated expense %s", exp.String())
+	return exp
+}
+
+func (r RecurringTemplate) invoice(s Schema, name, date string) Invoice {
+	inv := NewInvoiceWithUuid()
+	inv.Identifier = SuggestNextIdentifier(s.Invoices.GetIdentifiables(), DefaultInvoicesPrefix)
+	inv.Name = name
+	inv.Amount = r.amount()
+	inv.Path = ""
+	inv.Customer = r.Customer
+	inv.SendDate = date
+	inv.DateOfSettlement = ""
+	inv.Project = r.Project
+	logrus.Infof("generated invoice %s", inv.String())
+	return inv
+}
+
+// addMonths adds the given number of months to the date. If the day doesn't exist in the
+// resulting month (ex: 31th of April) the last day of the month is used.
+func addMonths(date time.Time, months int) time.Time {
+	first := time.Date(date.Year(), date.Month()+time.Month(months), 1, 0, 0, 0, 0, date.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	day := date.Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, date.Location())
+}
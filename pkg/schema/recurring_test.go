@@ -0,0 +1,43 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestRecurringOccurrences(t *testing.T) {
+	rec := RecurringTemplate{
+		Identifier:     "r-1",
+		Interval:       MonthlyInterval,
+		Start:          "2022-01-31",
+		End:            "2022-06-30",
+		LastOccurrence: "2022-02-28",
+	}
+	dates, err := rec.Occurrences(time.Date(2022, time.December, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"2022-03-31", "2022-04-30", "2022-05-31", "2022-06-30"}
+	if len(dates) != len(expected) {
+		t.Fatalf("expected %d occurrences, got %v", len(expected), dates)
+	}
+	for i := range dates {
+		if dates[i].Format("2006-01-02") != expected[i] {
+			t.Errorf("occurrence %d: expected %s, got %s", i, expected[i], dates[i].Format("2006-01-02"))
+		}
+	}
+}
+
+func TestRecurringAmountCopy(t *testing.T) {
+	rec := RecurringTemplate{Amount: util.NewMoney(4200, "CHF")}
+	exp := rec.expense(Schema{}, "Rent 2022-03", "2022-03-01")
+	inv := rec.invoice(Schema{}, "Rent 2022-03", "2022-03-01")
+	if exp.Amount.Money == rec.Amount.Money || inv.Amount.Money == rec.Amount.Money || exp.Amount.Money == inv.Amount.Money {
+		t.Error("generated records share the money object of the template")
+	}
+	if exp.Amount.Value() != "42.00 CHF" || inv.Amount.Value() != "42.00 CHF" {
+		t.Errorf("unexpected amounts %s and %s", exp.Amount.Value(), inv.Amount.Value())
+	}
+}
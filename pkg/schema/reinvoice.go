@@ -0,0 +1,122 @@
+package schema
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/72nd/acc/pkg/util"
+	"github.com/sirupsen/logrus"
+)
+
+// IsReInvoiced returns true if the Expense was already forwarded to the obliged customer.
+func (e Expense) IsReInvoiced() bool {
+	return !e.Invoice.Empty()
+}
+
+// ReInvoiceable returns all billable expenses which weren't forwarded to the obliged
+// customer yet.
+func (e Expenses) ReInvoiceable() Expenses {
+	var rsl Expenses
+	for i := range e {
+		if e[i].Billable && !e[i].IsReInvoiced() {
+			rsl = append(rsl, e[i])
+		}
+	}
+	return rsl
+}
+
+// ReInvoicedBy returns all expenses which were forwarded with the given invoice.
+func (e Expenses) ReInvoicedBy(inv Invoice) Expenses {
+	var rsl Expenses
+	for i := range e {
+		if e[i].Invoice.Match(inv) {
+			rsl = append(rsl, e[i])
+		}
+	}
+	return rsl
+}
+
+// ByCustomer returns all expenses the given customer is obliged to pay.
+func (e Expenses) ByCustomer(cst Party) Expenses {
+	var rsl Expenses
+	for i := range e {
+		if e[i].ObligedCustomer.Match(cst) {
+			rsl = append(rsl, e[i])
+		}
+	}
+	return rsl
+}
+
+// ByProject returns all expenses associated with the given project.
+func (e Expenses) ByProject(prj Project) Expenses {
+	var rsl Expenses
+	for i := range e {
+		if e[i].Project.Match(prj) {
+			rsl = append(rsl, e[i])
+		}
+	}
+	return rsl
+}
+
+// GroupByCustomerAndProject splits the expenses into groups with the same obliged customer
+// and project. The order of the first occurrence is retained.
+func (e Expenses) GroupByCustomerAndProject() []Expenses {
+	var rsl []Expenses
+	index := make(map[string]int)
+	for i := range e {
+		key := fmt.Sprintf("%s/%s", e[i].ObligedCustomer.Id, e[i].Project.Id)
+		j, ok := index[key]
+		if !ok {
+			j = len(rsl)
+			index[key] = j
+			rsl = append(rsl, Expenses{})
+		}
+		rsl[j] = append(rsl[j], e[i])
+	}
+	return rsl
+}
+
+// linkInvoice sets the given invoice as re-invoicing invoice for the expenses with the
+// given ids.
+func (e Expenses) linkInvoice(exp Expenses, inv Invoice) {
+	for i := range e {
+		for j := range exp {
+			if e[i].Id == exp[j].Id {
+				e[i].Invoice = NewRef(inv.Id)
+			}
+		}
+	}
+}
+
+// ReInvoice creates a new invoice which forwards the given billable expenses (of the same
+// customer and project) to the obliged customer. The invoice is added to the schema and
+// linked in the expenses. The path of the invoice document is set to a PDF in the given
+// destination folder. The invoice is a draft dated today, it's booked once the draft flag
+// is removed (acc invoices send does so).
+func (s *Schema) ReInvoice(exp Expenses, dstFolder string) Invoice {
+	if len(exp) == 0 {
+		logrus.Fatal("no expenses to re-invoice given")
+	}
+	var sum int64
+	for i := range exp {
+		sum += amountOrZero(exp[i].Amount)
+	}
+	inv := NewInvoiceWithUuid()
+	inv.Identifier = SuggestNextIdentifier(s.Invoices.GetIdentifiables(), DefaultInvoicesPrefix)
+	inv.Name = "Re-invoicing of expenses"
+	if prj, err := s.Projects.ProjectByRef(exp[0].Project); err == nil {
+		inv.Name = fmt.Sprintf("Re-invoicing of expenses %s", prj.Name)
+	}
+	inv.Amount = util.NewMoney(sum, s.Currency)
+	inv.Path = path.Join(dstFolder, fmt.Sprintf("%s.pdf", inv.FileString()))
+	inv.Customer = exp[0].ObligedCustomer
+	inv.SendDate = time.Now().Format(util.DateFormat)
+	inv.Draft = true
+	inv.DateOfSettlement = ""
+	inv.Project = exp[0].Project
+	s.Invoices = append(s.Invoices, inv)
+	s.Expenses.linkInvoice(exp, inv)
+	logrus.Infof("generated invoice %s for %d expenses", inv.String(), len(exp))
+	return inv
+}
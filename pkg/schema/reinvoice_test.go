@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/72nd/acc/pkg/util"
+)
+
+func TestReInvoice(t *testing.T) {
+	s := Schema{
+		Currency: "CHF",
+		Projects: Projects{{Id: "prj-1", Identifier: "p-1", Name: "Hamlet"}},
+		Expenses: Expenses{
+			{Id: "exp-1", Identifier: "e-1", Amount: util.NewMoney(12000, "CHF"), Billable: true, ObligedCustomer: NewRef("cst-1"), Project: NewRef("prj-1")},
+			{Id: "exp-2", Identifier: "e-2", Amount: util.NewMoney(3050, "CHF"), Billable: true, ObligedCustomer: NewRef("cst-2")},
+			{Id: "exp-3", Identifier: "e-3", Amount: util.NewMoney(800, "CHF")},
+			{Id: "exp-4", Identifier: "e-4", Amount: util.NewMoney(2000, "CHF"), Billable: true, ObligedCustomer: NewRef("cst-1"), Project: NewRef("prj-1")},
+		},
+		Invoices: Invoices{{Id: "inv-1", Identifier: "i-1"}},
+	}
+	groups := s.Expenses.ReInvoiceable().GroupByCustomerAndProject()
+	if len(groups) != 2 || len(groups[0]) != 2 || groups[0][1].Id != "exp-4" || groups[1][0].Id != "exp-2" {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	inv := s.ReInvoice(groups[0], "invoices")
+	if inv.Identifier != "i-2" || inv.Name != "Re-invoicing of expenses Hamlet" || inv.Amount.Value() != "140.00 CHF" {
+		t.Errorf("unexpected invoice %s (%s)", inv.String(), inv.Amount.Value())
+	}
+	if !inv.Customer.Match(Party{Id: "cst-1"}) || !inv.Project.Match(s.Projects[0]) {
+		t.Errorf("expected customer cst-1 and project prj-1, got %s and %s", inv.Customer.Id, inv.Project.Id)
+	}
+	if !inv.IsDraft() || !util.ValidDate(util.DateFormat, inv.SendDate) || inv.Path != "invoices/"+inv.FileString()+".pdf" {
+		t.Errorf("expected dated draft with document in invoices, got «%s» and %s", inv.SendDate, inv.Path)
+	}
+	if len(s.Invoices) != 2 || len(s.Expenses.ReInvoicedBy(inv)) != 2 {
+		t.Errorf("invoice not added or not linked in the expenses")
+	}
+	if rest := s.Expenses.ReInvoiceable(); len(rest) != 1 || rest[0].Id != "exp-2" {
+		t.Errorf("expected only exp-2 to be left for re-invoicing, got %+v", rest)
+	}
+}
@@ -18,23 +18,33 @@ import (
 // Schema is the entirety of all business data for all the functionality of acc.
 type Schema struct {
 	Company             Company
+	CreditNotes         CreditNotes
 	Expenses            Expenses
+	FixedAssets         FixedAssets
+	InboxConfig         InboxConfig
 	Invoices            Invoices
 	JournalConfig       JournalConfig
 	Currency            string
+	MailConfig          MailConfig
 	MiscRecords         MiscRecords
+	Offers              Offers
 	Parties             PartiesCollection
+	PayrollConfig       PayrollConfig
 	Projects            Projects
+	RecurringTemplates  RecurringTemplates
+	Salaries            Salaries
 	Statement           Statement
 	AppendExpenseSuffix func(suffix string, overwrite bool)
 	AppendInvoiceSuffix func(suffix string, overwrite bool)
+	ExpenseFolder       func(s Schema, exp Expense) string
 	SaveFunc            func(s Schema)
 	FileHashes          map[string]string
 	BaseFolder          string
 }
 
-// Save the schema.
+// Save the schema. Missing content hashes of the files of the records are added.
 func (s Schema) Save() {
+	s.HashAssets()
 	cst := s.Parties.GetCustomerIdentifiables()
 	emp := s.Parties.GetEmployeeIdentifiables()
 	exp := s.Expenses.GetIdentifiables()
@@ -42,12 +52,20 @@ func (s Schema) Save() {
 	misc := s.MiscRecords.GetIdentifiables()
 	trn := s.Statement.GetIdentifiables()
 	prj := s.Projects.GetIdentifiables()
+	sal := s.Salaries.GetIdentifiables()
+	off := s.Offers.GetIdentifiables()
+	crn := s.CreditNotes.GetIdentifiables()
 
-	s.Expenses.SetReferenceDestinations(cst, emp, trn, prj)
-	s.Invoices.SetReferenceDestinations(cst, trn, prj)
+	s.CreditNotes.SetReferenceDestinations(inv)
+	s.Expenses.SetReferenceDestinations(cst, emp, trn, prj, inv)
+	s.Invoices.SetReferenceDestinations(cst, trn, prj, off)
 	s.MiscRecords.SetReferenceDestinations(trn)
+	s.FixedAssets.SetReferenceDestinations(exp)
+	s.Offers.SetReferenceDestinations(cst, prj)
+	s.Salaries.SetReferenceDestinations(emp, trn)
+	s.RecurringTemplates.SetReferenceDestinations(cst, prj)
 	s.Projects.SetReferenceDestinations(cst)
-	s.Statement.SetReferenceDestinations(append(append(exp, inv...), misc...), append(cst, emp...))
+	s.Statement.SetReferenceDestinations(append(append(append(append(exp, inv...), misc...), sal...), crn...), append(cst, emp...))
 	s.SaveFunc(s)
 }
 
@@ -55,24 +73,35 @@ func (s Schema) Save() {
 func (s Schema) ValidateProject() util.ValidateResults {
 	var rsl util.ValidateResults
 	rsl = append(rsl, util.Check(s.Company))
+	rsl = append(rsl, s.CreditNotes.Validate()...)
+	rsl = append(rsl, s.CreditNotes.ValidateAmounts(s)...)
 	rsl = append(rsl, s.Expenses.Validate()...)
+	rsl = append(rsl, s.FixedAssets.Validate()...)
 	rsl = append(rsl, s.Invoices.Validate()...)
 	rsl = append(rsl, s.MiscRecords.Validate()...)
+	rsl = append(rsl, s.Offers.Validate()...)
 	rsl = append(rsl, s.Statement.Validate()...)
 	rsl = append(rsl, s.Parties.Validate()...)
 	rsl = append(rsl, s.Projects.Validate()...)
+	rsl = append(rsl, s.Projects.ValidateBudgets(s)...)
+	rsl = append(rsl, s.RecurringTemplates.Validate()...)
+	rsl = append(rsl, s.Salaries.Validate()...)
 	rsl = append(rsl, s.Statement.Validate()...)
+	rsl = append(rsl, s.ValidateIntegrity()...)
 	return rsl
 }
 
-// ValidateAndReportProject validates the Schema and saves the report to the given path.
-func (s Schema) ValidateAndReportProject(path string) {
+// ValidateAndReportProject validates the Schema, saves the report in the given format to
+// the path and returns the validation results.
+func (s Schema) ValidateAndReportProject(path string, format util.ReportFormat) util.ValidateResults {
+	rsl := s.ValidateProject()
 	rpt := util.Report{
 		Title:           "Schema Validation Report",
 		ColumnTitles:    []string{"type", "element", "reason"},
-		ValidateResults: s.ValidateProject(),
+		ValidateResults: rsl,
 	}
-	rpt.Write(path)
+	rpt.WriteFormat(path, format)
+	return rsl
 }
 
 // Filter all elements of the schema by date (between from and to) as well as the identifier.
@@ -104,6 +133,8 @@ func (s Schema) FilterYear(year int) Schema {
 		s.Expenses, _ = s.Expenses.Filter(&from, &to, "")
 		s.Invoices, _ = s.Invoices.Filter(&from, &to)
 		s.Statement.Transactions, _ = s.Statement.FilterTransactions(&from, &to)
+		s.Salaries = s.Salaries.Filter(&from, &to)
+		s.CreditNotes = s.CreditNotes.Filter(&from, &to)
 	}
 	return s
 }
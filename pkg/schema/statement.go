@@ -72,6 +72,16 @@ func (t Statement) TransactionByRef(ref Ref) (*Transaction, error) {
 	return nil, fmt.Errorf("no transaction for id \"%s\" found", ref.Id)
 }
 
+// TransactionByIdent returns the Transaction with the given identifier.
+func (t Statement) TransactionByIdent(ident string) (*Transaction, error) {
+	for i := range t.Transactions {
+		if t.Transactions[i].Identifier == ident {
+			return &t.Transactions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no transaction for identifier \"%s\" found", ident)
+}
+
 // Type returns a string with the type name of the element.
 func (t Statement) Type() string {
 	return "Bank-Statement"
@@ -32,6 +32,7 @@ type Transaction struct {
 	AssociatedDocument Ref                  `yaml:"associatedDocumentId" default:"" query:"expense,invoice,misc"`
 	Date               string               `yaml:"date" default:""`
 	JournalMode        JournalMode          `yaml:"journalMode" default:"0"`
+	JournalOverride    *JournalOverride     `yaml:"journalOverride,omitempty"`
 }
 
 func NewTransaction() Transaction {
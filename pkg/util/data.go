@@ -22,6 +22,31 @@ const (
 
 const DateFormat = "2006-01-02"
 
+// alternativeDateFormats are unambiguous date formats which can be converted to
+// DateFormat without guessing the order of day and month.
+var alternativeDateFormats = []string{
+	"2006-1-2",
+	"2006/1/2",
+	"2006.1.2",
+	"20060102",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	time.RFC3339,
+}
+
+// NormalizeDate converts a date given in one of the alternative formats (ex: 2019.12.24,
+// 2019/12/24, 2019-12-24T10:00:00Z) to DateFormat. False is returned if the input can't
+// be converted.
+func NormalizeDate(input string) (string, bool) {
+	input = strings.TrimSpace(input)
+	for _, format := range append([]string{DateFormat}, alternativeDateFormats...) {
+		if date, err := time.Parse(format, input); err == nil {
+			return date.Format(DateFormat), true
+		}
+	}
+	return input, false
+}
+
 func Contains(list []string, key string) bool {
 	for i := range list {
 		if list[i] == key {
@@ -187,12 +187,6 @@ func AskMoney(name, desc string, defaultValue Money, currency string) Money {
 }
 
 func AskDate(name, desc string, defaultValue time.Time) string {
-	possibleLayouts := []string{
-		GermanLayout,
-		"01.02.2006",
-		"2006-01-02",
-		"2006.02.01",
-	}
 	header(name, "DD-MM-YYYY", desc, fmt.Sprintf("Enter for empty, 'T' for today (%s)", defaultValue.Format(GermanLayout)), true)
 	input := getInput()
 	if input == "" {
@@ -201,21 +195,50 @@ func AskDate(name, desc string, defaultValue time.Time) string {
 	if input == "T" {
 		return defaultValue.Format(DateFormat)
 	}
-	success := false
-	var value time.Time
-	for i := range possibleLayouts {
-		var err error
-		value, err = time.Parse(possibleLayouts[i], input)
-		if err == nil {
-			success = true
-			break
-		}
-	}
-	if !success {
+	value, ok := parseInputDate(input)
+	if !ok {
 		logrus.Warnf("Could not parse input as date with format: %s", DateFormat)
 		return AskDate(name, desc, defaultValue)
 	}
-	return value.Format(DateFormat)
+	return value
+}
+
+// AskDateWithDefault asks for a date, enter keeps the default (YYYY-MM-DD) and 'T' returns
+// the date of today. Behaves like AskDate if the default is empty.
+func AskDateWithDefault(name, desc, defaultValue string) string {
+	if defaultValue == "" {
+		return AskDate(name, desc, time.Now())
+	}
+	header(name, "DD-MM-YYYY", desc, fmt.Sprintf("Enter for default (%s), 'T' for today (%s)", defaultValue, time.Now().Format(GermanLayout)), true)
+	input := getInput()
+	if input == "" {
+		return defaultValue
+	}
+	if input == "T" {
+		return time.Now().Format(DateFormat)
+	}
+	value, ok := parseInputDate(input)
+	if !ok {
+		logrus.Warnf("Could not parse input as date with format: %s", DateFormat)
+		return AskDateWithDefault(name, desc, defaultValue)
+	}
+	return value
+}
+
+// parseInputDate parses a date entered by the user and returns it as YYYY-MM-DD.
+func parseInputDate(input string) (string, bool) {
+	possibleLayouts := []string{
+		GermanLayout,
+		"01.02.2006",
+		"2006-01-02",
+		"2006.02.01",
+	}
+	for i := range possibleLayouts {
+		if value, err := time.Parse(possibleLayouts[i], input); err == nil {
+			return value.Format(DateFormat), true
+		}
+	}
+	return "", false
 }
 
 func AskForStategy() Strategy {
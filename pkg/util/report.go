@@ -2,9 +2,13 @@ package util
 
 import (
 	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
 	"github.com/olekukonko/tablewriter"
 	"github.com/sirupsen/logrus"
 	"io/ioutil"
+	"os"
 	"strings"
 	"text/template"
 	"unicode/utf8"
@@ -23,6 +27,43 @@ The flaws levels states the moment, when a validation flaw should be fixed:
 - BeforeExportFlaw: Should be fixed before using export functions.
 `
 
+// ReportFormat states the file format of a Report.
+type ReportFormat int
+
+const (
+	// TextReport is a human readable text table.
+	TextReport ReportFormat = iota
+	// JsonReport lists the flaws as JSON for scripts.
+	JsonReport
+	// JUnitReport lists the flaws as JUnit XML which can be displayed by CI systems.
+	JUnitReport
+)
+
+// ReportFormatFromString returns the ReportFormat for the given name.
+func ReportFormatFromString(input string) (ReportFormat, error) {
+	switch strings.ToLower(input) {
+	case "", "text", "txt":
+		return TextReport, nil
+	case "json":
+		return JsonReport, nil
+	case "junit", "xml":
+		return JUnitReport, nil
+	}
+	return TextReport, fmt.Errorf("unknown report format «%s», use text, json or junit", input)
+}
+
+// Extension returns the file extension for the format.
+func (f ReportFormat) Extension() string {
+	switch f {
+	case JsonReport:
+		return "json"
+	case JUnitReport:
+		return "xml"
+	default:
+		return "txt"
+	}
+}
+
 type Report struct {
 	Title           string
 	ColumnTitles    []string
@@ -62,8 +103,150 @@ func (r Report) renderTable() string {
 
 // Write renders the Report and writes it to the given path.
 func (r Report) Write(pth string) {
-	output := r.Render()
-	if err := ioutil.WriteFile(pth, []byte(output), 0644); err != nil {
+	r.WriteFormat(pth, TextReport)
+}
+
+// WriteFormat renders the Report in the given format and writes it to the given path. The
+// path - writes the report to the standard output.
+func (r Report) WriteFormat(pth string, format ReportFormat) {
+	var output []byte
+	var err error
+	switch format {
+	case JsonReport:
+		output, err = r.RenderJson()
+	case JUnitReport:
+		output, err = r.RenderJUnit()
+	default:
+		output = []byte(r.Render())
+	}
+	if err != nil {
+		logrus.Fatal("error while rendering the report: ", err)
+	}
+	if pth == "-" {
+		_, err = os.Stdout.Write(output)
+	} else {
+		err = ioutil.WriteFile(pth, output, 0644)
+	}
+	if err != nil {
 		logrus.Fatal(err)
 	}
 }
+
+// reportFlaw is a single flaw in the machine readable reports.
+type reportFlaw struct {
+	Type       string `json:"type"`
+	Id         string `json:"id,omitempty"`
+	Identifier string `json:"identifier,omitempty"`
+	Element    string `json:"element"`
+	Message    string `json:"message"`
+	Level      string `json:"level"`
+}
+
+// elementIds returns the id and the identifier of the element if it has them.
+func elementIds(ele Validatable) (string, string) {
+	var id, ident string
+	if idt, ok := ele.(interface{ GetId() string }); ok {
+		id = idt.GetId()
+	}
+	if idt, ok := ele.(interface{ GetIdentifier() string }); ok {
+		ident = idt.GetIdentifier()
+	}
+	return id, ident
+}
+
+func (r Report) flaws() []reportFlaw {
+	rsl := []reportFlaw{}
+	for _, res := range r.ValidateResults {
+		id, ident := elementIds(res.Element)
+		for _, cnd := range res.Conditions {
+			rsl = append(rsl, reportFlaw{
+				Type:       res.Element.Type(),
+				Id:         id,
+				Identifier: ident,
+				Element:    res.Element.String(),
+				Message:    cnd.Message,
+				Level:      cnd.Level.String(),
+			})
+		}
+	}
+	return rsl
+}
+
+// RenderJson returns the report as JSON.
+func (r Report) RenderJson() ([]byte, error) {
+	flaws := r.flaws()
+	data := struct {
+		Title string       `json:"title"`
+		Valid bool         `json:"valid"`
+		Flaws []reportFlaw `json:"flaws"`
+	}{r.Title, len(flaws) == 0, flaws}
+	rsl, err := json.MarshalIndent(data, "", "  ")
+	return append(rsl, '\n'), err
+}
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Name     string       `xml:"name,attr"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string         `xml:"name,attr"`
+	ClassName string         `xml:"classname,attr"`
+	Failures  []junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// RenderJUnit returns the report as JUnit XML. Each element type is a test suite and each
+// validated element a test case which fails with its flaws.
+func (r Report) RenderJUnit() ([]byte, error) {
+	rsl := junitSuites{Name: r.Title}
+	index := make(map[string]int)
+	for _, res := range r.ValidateResults {
+		typ := res.Element.Type()
+		i, ok := index[typ]
+		if !ok {
+			i = len(rsl.Suites)
+			index[typ] = i
+			rsl.Suites = append(rsl.Suites, junitSuite{Name: typ})
+		}
+		name := res.Element.String()
+		if id, _ := elementIds(res.Element); id != "" {
+			name = fmt.Sprintf("%s [%s]", name, id)
+		}
+		tc := junitCase{Name: name, ClassName: typ}
+		for _, cnd := range res.Conditions {
+			tc.Failures = append(tc.Failures, junitFailure{
+				Message: cnd.Message,
+				Type:    cnd.Level.String(),
+				Text:    fmt.Sprintf("%s: %s (%s)", name, cnd.Message, cnd.Level),
+			})
+		}
+		rsl.Suites[i].Cases = append(rsl.Suites[i].Cases, tc)
+		rsl.Suites[i].Tests++
+		rsl.Tests++
+		if len(tc.Failures) != 0 {
+			rsl.Suites[i].Failures++
+			rsl.Failures++
+		}
+	}
+	out, err := xml.MarshalIndent(rsl, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(out, '\n')...), nil
+}
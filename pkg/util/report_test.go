@@ -0,0 +1,109 @@
+package util
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type testElement struct {
+	id    string
+	flaws Conditions
+}
+
+func (e testElement) Type() string           { return "Test" }
+func (e testElement) String() string         { return "element " + e.id }
+func (e testElement) GetId() string          { return e.id }
+func (e testElement) Conditions() Conditions { return e.flaws }
+
+func testResults() ValidateResults {
+	return ValidateResults{
+		Check(testElement{id: "t-1"}),
+		Check(testElement{id: "t-2", flaws: Conditions{
+			{Condition: true, Message: "amount <missing>", Level: BeforeExportFlaw},
+			{Condition: false, Message: "not a flaw", Level: FundamentalFlaw},
+			{Condition: true, Message: "id not set", Level: FundamentalFlaw},
+		}}),
+	}
+}
+
+func TestFlawLevelFromString(t *testing.T) {
+	levels := map[string]FlawLevel{
+		"fundamental":      FundamentalFlaw,
+		"before-import":    BeforeImportFlaw,
+		"before merge":     BeforeMergeFlaw,
+		"BeforeExportFlaw": BeforeExportFlaw,
+		"any":              UndefinedFlaw,
+	}
+	for input, expected := range levels {
+		if lvl, err := FlawLevelFromString(input); err != nil || lvl != expected {
+			t.Errorf("%s was parsed as %s (%v), expected %s", input, lvl, err, expected)
+		}
+	}
+	if _, err := FlawLevelFromString("urgent"); err == nil {
+		t.Error("unknown level was accepted")
+	}
+}
+
+func TestCountFlaws(t *testing.T) {
+	rsl := testResults()
+	for lvl, expected := range map[FlawLevel]int{
+		FundamentalFlaw:  1,
+		BeforeImportFlaw: 1,
+		BeforeExportFlaw: 2,
+		UndefinedFlaw:    2,
+	} {
+		if n := rsl.CountFlaws(lvl); n != expected {
+			t.Errorf("%d flaws counted for %s, expected %d", n, lvl, expected)
+		}
+	}
+}
+
+func TestCountUndefinedFlaws(t *testing.T) {
+	rsl := ValidateResults{Check(testElement{id: "t-3", flaws: Conditions{
+		{Condition: true, Message: "name not set"},
+	}})}
+	for _, lvl := range []FlawLevel{FundamentalFlaw, BeforeExportFlaw, UndefinedFlaw} {
+		if n := rsl.CountFlaws(lvl); n != 1 {
+			t.Errorf("flaw without level not counted for %s", lvl)
+		}
+	}
+}
+
+func TestRenderJson(t *testing.T) {
+	rpt := Report{Title: "Test", ValidateResults: testResults()}
+	out, err := rpt.RenderJson()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data struct {
+		Valid bool
+		Flaws []reportFlaw
+	}
+	if err := json.Unmarshal(out, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.Valid || len(data.Flaws) != 2 {
+		t.Fatalf("unexpected report %s", out)
+	}
+	if data.Flaws[1].Id != "t-2" || data.Flaws[1].Level != "fundamental" {
+		t.Errorf("unexpected flaw %+v", data.Flaws[1])
+	}
+}
+
+func TestRenderJUnit(t *testing.T) {
+	rpt := Report{Title: "Test", ValidateResults: testResults()}
+	out, err := rpt.RenderJUnit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`<testsuites name="Test" tests="2" failures="1">`,
+		`<testcase name="element t-1 [t-1]" classname="Test"></testcase>`,
+		`<failure message="amount &lt;missing&gt;" type="before export">`,
+	} {
+		if !strings.Contains(string(out), expected) {
+			t.Errorf("%s not found in %s", expected, out)
+		}
+	}
+}
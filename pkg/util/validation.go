@@ -43,6 +43,46 @@ func (l FlawLevel) String() string {
 	}
 }
 
+// Severity returns the rank of the flaw level, the higher the more urgent the flaw is.
+// Undefined flaws have the lowest severity.
+func (l FlawLevel) Severity() int {
+	switch l {
+	case FundamentalFlaw:
+		return 4
+	case BeforeImportFlaw:
+		return 3
+	case BeforeMergeFlaw:
+		return 2
+	case BeforeExportFlaw:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// FlawLevelFromString parses the name of a flaw level (ex: fundamental, before-export).
+// «any» is an alias for the undefined level, which includes all flaws as threshold.
+func FlawLevelFromString(input string) (FlawLevel, error) {
+	name := strings.ToLower(input)
+	for _, sep := range []string{" ", "-", "_"} {
+		name = strings.ReplaceAll(name, sep, "")
+	}
+	name = strings.TrimSuffix(name, "flaw")
+	switch name {
+	case "any", "undefined":
+		return UndefinedFlaw, nil
+	case "fundamental":
+		return FundamentalFlaw, nil
+	case "beforeimport":
+		return BeforeImportFlaw, nil
+	case "beforemerge":
+		return BeforeMergeFlaw, nil
+	case "beforeexport":
+		return BeforeExportFlaw, nil
+	}
+	return UndefinedFlaw, fmt.Errorf("unknown flaw level «%s», use fundamental, before-import, before-merge, before-export or any", input)
+}
+
 type Checkable interface {
 	Validate() ValidateResults
 }
@@ -126,6 +166,21 @@ func (v ValidateResults) String() string {
 	return result
 }
 
+// CountFlaws returns the number of flaws with at least the severity of the given level.
+// As their urgency is unknown, flaws without a level are counted for every threshold.
+func (v ValidateResults) CountFlaws(threshold FlawLevel) int {
+	n := 0
+	for i := range v {
+		for j := range v[i].Conditions {
+			lvl := v[i].Conditions[j].Level
+			if lvl == UndefinedFlaw || lvl.Severity() >= threshold.Severity() {
+				n++
+			}
+		}
+	}
+	return n
+}
+
 func (v ValidateResults) TableRows() [][]string {
 	var results [][]string
 	for i := range v {